@@ -0,0 +1,217 @@
+package crypt
+
+import (
+	"fmt"
+	"runtime"
+
+	"golang.org/x/sys/cpu"
+)
+
+// Algorithm identifies an AEAD cipher in the tagged ciphertext format. The
+// values are written to storage as the first byte of every tagged
+// ciphertext, so they are frozen: never renumber an existing one.
+type Algorithm byte
+
+const (
+	// AlgAesGcm is AES-GCM with a 96-bit nonce; the key length (16, 24 or 32
+	// bytes) selects AES-128, AES-192 or AES-256.
+	AlgAesGcm Algorithm = 0x01
+	// AlgChacha20poly1305 is ChaCha20-Poly1305 with a 96-bit nonce.
+	AlgChacha20poly1305 Algorithm = 0x02
+	// AlgXChacha20poly1305 is XChaCha20-Poly1305 with a 192-bit nonce.
+	AlgXChacha20poly1305 Algorithm = 0x03
+)
+
+// String returns the conventional name of the algorithm.
+func (a Algorithm) String() string {
+	switch a {
+	case AlgAesGcm:
+		return "AES-GCM"
+	case AlgChacha20poly1305:
+		return "ChaCha20-Poly1305"
+	case AlgXChacha20poly1305:
+		return "XChaCha20-Poly1305"
+	default:
+		return fmt.Sprintf("Algorithm(%d)", byte(a))
+	}
+}
+
+// AEAD is the interface shared by the reusable cipher handles [AesGcm],
+// [Chacha20poly1305] and [XChacha20poly1305], so code can be written once
+// against any of them. Seal and Open use the nonce-appended layout
+// [nonce + ciphertext + tag] and append to dst.
+type AEAD interface {
+	// Algorithm reports which cipher the handle implements.
+	Algorithm() Algorithm
+	// NonceSize returns the length of the nonce at the start of every
+	// ciphertext.
+	NonceSize() int
+	// Overhead returns the difference between the ciphertext and plaintext
+	// lengths.
+	Overhead() int
+	// Seal encrypts and authenticates plaintext and additionalData under a
+	// fresh random nonce and appends nonce + ciphertext + tag to dst.
+	Seal(dst, plaintext, additionalData []byte) ([]byte, error)
+	// Open authenticates and decrypts a nonce-appended ciphertext and
+	// appends the plaintext to dst.
+	Open(dst, ciphertext, additionalData []byte) ([]byte, error)
+}
+
+var (
+	_ AEAD = (*AesGcm)(nil)
+	_ AEAD = (*Chacha20poly1305)(nil)
+	_ AEAD = (*XChacha20poly1305)(nil)
+)
+
+// NewAEAD returns the cipher handle for alg under key.
+func NewAEAD(alg Algorithm, key []byte) (AEAD, error) {
+	switch alg {
+	case AlgAesGcm:
+		return NewAesGcm(key)
+	case AlgChacha20poly1305:
+		return NewChacha20poly1305(key)
+	case AlgXChacha20poly1305:
+		return NewXChacha20poly1305(key)
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, alg)
+	}
+}
+
+// PreferredAlgorithm returns [AlgAesGcm] when the CPU has hardware support for
+// both AES and the GCM carry-less multiply, and [AlgXChacha20poly1305]
+// otherwise, where a constant-time software AES would be much slower. Both
+// accept the same 32-byte key, so the choice never changes what the caller
+// must supply.
+func PreferredAlgorithm() Algorithm {
+	if hasAESGCMHardwareSupport() {
+		return AlgAesGcm
+	}
+	return AlgXChacha20poly1305
+}
+
+// hasAESGCMHardwareSupport mirrors the check crypto/tls uses to order its
+// cipher suites.
+func hasAESGCMHardwareSupport() bool {
+	switch runtime.GOARCH {
+	case "amd64", "386":
+		return cpu.X86.HasAES && cpu.X86.HasPCLMULQDQ
+	case "arm64":
+		return cpu.ARM64.HasAES && cpu.ARM64.HasPMULL
+	case "s390x":
+		return cpu.S390X.HasAES && cpu.S390X.HasAESCTR && cpu.S390X.HasGHASH
+	case "ppc64", "ppc64le":
+		// POWER8 and later always ship the AES and GHASH instructions.
+		return true
+	default:
+		return false
+	}
+}
+
+// taggedHeaderSize is the length of the algorithm ID that leads every tagged
+// ciphertext.
+const taggedHeaderSize = 1
+
+// taggedAAD returns the additional data actually authenticated for a tagged
+// ciphertext: the algorithm ID followed by the caller-supplied AAD. Binding
+// the ID means a blob whose tag byte was rewritten fails authentication
+// instead of being handed to a different cipher.
+func taggedAAD(alg Algorithm, additionalData []byte) []byte {
+	out := make([]byte, 0, taggedHeaderSize+len(additionalData))
+	out = append(out, byte(alg))
+	return append(out, additionalData...)
+}
+
+// SealTagged seals plaintext with a and appends a self-describing ciphertext
+// to dst [algorithm ID (1 byte) + nonce + ciphertext + tag]. The algorithm ID
+// is authenticated together with additionalData (which may be nil), and the
+// result can be opened by [OpenTagged] or [DecryptAuto].
+func SealTagged(a AEAD, dst, plaintext, additionalData []byte) ([]byte, error) {
+	alg := a.Algorithm()
+	dst = append(dst, byte(alg))
+	out, err := a.Seal(dst, plaintext, taggedAAD(alg, additionalData))
+	if err != nil {
+		return dst[:len(dst)-taggedHeaderSize], err
+	}
+	return out, nil
+}
+
+// OpenTagged authenticates and decrypts a tagged ciphertext produced by
+// [SealTagged] and appends the plaintext to dst. It fails if the ciphertext is
+// tagged with an algorithm other than a's.
+func OpenTagged(a AEAD, dst, ciphertext, additionalData []byte) ([]byte, error) {
+	alg, err := TaggedAlgorithm(ciphertext)
+	if err != nil {
+		return dst, err
+	}
+	if alg != a.Algorithm() {
+		return dst, fmt.Errorf("%w: ciphertext algorithm %v does not match %v", ErrUnsupportedAlgorithm, alg, a.Algorithm())
+	}
+	return a.Open(dst, ciphertext[taggedHeaderSize:], taggedAAD(alg, additionalData))
+}
+
+// TaggedAlgorithm reports the algorithm a tagged ciphertext was sealed with.
+// It only reads the tag byte; nothing is authenticated until the ciphertext
+// is opened.
+func TaggedAlgorithm(ciphertext []byte) (Algorithm, error) {
+	if len(ciphertext) < taggedHeaderSize {
+		return 0, ErrCiphertextTooShort
+	}
+
+	alg := Algorithm(ciphertext[0])
+	switch alg {
+	case AlgAesGcm, AlgChacha20poly1305, AlgXChacha20poly1305:
+		return alg, nil
+	default:
+		return 0, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, alg)
+	}
+}
+
+// EncryptByteTagged encrypts and authenticates the given message (bytes) with
+// alg under key, additionally authenticating additionalData (which may be
+// nil), and returns a tagged ciphertext [algorithm ID + nonce + ciphertext].
+func EncryptByteTagged(alg Algorithm, key, input, additionalData []byte) (ciphertext []byte, err error) {
+	a, err := NewAEAD(alg, key)
+	if err != nil {
+		return
+	}
+	return SealTagged(a, nil, input, additionalData)
+}
+
+// EncryptByteAuto is [EncryptByteTagged] with the algorithm chosen by
+// [PreferredAlgorithm], so it requires a 256-bit key. The tag records the
+// choice, so [DecryptByteAuto] opens the result on any machine.
+func EncryptByteAuto(key, input, additionalData []byte) (ciphertext []byte, err error) {
+	return EncryptByteTagged(PreferredAlgorithm(), key, input, additionalData)
+}
+
+// EncryptAuto is the string form of [EncryptByteAuto].
+func EncryptAuto(key []byte, text string, additionalData []byte) (ciphertext []byte, err error) {
+	return EncryptByteAuto(key, []byte(text), additionalData)
+}
+
+// DecryptByteAuto decrypts and authenticates a tagged ciphertext produced by
+// [EncryptByteTagged], [EncryptByteAuto] or [SealTagged], dispatching on its
+// algorithm ID. additionalData must match the value supplied at encryption.
+func DecryptByteAuto(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	alg, err := TaggedAlgorithm(ciphertext)
+	if err != nil {
+		return
+	}
+
+	a, err := NewAEAD(alg, key)
+	if err != nil {
+		return
+	}
+	return OpenTagged(a, nil, ciphertext, additionalData)
+}
+
+// DecryptAuto is the string form of [DecryptByteAuto].
+func DecryptAuto(key, ciphertext, additionalData []byte) (text string, err error) {
+	plaintext, err := DecryptByteAuto(key, ciphertext, additionalData)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
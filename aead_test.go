@@ -0,0 +1,133 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+var allAlgorithms = []Algorithm{AlgAesGcm, AlgChacha20poly1305, AlgXChacha20poly1305}
+
+func TestTaggedRoundTrip(t *testing.T) {
+	key := mustBytes(t, 32)
+	in := []byte("the quick brown fox jumps over the lazy dog")
+	aad := []byte("record:42")
+
+	for _, alg := range allAlgorithms {
+		t.Run(alg.String(), func(t *testing.T) {
+			ciphertext, err := EncryptByteTagged(alg, key, in, aad)
+			if err != nil {
+				t.Fatalf("EncryptByteTagged: %v", err)
+			}
+			if Algorithm(ciphertext[0]) != alg {
+				t.Errorf("tag byte = %#x, want %#x", ciphertext[0], byte(alg))
+			}
+			if got, err := TaggedAlgorithm(ciphertext); err != nil || got != alg {
+				t.Errorf("TaggedAlgorithm = %v, %v; want %v", got, err, alg)
+			}
+
+			got, err := DecryptByteAuto(key, ciphertext, aad)
+			if err != nil {
+				t.Fatalf("DecryptByteAuto: %v", err)
+			}
+			if !bytes.Equal(got, in) {
+				t.Errorf("round-trip mismatch: got %q, want %q", got, in)
+			}
+
+			if _, err := DecryptByteAuto(key, ciphertext, []byte("record:7")); err == nil {
+				t.Error("decryption with wrong AAD succeeded, want failure")
+			}
+		})
+	}
+}
+
+func TestTaggedHandles(t *testing.T) {
+	key := mustBytes(t, 32)
+	in := []byte("attack at dawn")
+
+	for _, alg := range allAlgorithms {
+		t.Run(alg.String(), func(t *testing.T) {
+			a, err := NewAEAD(alg, key)
+			if err != nil {
+				t.Fatalf("NewAEAD: %v", err)
+			}
+			if a.Algorithm() != alg {
+				t.Errorf("Algorithm() = %v, want %v", a.Algorithm(), alg)
+			}
+
+			prefix := []byte("hdr:")
+			sealed, err := SealTagged(a, append([]byte(nil), prefix...), in, nil)
+			if err != nil {
+				t.Fatalf("SealTagged: %v", err)
+			}
+			if want := len(prefix) + 1 + len(in) + a.Overhead(); len(sealed) != want {
+				t.Errorf("sealed len = %d, want %d", len(sealed), want)
+			}
+			got, err := OpenTagged(a, nil, sealed[len(prefix):], nil)
+			if err != nil || !bytes.Equal(got, in) {
+				t.Errorf("OpenTagged = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestTaggedRejects(t *testing.T) {
+	key := mustBytes(t, 32)
+
+	t.Run("rewrittenTag", func(t *testing.T) {
+		// the tag is authenticated, so pointing the blob at another
+		// algorithm must fail rather than decrypt under the wrong cipher
+		ciphertext, err := EncryptByteTagged(AlgXChacha20poly1305, key, []byte("secret"), nil)
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		ciphertext[0] = byte(AlgChacha20poly1305)
+		if _, err := DecryptByteAuto(key, ciphertext, nil); err == nil {
+			t.Error("decryption with rewritten tag succeeded, want failure")
+		}
+	})
+
+	t.Run("mismatchedHandle", func(t *testing.T) {
+		ciphertext, err := EncryptByteTagged(AlgAesGcm, key, []byte("secret"), nil)
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		a, err := NewAEAD(AlgXChacha20poly1305, key)
+		if err != nil {
+			t.Fatalf("NewAEAD: %v", err)
+		}
+		if _, err := OpenTagged(a, nil, ciphertext, nil); err == nil {
+			t.Error("OpenTagged with mismatched handle succeeded, want failure")
+		}
+	})
+
+	t.Run("unknownTag", func(t *testing.T) {
+		for _, blob := range [][]byte{nil, {0x00, 1, 2, 3}, {0xFF, 1, 2, 3}} {
+			if _, err := DecryptByteAuto(key, blob, nil); err == nil {
+				t.Errorf("DecryptByteAuto(%x) succeeded, want failure", blob)
+			}
+		}
+		if _, err := NewAEAD(Algorithm(0x7F), key); err == nil {
+			t.Error("NewAEAD with unknown algorithm succeeded, want failure")
+		}
+	})
+}
+
+func TestEncryptAuto(t *testing.T) {
+	key := mustBytes(t, 32)
+	const text = "attack at dawn"
+
+	ciphertext, err := EncryptAuto(key, text, nil)
+	if err != nil {
+		t.Fatalf("EncryptAuto: %v", err)
+	}
+	if alg, _ := TaggedAlgorithm(ciphertext); alg != PreferredAlgorithm() {
+		t.Errorf("tag = %v, want PreferredAlgorithm() = %v", alg, PreferredAlgorithm())
+	}
+	got, err := DecryptAuto(key, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("DecryptAuto: %v", err)
+	}
+	if got != text {
+		t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
-	"errors"
 	"fmt"
 )
 
@@ -24,19 +23,20 @@ func aesGCM(key []byte) (cipher.AEAD, error) {
 	// to select AES-128, AES-192, or AES-256
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return nil, fmt.Errorf("error creating cipher.Block: %v", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
 	}
 
 	aead, err := cipher.NewGCM(block)
 	if err != nil {
-		return nil, fmt.Errorf("error creating AEAD: %v", err)
+		return nil, fmt.Errorf("error creating AEAD: %w", err)
 	}
 	return aead, nil
 }
 
-// EncryptByteAesGcm encrypts and authenticates the given message (bytes) with AES in GCM mode
-// using the given 128, 192 or 256-bit key.
-func EncryptByteAesGcm(key []byte, input []byte) (ciphertext []byte, nonce []byte, err error) {
+// encryptByteAesGcm is the shared AES-GCM encryption core. It seals input
+// under key with a fresh random 96-bit nonce and additionally authenticates
+// additionalData (which may be nil).
+func encryptByteAesGcm(key, input, additionalData []byte) (ciphertext []byte, nonce []byte, err error) {
 	aead, err := aesGCM(key)
 	if err != nil {
 		return
@@ -44,7 +44,7 @@ func EncryptByteAesGcm(key []byte, input []byte) (ciphertext []byte, nonce []byt
 
 	// reject oversized input so Seal returns an error rather than panicking
 	if uint64(len(input)) > gcmMaxPlaintextSize {
-		err = errors.New("plaintext too large")
+		err = ErrPlaintextTooLarge
 		return
 	}
 
@@ -52,16 +52,22 @@ func EncryptByteAesGcm(key []byte, input []byte) (ciphertext []byte, nonce []byt
 	nonce = make([]byte, aead.NonceSize())
 	_, err = rand.Read(nonce)
 	if err != nil {
-		err = fmt.Errorf("error generating nonce: %v", err)
+		err = fmt.Errorf("error generating nonce: %w", err)
 		return
 	}
 
 	// encrypt the data
-	ciphertext = aead.Seal(nil, nonce, input, nil)
+	ciphertext = aead.Seal(nil, nonce, input, additionalData)
 
 	return
 }
 
+// EncryptByteAesGcm encrypts and authenticates the given message (bytes) with AES in GCM mode
+// using the given 128, 192 or 256-bit key.
+func EncryptByteAesGcm(key []byte, input []byte) (ciphertext []byte, nonce []byte, err error) {
+	return encryptByteAesGcm(key, input, nil)
+}
+
 // EncryptAesGcm encrypts and authenticates the given message (string) with AES in GCM mode
 // using the given 128, 192 or 256-bit key.
 func EncryptAesGcm(key []byte, text string) (ciphertext []byte, nonce []byte, err error) {
@@ -69,19 +75,20 @@ func EncryptAesGcm(key []byte, text string) (ciphertext []byte, nonce []byte, er
 }
 
 // decryptByteAesGcm is the shared AES-GCM decryption core operating on an
-// already-built AEAD. It rejects a wrong-length nonce, which GCM's Open would
+// already-built AEAD. additionalData must be the same value supplied at
+// encryption (nil if none). It rejects a wrong-length nonce, which GCM's Open would
 // otherwise panic on. Oversized ciphertext needs no guard here: unlike
 // ChaCha20-Poly1305, GCM's Open returns an error rather than panicking.
-func decryptByteAesGcm(aead cipher.AEAD, nonce, ciphertext []byte) (plaintext []byte, err error) {
+func decryptByteAesGcm(aead cipher.AEAD, nonce, ciphertext, additionalData []byte) (plaintext []byte, err error) {
 	if len(nonce) != aead.NonceSize() {
-		err = errors.New("invalid nonce length")
+		err = ErrInvalidNonce
 		return
 	}
 
 	// decrypt the data
-	plaintext, err = aead.Open(nil, nonce, ciphertext, nil)
+	plaintext, err = aead.Open(nil, nonce, ciphertext, additionalData)
 	if err != nil {
-		err = fmt.Errorf("error decrypting data: %v", err)
+		err = fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
 		return
 	}
 
@@ -95,7 +102,7 @@ func DecryptByteAesGcm(key, nonce, ciphertext []byte) (plaintext []byte, err err
 	if err != nil {
 		return
 	}
-	return decryptByteAesGcm(aead, nonce, ciphertext)
+	return decryptByteAesGcm(aead, nonce, ciphertext, nil)
 }
 
 // DecryptAesGcm decrypts and authenticates the given message with AES in GCM mode
@@ -115,13 +122,7 @@ func DecryptAesGcm(key, nonce, ciphertext []byte) (text string, err error) {
 // using the given 128, 192 or 256-bit key.
 // It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
 func EncryptByteAesGcmWithNonceAppended(key []byte, input []byte) (ciphertext []byte, err error) {
-	ciphertext, nonce, err := EncryptByteAesGcm(key, input)
-	if err != nil {
-		return
-	}
-
-	ciphertext = append(nonce, ciphertext...)
-	return
+	return EncryptByteAesGcmWithNonceAppendedAAD(key, input, nil)
 }
 
 // EncryptAesGcmWithNonceAppended encrypts and authenticates the given message (string) with AES in GCM mode
@@ -135,6 +136,94 @@ func EncryptAesGcmWithNonceAppended(key []byte, text string) (ciphertext []byte,
 // using the given 128, 192 or 256-bit key.
 // It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
 func DecryptByteAesGcmWithNonceAppended(key, ciphertext []byte) (plaintext []byte, err error) {
+	return DecryptByteAesGcmWithNonceAppendedAAD(key, ciphertext, nil)
+}
+
+// DecryptAesGcmWithNonceAppended decrypts and authenticates the given ciphertext with AES in GCM mode
+// using the given 128, 192 or 256-bit key.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptAesGcmWithNonceAppended(key, ciphertext []byte) (text string, err error) {
+	plaintext, err := DecryptByteAesGcmWithNonceAppended(key, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteAesGcmAAD encrypts and authenticates the given message (bytes) with AES in GCM mode
+// using the given 128, 192 or 256-bit key, and additionally authenticates additionalData (AAD).
+// The AAD is neither encrypted nor included in the output; the identical bytes must be supplied
+// at decryption. A nil AAD makes this equivalent to EncryptByteAesGcm.
+func EncryptByteAesGcmAAD(key, input, additionalData []byte) (ciphertext []byte, nonce []byte, err error) {
+	return encryptByteAesGcm(key, input, additionalData)
+}
+
+// EncryptAesGcmAAD encrypts and authenticates the given message (string) with AES in GCM mode
+// using the given 128, 192 or 256-bit key, and additionally authenticates additionalData (AAD).
+// A nil AAD makes this equivalent to EncryptAesGcm.
+func EncryptAesGcmAAD(key []byte, text string, additionalData []byte) (ciphertext []byte, nonce []byte, err error) {
+	return EncryptByteAesGcmAAD(key, []byte(text), additionalData)
+}
+
+// DecryptByteAesGcmAAD decrypts and authenticates the given message with AES in GCM mode
+// using the given 128, 192 or 256-bit key and 96-bit nonce, verifying additionalData (AAD)
+// against the value supplied at encryption. Decryption fails if the AAD differs. A nil AAD
+// makes this equivalent to DecryptByteAesGcm.
+func DecryptByteAesGcmAAD(key, nonce, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	aead, err := aesGCM(key)
+	if err != nil {
+		return
+	}
+	return decryptByteAesGcm(aead, nonce, ciphertext, additionalData)
+}
+
+// DecryptAesGcmAAD decrypts and authenticates the given message with AES in GCM mode
+// using the given 128, 192 or 256-bit key and 96-bit nonce, verifying additionalData (AAD)
+// against the value supplied at encryption. A nil AAD makes this equivalent to DecryptAesGcm.
+func DecryptAesGcmAAD(key, nonce, ciphertext, additionalData []byte) (text string, err error) {
+	plaintext, err := DecryptByteAesGcmAAD(key, nonce, ciphertext, additionalData)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteAesGcmWithNonceAppendedAAD encrypts and authenticates the given
+// message (bytes) with AES in GCM mode using the given 128, 192 or 256-bit key,
+// and additionally authenticates additionalData (AAD). The AAD is neither encrypted
+// nor included in the output; the identical bytes must be supplied at decryption.
+// A nil AAD makes this equivalent to EncryptByteAesGcmWithNonceAppended.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptByteAesGcmWithNonceAppendedAAD(key, input, additionalData []byte) (ciphertext []byte, err error) {
+	ciphertext, nonce, err := encryptByteAesGcm(key, input, additionalData)
+	if err != nil {
+		return
+	}
+
+	ciphertext = append(nonce, ciphertext...)
+	return
+}
+
+// EncryptAesGcmWithNonceAppendedAAD encrypts and authenticates the given
+// message (string) with AES in GCM mode using the given 128, 192 or 256-bit key,
+// and additionally authenticates additionalData (AAD). A nil AAD makes this
+// equivalent to EncryptAesGcmWithNonceAppended.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptAesGcmWithNonceAppendedAAD(key []byte, text string, additionalData []byte) (ciphertext []byte, err error) {
+	return EncryptByteAesGcmWithNonceAppendedAAD(key, []byte(text), additionalData)
+}
+
+// DecryptByteAesGcmWithNonceAppendedAAD decrypts and authenticates the given
+// ciphertext with AES in GCM mode using the given 128, 192 or 256-bit key,
+// verifying additionalData (AAD) against the value supplied at encryption.
+// Decryption fails if the AAD differs. A nil AAD makes this equivalent to
+// DecryptByteAesGcmWithNonceAppended.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptByteAesGcmWithNonceAppendedAAD(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
 	aead, err := aesGCM(key)
 	if err != nil {
 		return
@@ -144,19 +233,21 @@ func DecryptByteAesGcmWithNonceAppended(key, ciphertext []byte) (plaintext []byt
 	// encrypt side prepended, even if the GCM nonce size ever changes
 	nonceSize := aead.NonceSize()
 	if len(ciphertext) < nonceSize {
-		err = errors.New("ciphertext is too short")
+		err = ErrCiphertextTooShort
 		return
 	}
 
 	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	return decryptByteAesGcm(aead, nonce, ciphertext)
+	return decryptByteAesGcm(aead, nonce, ciphertext, additionalData)
 }
 
-// DecryptAesGcmWithNonceAppended decrypts and authenticates the given ciphertext with AES in GCM mode
-// using the given 128, 192 or 256-bit key.
+// DecryptAesGcmWithNonceAppendedAAD decrypts and authenticates the given
+// ciphertext with AES in GCM mode using the given 128, 192 or 256-bit key,
+// verifying additionalData (AAD) against the value supplied at encryption.
+// A nil AAD makes this equivalent to DecryptAesGcmWithNonceAppended.
 // It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
-func DecryptAesGcmWithNonceAppended(key, ciphertext []byte) (text string, err error) {
-	plaintext, err := DecryptByteAesGcmWithNonceAppended(key, ciphertext)
+func DecryptAesGcmWithNonceAppendedAAD(key, ciphertext, additionalData []byte) (text string, err error) {
+	plaintext, err := DecryptByteAesGcmWithNonceAppendedAAD(key, ciphertext, additionalData)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,255 @@
+package crypt
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// NonceMode selects how an [AesGcmSealer] builds its 96-bit nonces.
+type NonceMode int
+
+const (
+	// NonceRandom draws every nonce from crypto/rand (SP 800-38D §8.2.2).
+	// NIST caps a key at 2^32 random-nonce messages, which is the default
+	// message limit in this mode.
+	NonceRandom NonceMode = iota
+
+	// NonceCounter uses the deterministic construction of SP 800-38D
+	// §8.2.1: a 4-byte fixed field followed by an 8-byte big-endian
+	// invocation counter. Nonces never repeat while the (key, fixed field)
+	// pair is used by exactly one sealer, so the 2^32 random-nonce bound
+	// does not apply.
+	NonceCounter
+)
+
+const (
+	// gcmNonceSize and gcmTagSize are the standard AES-GCM nonce and tag
+	// lengths that crypto/cipher.NewGCM uses.
+	gcmNonceSize = 12
+	gcmTagSize   = 16
+
+	// gcmSealerFixedSize is the fixed field of a counter nonce.
+	gcmSealerFixedSize = 4
+
+	// gcmSealerRandomMaxMessages is the SP 800-38D §8.3 bound on messages
+	// sealed under one key with random 96-bit nonces.
+	gcmSealerRandomMaxMessages uint64 = 1 << 32
+)
+
+// SealerUsage reports how much an [AesGcmSealer] has encrypted under its
+// current key. The limits are only enforced across restarts if the usage is
+// persisted alongside the key (from [AesGcmSealer.Usage], after the last
+// Seal) and passed back as [SealerConfig.InitialUsage].
+type SealerUsage struct {
+	// Messages is the number of messages sealed under the current key.
+	Messages uint64
+	// Bytes is the total plaintext length sealed under the current key.
+	Bytes uint64
+	// Counter is the invocation counter the next counter-mode nonce will
+	// use.
+	Counter uint64
+}
+
+// SealerConfig configures an [AesGcmSealer]. The zero value selects random
+// nonces with the NIST default limit.
+type SealerConfig struct {
+	// NonceMode selects random or counter nonces.
+	NonceMode NonceMode
+
+	// FixedField is the 4-byte fixed field of counter nonces; it must be
+	// unique to this sealer among everything using the same key. When nil a
+	// random one is drawn, which is only safe if few sealers share a key.
+	// Ignored in NonceRandom mode.
+	FixedField []byte
+
+	// Rand is the source of random nonces and of a drawn fixed field. Nil
+	// selects crypto/rand; anything else is for reproducible test vectors
+	// and must be safe for concurrent use if the sealer is shared.
+	Rand io.Reader
+
+	// InitialUsage is the usage the first key starts from, for resuming
+	// with the same key (and fixed field) after a restart. Without it the
+	// message and byte counts, including the 2^32 random-nonce bound, start
+	// over at zero, and counter nonces start at zero and repeat. A rotated
+	// key always starts from zero.
+	InitialUsage SealerUsage
+
+	// MaxMessages caps the messages sealed under one key. Zero selects 2^32
+	// for NonceRandom and no limit beyond counter exhaustion for
+	// NonceCounter.
+	MaxMessages uint64
+
+	// MaxBytes caps the total plaintext sealed under one key. Zero means no
+	// byte limit.
+	MaxBytes uint64
+
+	// Rotate, if set, is called when the next Seal would cross a limit. It
+	// receives the usage of the exhausted key and returns a fresh key; the
+	// sealer switches to it, resets its counts and carries on, zeroing the
+	// returned slice once the cipher is built. Rotate runs with the sealer
+	// locked and must not call back into it. When Rotate is nil, or returns
+	// an error, Seal fails with [ErrKeyUsageLimit].
+	Rotate func(usage SealerUsage) (newKey []byte, err error)
+}
+
+// AesGcmSealer is an AES-GCM encryptor that tracks how much it has sealed
+// under its key and enforces per-key limits, so a long-running service
+// cannot silently exceed the safe bound for one key. Its output uses the
+// nonce-appended layout [nonce + ciphertext + tag] and is readable by
+// [AesGcm.Open] and [DecryptByteAesGcmWithNonceAppendedAAD].
+//
+// An AesGcmSealer is safe for concurrent use.
+type AesGcmSealer struct {
+	mu          sync.Mutex
+	aead        cipher.AEAD
+	cfg         SealerConfig
+	random      io.Reader
+	fixed       [gcmSealerFixedSize]byte
+	maxMessages uint64
+	usage       SealerUsage
+}
+
+// NewAesGcmSealer returns a sealer for the given 128, 192 or 256-bit key.
+func NewAesGcmSealer(key []byte, cfg SealerConfig) (*AesGcmSealer, error) {
+	switch cfg.NonceMode {
+	case NonceRandom, NonceCounter:
+	default:
+		return nil, fmt.Errorf("%w: nonce mode %d", ErrInvalidParameters, cfg.NonceMode)
+	}
+	if cfg.FixedField != nil && len(cfg.FixedField) != gcmSealerFixedSize {
+		return nil, fmt.Errorf("%w: fixed field must be %d bytes", ErrInvalidParameters, gcmSealerFixedSize)
+	}
+
+	s := &AesGcmSealer{cfg: cfg, random: randomSource(cfg.Rand), maxMessages: cfg.MaxMessages}
+	if s.maxMessages == 0 {
+		s.maxMessages = math.MaxUint64
+		if cfg.NonceMode == NonceRandom {
+			s.maxMessages = gcmSealerRandomMaxMessages
+		}
+	}
+	if err := s.rekey(key); err != nil {
+		return nil, err
+	}
+	s.usage = cfg.InitialUsage
+	return s, nil
+}
+
+// rekey installs key, resets the usage counts and, for counter nonces,
+// picks the fixed field. The caller holds s.mu (or owns s exclusively).
+func (s *AesGcmSealer) rekey(key []byte) error {
+	aead, err := aesGCM(key)
+	if err != nil {
+		return err
+	}
+	if s.cfg.NonceMode == NonceCounter {
+		if s.cfg.FixedField != nil {
+			copy(s.fixed[:], s.cfg.FixedField)
+		} else if _, err := io.ReadFull(s.random, s.fixed[:]); err != nil {
+			return fmt.Errorf("error generating nonce: %w", err)
+		}
+	}
+	s.aead = aead
+	s.usage = SealerUsage{}
+	return nil
+}
+
+// exhausted reports whether sealing n more plaintext bytes would cross a
+// limit of the current key.
+func (s *AesGcmSealer) exhausted(n uint64) bool {
+	if s.usage.Messages >= s.maxMessages {
+		return true
+	}
+	if s.cfg.NonceMode == NonceCounter && s.usage.Counter == math.MaxUint64 {
+		return true
+	}
+	return s.cfg.MaxBytes != 0 && (n > s.cfg.MaxBytes || s.usage.Bytes > s.cfg.MaxBytes-n)
+}
+
+// reserve accounts for one message of n bytes, rotating the key if a limit
+// is reached. It returns the AEAD to seal with and, in NonceCounter mode,
+// writes the next counter nonce into nonce.
+func (s *AesGcmSealer) reserve(n uint64, nonce []byte) (cipher.AEAD, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.exhausted(n) {
+		if s.cfg.Rotate == nil {
+			return nil, ErrKeyUsageLimit
+		}
+		key, err := s.cfg.Rotate(s.usage)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key rotation failed: %w", ErrKeyUsageLimit, err)
+		}
+		err = s.rekey(key)
+		clear(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key rotation failed: %w", ErrKeyUsageLimit, err)
+		}
+		if s.exhausted(n) {
+			// a single message larger than MaxBytes can never fit
+			return nil, ErrKeyUsageLimit
+		}
+	}
+
+	if s.cfg.NonceMode == NonceCounter {
+		copy(nonce, s.fixed[:])
+		binary.BigEndian.PutUint64(nonce[gcmSealerFixedSize:], s.usage.Counter)
+		s.usage.Counter++
+	}
+	s.usage.Messages++
+	s.usage.Bytes += n
+	return s.aead, nil
+}
+
+// Seal encrypts and authenticates plaintext and additionalData (which may be
+// nil) and appends nonce + ciphertext + tag to dst. It returns an error
+// wrapping [ErrKeyUsageLimit] when the key is exhausted and no
+// [SealerConfig.Rotate] callback replaced it. plaintext must not overlap
+// the output: in-place sealing is not supported and is refused with
+// [ErrInvalidParameters] before any usage is counted.
+func (s *AesGcmSealer) Seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	if uint64(len(plaintext)) > gcmMaxPlaintextSize {
+		return dst, ErrPlaintextTooLarge
+	}
+
+	ret, out := sliceForAppend(dst, gcmNonceSize+len(plaintext)+gcmTagSize)
+	if anyOverlap(out, plaintext) {
+		return dst, errSealOverlap
+	}
+	nonce := out[:gcmNonceSize]
+	aead, err := s.reserve(uint64(len(plaintext)), nonce)
+	if err != nil {
+		return dst, err
+	}
+	if s.cfg.NonceMode == NonceRandom {
+		if _, err := io.ReadFull(s.random, nonce); err != nil {
+			return dst, fmt.Errorf("error generating nonce: %w", err)
+		}
+	}
+
+	aead.Seal(out[:gcmNonceSize], nonce, plaintext, additionalData)
+	return ret, nil
+}
+
+// Usage returns the current key's message and byte counts and the next
+// invocation counter.
+func (s *AesGcmSealer) Usage() SealerUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
+
+// NonceSize returns the length of the nonce at the start of every ciphertext.
+func (s *AesGcmSealer) NonceSize() int {
+	return gcmNonceSize
+}
+
+// Overhead returns the difference between the ciphertext and plaintext
+// lengths: the nonce plus the authentication tag.
+func (s *AesGcmSealer) Overhead() int {
+	return gcmNonceSize + gcmTagSize
+}
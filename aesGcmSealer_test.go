@@ -0,0 +1,277 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestAesGcmSealerCounterNonce(t *testing.T) {
+	key := mustBytes(t, 32)
+	fixed := []byte{0xDE, 0xAD, 0xBE, 0xEF}
+	s, err := NewAesGcmSealer(key, SealerConfig{NonceMode: NonceCounter, FixedField: fixed, InitialUsage: SealerUsage{Counter: 41}})
+	if err != nil {
+		t.Fatalf("NewAesGcmSealer: %v", err)
+	}
+
+	for want := uint64(41); want < 44; want++ {
+		ct, err := s.Seal(nil, []byte("hello"), []byte("ad"))
+		if err != nil {
+			t.Fatalf("seal: %v", err)
+		}
+		if !bytes.Equal(ct[:4], fixed) {
+			t.Errorf("fixed field = %x, want %x", ct[:4], fixed)
+		}
+		if got := binary.BigEndian.Uint64(ct[4:12]); got != want {
+			t.Errorf("invocation counter = %d, want %d", got, want)
+		}
+		pt, err := DecryptByteAesGcmWithNonceAppendedAAD(key, ct, []byte("ad"))
+		if err != nil || string(pt) != "hello" {
+			t.Fatalf("decrypt = %q, %v", pt, err)
+		}
+	}
+
+	u := s.Usage()
+	if u.Messages != 3 || u.Bytes != 15 || u.Counter != 44 {
+		t.Errorf("usage = %+v, want 3 messages, 15 bytes, counter 44", u)
+	}
+}
+
+func TestAesGcmSealerRandomNonce(t *testing.T) {
+	key := mustBytes(t, 16)
+	s, err := NewAesGcmSealer(key, SealerConfig{})
+	if err != nil {
+		t.Fatalf("NewAesGcmSealer: %v", err)
+	}
+	h, err := NewAesGcm(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := s.Seal(nil, []byte("same"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y, err := s.Seal(nil, []byte("same"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(x[:12], y[:12]) {
+		t.Error("two random nonces are identical")
+	}
+	if len(x) != len("same")+s.Overhead() {
+		t.Errorf("ciphertext len = %d, want %d", len(x), len("same")+s.Overhead())
+	}
+	if pt, err := h.Open(nil, y, nil); err != nil || string(pt) != "same" {
+		t.Errorf("AesGcm.Open = %q, %v", pt, err)
+	}
+}
+
+func TestAesGcmSealerLimits(t *testing.T) {
+	key := mustBytes(t, 32)
+
+	t.Run("messages", func(t *testing.T) {
+		s, err := NewAesGcmSealer(key, SealerConfig{NonceMode: NonceCounter, MaxMessages: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 2 {
+			if _, err := s.Seal(nil, []byte("x"), nil); err != nil {
+				t.Fatalf("seal within limit: %v", err)
+			}
+		}
+		if _, err := s.Seal(nil, []byte("x"), nil); !errors.Is(err, ErrKeyUsageLimit) {
+			t.Errorf("seal past limit err = %v, want ErrKeyUsageLimit", err)
+		}
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		s, err := NewAesGcmSealer(key, SealerConfig{MaxBytes: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Seal(nil, make([]byte, 6), nil); err != nil {
+			t.Fatalf("seal within limit: %v", err)
+		}
+		if _, err := s.Seal(nil, make([]byte, 5), nil); !errors.Is(err, ErrKeyUsageLimit) {
+			t.Errorf("seal past limit err = %v, want ErrKeyUsageLimit", err)
+		}
+		if _, err := s.Seal(nil, make([]byte, 4), nil); err != nil {
+			t.Errorf("seal up to the limit: %v", err)
+		}
+	})
+
+	t.Run("counterExhausted", func(t *testing.T) {
+		s, err := NewAesGcmSealer(key, SealerConfig{NonceMode: NonceCounter, InitialUsage: SealerUsage{Counter: 1<<64 - 2}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Seal(nil, []byte("x"), nil); err != nil {
+			t.Fatalf("seal: %v", err)
+		}
+		if _, err := s.Seal(nil, []byte("x"), nil); !errors.Is(err, ErrKeyUsageLimit) {
+			t.Errorf("seal with exhausted counter err = %v, want ErrKeyUsageLimit", err)
+		}
+	})
+}
+
+func TestAesGcmSealerResume(t *testing.T) {
+	key := mustBytes(t, 32)
+	for _, mode := range []NonceMode{NonceRandom, NonceCounter} {
+		cfg := SealerConfig{NonceMode: mode, FixedField: []byte{1, 2, 3, 4}, MaxMessages: 3, MaxBytes: 100}
+		s, err := NewAesGcmSealer(key, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range 2 {
+			if _, err := s.Seal(nil, make([]byte, 10), nil); err != nil {
+				t.Fatalf("mode %d: seal: %v", mode, err)
+			}
+		}
+
+		// a restart with the persisted usage keeps the original bounds
+		cfg.InitialUsage = s.Usage()
+		resumed, err := NewAesGcmSealer(key, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, err := resumed.Seal(nil, make([]byte, 10), nil)
+		if err != nil {
+			t.Fatalf("mode %d: resumed seal: %v", mode, err)
+		}
+		if mode == NonceCounter {
+			if got := binary.BigEndian.Uint64(ct[4:12]); got != 2 {
+				t.Errorf("resumed invocation counter = %d, want 2", got)
+			}
+		}
+		if _, err := resumed.Seal(nil, make([]byte, 10), nil); !errors.Is(err, ErrKeyUsageLimit) {
+			t.Errorf("mode %d: fourth message err = %v, want ErrKeyUsageLimit", mode, err)
+		}
+		if u := resumed.Usage(); u.Messages != 3 || u.Bytes != 30 {
+			t.Errorf("mode %d: resumed usage = %+v, want 3 messages, 30 bytes", mode, u)
+		}
+	}
+
+	// the random-nonce default bound of 2^32 messages carries over as well
+	s, err := NewAesGcmSealer(key, SealerConfig{InitialUsage: SealerUsage{Messages: 1<<32 - 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Seal(nil, []byte("x"), nil); err != nil {
+		t.Fatalf("last message: %v", err)
+	}
+	if _, err := s.Seal(nil, []byte("x"), nil); !errors.Is(err, ErrKeyUsageLimit) {
+		t.Errorf("message 2^32+1 err = %v, want ErrKeyUsageLimit", err)
+	}
+}
+
+func TestAesGcmSealerInPlace(t *testing.T) {
+	s, err := NewAesGcmSealer(mustBytes(t, 32), SealerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := []byte("the quick brown fox")
+	buf := make([]byte, len(in), len(in)+gcmNonceSize+gcmTagSize)
+	copy(buf, in)
+	if _, err := s.Seal(buf[:0], buf, nil); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("in-place Seal err = %v, want ErrInvalidParameters", err)
+	}
+	if !bytes.Equal(buf, in) {
+		t.Error("refused Seal modified the plaintext")
+	}
+	if u := s.Usage(); u.Messages != 0 {
+		t.Errorf("refused Seal was counted: %+v", u)
+	}
+}
+
+func TestAesGcmSealerRotate(t *testing.T) {
+	keys := [][]byte{mustBytes(t, 32), mustBytes(t, 32)}
+	next := append([]byte(nil), keys[1]...)
+	var rotated []SealerUsage
+
+	s, err := NewAesGcmSealer(keys[0], SealerConfig{
+		NonceMode:   NonceCounter,
+		MaxMessages: 2,
+		Rotate: func(u SealerUsage) ([]byte, error) {
+			rotated = append(rotated, u)
+			if len(rotated) > 1 {
+				return nil, errors.New("no more keys")
+			}
+			return next, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cts [][]byte
+	for range 4 {
+		ct, err := s.Seal(nil, []byte("msg"), nil)
+		if err != nil {
+			t.Fatalf("seal: %v", err)
+		}
+		cts = append(cts, ct)
+	}
+	if len(rotated) != 1 || rotated[0].Messages != 2 {
+		t.Fatalf("rotations = %+v, want one after 2 messages", rotated)
+	}
+	if !bytes.Equal(next, make([]byte, 32)) {
+		t.Error("rotated key slice was not zeroed")
+	}
+	for i, ct := range cts {
+		if _, err := DecryptByteAesGcmWithNonceAppended(keys[i/2], ct); err != nil {
+			t.Errorf("message %d does not open under key %d: %v", i, i/2, err)
+		}
+	}
+
+	if _, err := s.Seal(nil, []byte("msg"), nil); !errors.Is(err, ErrKeyUsageLimit) {
+		t.Errorf("seal after failed rotation err = %v, want ErrKeyUsageLimit", err)
+	}
+}
+
+func TestAesGcmSealerConcurrentUniqueNonces(t *testing.T) {
+	s, err := NewAesGcmSealer(mustBytes(t, 32), SealerConfig{NonceMode: NonceCounter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers, perWorker = 8, 200
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		nonces = make(map[string]bool)
+	)
+	for range workers {
+		wg.Go(func() {
+			for range perWorker {
+				ct, err := s.Seal(nil, []byte("x"), nil)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				nonces[string(ct[:12])] = true
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if len(nonces) != workers*perWorker {
+		t.Errorf("unique nonces = %d, want %d", len(nonces), workers*perWorker)
+	}
+}
+
+func TestAesGcmSealerConfigErrors(t *testing.T) {
+	key := mustBytes(t, 32)
+	if _, err := NewAesGcmSealer(key, SealerConfig{NonceMode: NonceCounter, FixedField: []byte{1, 2}}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("short fixed field err = %v, want ErrInvalidParameters", err)
+	}
+	if _, err := NewAesGcmSealer(key, SealerConfig{NonceMode: 9}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("bad nonce mode err = %v, want ErrInvalidParameters", err)
+	}
+	if _, err := NewAesGcmSealer(key[:5], SealerConfig{}); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("bad key err = %v, want ErrInvalidKeySize", err)
+	}
+}
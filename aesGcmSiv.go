@@ -0,0 +1,470 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Sizes and limits of AES-GCM-SIV (RFC 8452).
+const (
+	gcmSivNonceSize = 12
+	gcmSivTagSize   = 16
+
+	// gcmSivMaxPlaintextSize is the RFC 8452 bound on both the plaintext and
+	// the additional data (2^36 bytes, 64 GiB). Larger inputs are rejected
+	// with an error, as the other AEADs in this package do.
+	gcmSivMaxPlaintextSize uint64 = 1 << 36
+)
+
+// aesGcmSiv implements AES-GCM-SIV as specified in RFC 8452. Both the
+// 128-bit and the 256-bit key sizes are supported; the nonce is 96 bits and
+// the tag 128 bits, as in AES-GCM.
+//
+// Unlike AES-GCM, repeating a nonce under the same key does not expose the
+// authentication key or the XOR of two plaintexts: it only reveals whether
+// two messages (with the same AAD) were identical. A fresh per-message
+// encryption and authentication key is derived from the nonce.
+//
+// It is only used through the package functions below, which never pass
+// overlapping buffers, so unlike crypto/cipher it does not check for aliasing.
+type aesGcmSiv struct {
+	block  cipher.Block // key-generating key
+	keyLen int
+}
+
+// newAesGcmSiv builds an AES-GCM-SIV AEAD for the given 128 or 256-bit key.
+func newAesGcmSiv(key []byte) (*aesGcmSiv, error) {
+	// RFC 8452 defines AEAD_AES_128_GCM_SIV and AEAD_AES_256_GCM_SIV only
+	if len(key) != 16 && len(key) != 32 {
+		return nil, fmt.Errorf("%w: AES-GCM-SIV takes a 16 or 32-byte key, got %d", ErrInvalidKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
+	}
+	return &aesGcmSiv{block: block, keyLen: len(key)}, nil
+}
+
+func (*aesGcmSiv) NonceSize() int { return gcmSivNonceSize }
+
+func (*aesGcmSiv) Overhead() int { return gcmSivTagSize }
+
+// deriveKeys derives the per-nonce message-authentication key and the
+// message-encryption block cipher (RFC 8452 section 4).
+func (a *aesGcmSiv) deriveKeys(nonce []byte) (authKey [16]byte, enc cipher.Block) {
+	var in, out [16]byte
+	var encKey [32]byte
+	copy(in[4:], nonce)
+
+	// 2 blocks for the 128-bit authentication key, then 2 or 4 blocks for
+	// the encryption key; only the first 8 bytes of every output are used
+	blocks := 2 + a.keyLen/8
+	for i := range blocks {
+		binary.LittleEndian.PutUint32(in[:4], uint32(i)) // #nosec G115 -- i < 6
+		a.block.Encrypt(out[:], in[:])
+		if i < 2 {
+			copy(authKey[i*8:], out[:8])
+		} else {
+			copy(encKey[(i-2)*8:], out[:8])
+		}
+	}
+
+	// the length was validated by newAesGcmSiv, so NewCipher cannot fail
+	enc, _ = aes.NewCipher(encKey[:a.keyLen])
+	clear(encKey[:])
+	return authKey, enc
+}
+
+// tag computes the AES-GCM-SIV tag over additionalData and plaintext.
+func (a *aesGcmSiv) tag(authKey *[16]byte, enc cipher.Block, nonce, plaintext, additionalData []byte) (tag [16]byte) {
+	var p polyval
+	p.init(authKey)
+	p.update(additionalData)
+	p.update(plaintext)
+
+	var lengths [16]byte
+	binary.LittleEndian.PutUint64(lengths[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengths[8:], uint64(len(plaintext))*8)
+	p.update(lengths[:])
+
+	s := p.sum()
+	for i := range gcmSivNonceSize {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+	enc.Encrypt(tag[:], s[:])
+	return tag
+}
+
+// gcmSivCTR runs AES in counter mode with the 32-bit little-endian counter RFC 8452
+// places in the first four bytes of the block (wrapping modulo 2^32).
+func gcmSivCTR(enc cipher.Block, tag *[16]byte, dst, src []byte) {
+	var counter, keystream [16]byte
+	counter = *tag
+	counter[15] |= 0x80
+
+	for len(src) > 0 {
+		enc.Encrypt(keystream[:], counter[:])
+		n := subtle.XORBytes(dst, src, keystream[:])
+		dst, src = dst[n:], src[n:]
+
+		c := binary.LittleEndian.Uint32(counter[:4])
+		binary.LittleEndian.PutUint32(counter[:4], c+1)
+	}
+}
+
+// Seal encrypts and authenticates plaintext and appends ciphertext || tag to
+// dst. Like crypto/cipher it panics on a wrong-length nonce; the package
+// functions validate their inputs first.
+func (a *aesGcmSiv) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSivNonceSize {
+		panic("crypt: incorrect nonce length given to AES-GCM-SIV")
+	}
+	if uint64(len(plaintext)) > gcmSivMaxPlaintextSize || uint64(len(additionalData)) > gcmSivMaxPlaintextSize {
+		panic("crypt: message too large for AES-GCM-SIV")
+	}
+
+	authKey, enc := a.deriveKeys(nonce)
+	tag := a.tag(&authKey, enc, nonce, plaintext, additionalData)
+	clear(authKey[:])
+
+	ret, out := sliceForAppend(dst, len(plaintext)+gcmSivTagSize)
+	gcmSivCTR(enc, &tag, out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+// Open authenticates and decrypts ciphertext || tag and appends the plaintext
+// to dst. On failure the output is wiped and an error returned.
+func (a *aesGcmSiv) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSivNonceSize {
+		panic("crypt: incorrect nonce length given to AES-GCM-SIV")
+	}
+	if len(ciphertext) < gcmSivTagSize ||
+		uint64(len(ciphertext)) > gcmSivMaxPlaintextSize+gcmSivTagSize ||
+		uint64(len(additionalData)) > gcmSivMaxPlaintextSize {
+		return nil, ErrAuthenticationFailed
+	}
+
+	var tag [16]byte
+	copy(tag[:], ciphertext[len(ciphertext)-gcmSivTagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-gcmSivTagSize]
+
+	authKey, enc := a.deriveKeys(nonce)
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	gcmSivCTR(enc, &tag, out, ciphertext)
+
+	expected := a.tag(&authKey, enc, nonce, out, additionalData)
+	clear(authKey[:])
+	if subtle.ConstantTimeCompare(expected[:], tag[:]) != 1 {
+		// never release unauthenticated plaintext
+		clear(out)
+		return nil, ErrAuthenticationFailed
+	}
+	return ret, nil
+}
+
+// polyval computes the POLYVAL universal hash of RFC 8452 section 3 over a
+// sequence of inputs, each zero-padded to a whole number of blocks. Elements
+// are two little-endian 64-bit words, low word first.
+type polyval struct {
+	h   [2]uint64
+	acc [2]uint64
+}
+
+func (p *polyval) init(key *[16]byte) {
+	p.h[0] = binary.LittleEndian.Uint64(key[:8])
+	p.h[1] = binary.LittleEndian.Uint64(key[8:])
+	p.acc = [2]uint64{}
+}
+
+// update absorbs b, zero-padding its final partial block.
+func (p *polyval) update(b []byte) {
+	for len(b) > 0 {
+		var block [16]byte
+		n := copy(block[:], b)
+		b = b[n:]
+
+		p.acc[0] ^= binary.LittleEndian.Uint64(block[:8])
+		p.acc[1] ^= binary.LittleEndian.Uint64(block[8:])
+		p.acc = polyvalDot(p.acc, p.h)
+	}
+}
+
+func (p *polyval) sum() (out [16]byte) {
+	binary.LittleEndian.PutUint64(out[:8], p.acc[0])
+	binary.LittleEndian.PutUint64(out[8:], p.acc[1])
+	return out
+}
+
+// polyvalDot returns a*b*x^-128 in GF(2^128) modulo
+// x^128 + x^127 + x^126 + x^121 + 1.
+func polyvalDot(a, b [2]uint64) [2]uint64 {
+	// schoolbook 128x128 carry-less multiply into d3:d2:d1:d0
+	l0, h0 := clmul(a[0], b[0])
+	l1, h1 := clmul(a[0], b[1])
+	l2, h2 := clmul(a[1], b[0])
+	l3, h3 := clmul(a[1], b[1])
+	d0 := l0
+	d1 := h0 ^ l1 ^ l2
+	d2 := h1 ^ h2 ^ l3
+	d3 := h3
+
+	// Montgomery reduction, 64 bits at a time. The polynomial is 1 modulo
+	// x^64, so adding d0*P clears the low word; the x^121+x^126+x^127 terms
+	// are x^64 times the constant 0xc2<<56.
+	const poly = 0xc200000000000000
+	lo, hi := clmul(d0, poly)
+	d1 ^= lo
+	d2 ^= hi ^ d0
+
+	lo, hi = clmul(d1, poly)
+	d2 ^= lo
+	d3 ^= hi ^ d1
+
+	return [2]uint64{d2, d3}
+}
+
+// clmul returns the 128-bit carry-less product of x and y.
+func clmul(x, y uint64) (lo, hi uint64) {
+	lo = bmul64(x, y)
+	// the high half is the bit-reversed low half of the bit-reversed inputs,
+	// shifted by one because the product has only 127 bits
+	hi = bits.Reverse64(bmul64(bits.Reverse64(x), bits.Reverse64(y))) >> 1
+	return lo, hi
+}
+
+// bmul64 returns the low 64 bits of the carry-less product of x and y. It
+// uses integer multiplication with holes in the operands so carries never
+// reach a neighbouring bit, which keeps it constant time (BearSSL's
+// ghash_ctmul64 technique).
+func bmul64(x, y uint64) uint64 {
+	const (
+		m0 = 0x1111111111111111
+		m1 = 0x2222222222222222
+		m2 = 0x4444444444444444
+		m3 = 0x8888888888888888
+	)
+	x0, x1, x2, x3 := x&m0, x&m1, x&m2, x&m3
+	y0, y1, y2, y3 := y&m0, y&m1, y&m2, y&m3
+	z0 := (x0 * y0) ^ (x1 * y3) ^ (x2 * y2) ^ (x3 * y1)
+	z1 := (x0 * y1) ^ (x1 * y0) ^ (x2 * y3) ^ (x3 * y2)
+	z2 := (x0 * y2) ^ (x1 * y1) ^ (x2 * y0) ^ (x3 * y3)
+	z3 := (x0 * y3) ^ (x1 * y2) ^ (x2 * y1) ^ (x3 * y0)
+	return (z0 & m0) | (z1 & m1) | (z2 & m2) | (z3 & m3)
+}
+
+// encryptByteAesGcmSiv is the shared AES-GCM-SIV encryption core. It seals
+// input under key with a fresh random 96-bit nonce and additionally
+// authenticates additionalData (which may be nil).
+func encryptByteAesGcmSiv(key, input, additionalData []byte) (ciphertext []byte, nonce []byte, err error) {
+	aead, err := newAesGcmSiv(key)
+	if err != nil {
+		return
+	}
+
+	// reject oversized input so Seal returns an error rather than panicking
+	if uint64(len(input)) > gcmSivMaxPlaintextSize {
+		err = ErrPlaintextTooLarge
+		return
+	}
+	if uint64(len(additionalData)) > gcmSivMaxPlaintextSize {
+		err = ErrAssociatedDataTooLarge
+		return
+	}
+
+	// generate a 96-bit random nonce
+	nonce = make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		err = fmt.Errorf("error generating nonce: %w", err)
+		return
+	}
+
+	// encrypt the data
+	ciphertext = aead.Seal(nil, nonce, input, additionalData)
+	return
+}
+
+// decryptByteAesGcmSiv is the shared AES-GCM-SIV decryption core.
+// additionalData must be the same value supplied at encryption (nil if none).
+func decryptByteAesGcmSiv(key, nonce, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	aead, err := newAesGcmSiv(key)
+	if err != nil {
+		return
+	}
+
+	// reject a wrong-length nonce so Open returns an error rather than
+	// panicking on caller-supplied input
+	if len(nonce) != aead.NonceSize() {
+		err = ErrInvalidNonce
+		return
+	}
+
+	// decrypt the data
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+// EncryptByteAesGcmSiv encrypts and authenticates the given message (bytes) with
+// AES-GCM-SIV (RFC 8452) using the given 128 or 256-bit key and a random 96-bit nonce.
+func EncryptByteAesGcmSiv(key []byte, input []byte) (ciphertext []byte, nonce []byte, err error) {
+	return encryptByteAesGcmSiv(key, input, nil)
+}
+
+// EncryptAesGcmSiv encrypts and authenticates the given message (string) with
+// AES-GCM-SIV (RFC 8452) using the given 128 or 256-bit key and a random 96-bit nonce.
+func EncryptAesGcmSiv(key []byte, text string) (ciphertext []byte, nonce []byte, err error) {
+	return EncryptByteAesGcmSiv(key, []byte(text))
+}
+
+// DecryptByteAesGcmSiv decrypts and authenticates the given ciphertext with
+// AES-GCM-SIV (RFC 8452) using the given 128 or 256-bit key and 96-bit nonce.
+func DecryptByteAesGcmSiv(key, nonce, ciphertext []byte) (plaintext []byte, err error) {
+	return decryptByteAesGcmSiv(key, nonce, ciphertext, nil)
+}
+
+// DecryptAesGcmSiv decrypts and authenticates the given ciphertext with
+// AES-GCM-SIV (RFC 8452) using the given 128 or 256-bit key and 96-bit nonce.
+func DecryptAesGcmSiv(key, nonce, ciphertext []byte) (text string, err error) {
+	plaintext, err := DecryptByteAesGcmSiv(key, nonce, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteAesGcmSivAAD encrypts and authenticates the given message (bytes) with
+// AES-GCM-SIV using the given 128 or 256-bit key, and additionally authenticates
+// additionalData (AAD). The AAD is neither encrypted nor included in the output; the
+// identical bytes must be supplied at decryption. A nil AAD makes this equivalent to
+// EncryptByteAesGcmSiv.
+func EncryptByteAesGcmSivAAD(key, input, additionalData []byte) (ciphertext []byte, nonce []byte, err error) {
+	return encryptByteAesGcmSiv(key, input, additionalData)
+}
+
+// EncryptAesGcmSivAAD encrypts and authenticates the given message (string) with
+// AES-GCM-SIV using the given 128 or 256-bit key, and additionally authenticates
+// additionalData (AAD). A nil AAD makes this equivalent to EncryptAesGcmSiv.
+func EncryptAesGcmSivAAD(key []byte, text string, additionalData []byte) (ciphertext []byte, nonce []byte, err error) {
+	return EncryptByteAesGcmSivAAD(key, []byte(text), additionalData)
+}
+
+// DecryptByteAesGcmSivAAD decrypts and authenticates the given ciphertext with
+// AES-GCM-SIV using the given 128 or 256-bit key and 96-bit nonce, verifying
+// additionalData (AAD) against the value supplied at encryption. Decryption fails if
+// the AAD differs. A nil AAD makes this equivalent to DecryptByteAesGcmSiv.
+func DecryptByteAesGcmSivAAD(key, nonce, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	return decryptByteAesGcmSiv(key, nonce, ciphertext, additionalData)
+}
+
+// DecryptAesGcmSivAAD decrypts and authenticates the given ciphertext with
+// AES-GCM-SIV using the given 128 or 256-bit key and 96-bit nonce, verifying
+// additionalData (AAD) against the value supplied at encryption. A nil AAD makes this
+// equivalent to DecryptAesGcmSiv.
+func DecryptAesGcmSivAAD(key, nonce, ciphertext, additionalData []byte) (text string, err error) {
+	plaintext, err := DecryptByteAesGcmSivAAD(key, nonce, ciphertext, additionalData)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteAesGcmSivWithNonceAppended encrypts and authenticates the given message (bytes) with
+// AES-GCM-SIV using the given 128 or 256-bit key and a random 96-bit nonce.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptByteAesGcmSivWithNonceAppended(key []byte, input []byte) (ciphertext []byte, err error) {
+	return EncryptByteAesGcmSivWithNonceAppendedAAD(key, input, nil)
+}
+
+// EncryptAesGcmSivWithNonceAppended encrypts and authenticates the given message (string) with
+// AES-GCM-SIV using the given 128 or 256-bit key and a random 96-bit nonce.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptAesGcmSivWithNonceAppended(key []byte, text string) (ciphertext []byte, err error) {
+	return EncryptByteAesGcmSivWithNonceAppended(key, []byte(text))
+}
+
+// DecryptByteAesGcmSivWithNonceAppended decrypts and authenticates the given ciphertext with
+// AES-GCM-SIV using the given 128 or 256-bit key.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptByteAesGcmSivWithNonceAppended(key, ciphertext []byte) (plaintext []byte, err error) {
+	return DecryptByteAesGcmSivWithNonceAppendedAAD(key, ciphertext, nil)
+}
+
+// DecryptAesGcmSivWithNonceAppended decrypts and authenticates the given ciphertext with
+// AES-GCM-SIV using the given 128 or 256-bit key.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptAesGcmSivWithNonceAppended(key, ciphertext []byte) (text string, err error) {
+	plaintext, err := DecryptByteAesGcmSivWithNonceAppended(key, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteAesGcmSivWithNonceAppendedAAD encrypts and authenticates the given
+// message (bytes) with AES-GCM-SIV using the given 128 or 256-bit key, and
+// additionally authenticates additionalData (AAD). The AAD is neither encrypted nor
+// included in the output; the identical bytes must be supplied at decryption. A nil
+// AAD makes this equivalent to EncryptByteAesGcmSivWithNonceAppended.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptByteAesGcmSivWithNonceAppendedAAD(key, input, additionalData []byte) (ciphertext []byte, err error) {
+	ciphertext, nonce, err := encryptByteAesGcmSiv(key, input, additionalData)
+	if err != nil {
+		return
+	}
+
+	ciphertext = append(nonce, ciphertext...)
+	return
+}
+
+// EncryptAesGcmSivWithNonceAppendedAAD encrypts and authenticates the given
+// message (string) with AES-GCM-SIV using the given 128 or 256-bit key, and
+// additionally authenticates additionalData (AAD). A nil AAD makes this equivalent
+// to EncryptAesGcmSivWithNonceAppended.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptAesGcmSivWithNonceAppendedAAD(key []byte, text string, additionalData []byte) (ciphertext []byte, err error) {
+	return EncryptByteAesGcmSivWithNonceAppendedAAD(key, []byte(text), additionalData)
+}
+
+// DecryptByteAesGcmSivWithNonceAppendedAAD decrypts and authenticates the given
+// ciphertext with AES-GCM-SIV using the given 128 or 256-bit key, verifying
+// additionalData (AAD) against the value supplied at encryption. Decryption fails if
+// the AAD differs. A nil AAD makes this equivalent to
+// DecryptByteAesGcmSivWithNonceAppended.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptByteAesGcmSivWithNonceAppendedAAD(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	if len(ciphertext) < gcmSivNonceSize {
+		err = ErrCiphertextTooShort
+		return
+	}
+
+	nonce, ciphertext := ciphertext[:gcmSivNonceSize], ciphertext[gcmSivNonceSize:]
+	return decryptByteAesGcmSiv(key, nonce, ciphertext, additionalData)
+}
+
+// DecryptAesGcmSivWithNonceAppendedAAD decrypts and authenticates the given
+// ciphertext with AES-GCM-SIV using the given 128 or 256-bit key, verifying
+// additionalData (AAD) against the value supplied at encryption. A nil AAD makes
+// this equivalent to DecryptAesGcmSivWithNonceAppended.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptAesGcmSivWithNonceAppendedAAD(key, ciphertext, additionalData []byte) (text string, err error) {
+	plaintext, err := DecryptByteAesGcmSivWithNonceAppendedAAD(key, ciphertext, additionalData)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
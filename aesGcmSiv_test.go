@@ -0,0 +1,270 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// mustHex decodes a hex test vector, failing the test on malformed input. It
+// is shared by the known-answer tests in this package.
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex.DecodeString(%q): %v", s, err)
+	}
+	return b
+}
+
+func TestPolyvalKnownAnswer(t *testing.T) {
+	// RFC 8452 Appendix A
+	var key [16]byte
+	copy(key[:], mustHex(t, "25629347589242761d31f826ba4b757b"))
+
+	var p polyval
+	p.init(&key)
+	p.update(mustHex(t, "4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362"))
+	sum := p.sum()
+	if want := "f7a3b47b846119fae5b7866cf5e5b77e"; hex.EncodeToString(sum[:]) != want {
+		t.Errorf("POLYVAL = %x, want %s", sum, want)
+	}
+}
+
+func TestAesGcmSivKnownAnswer(t *testing.T) {
+	// RFC 8452 Appendix C
+	vectors := []struct {
+		name, key, nonce, aad, plaintext, result string
+	}{
+		{
+			name:   "AES-128/empty",
+			key:    "01000000000000000000000000000000",
+			nonce:  "030000000000000000000000",
+			result: "dc20e2d83f25705bb49e439eca56de25",
+		},
+		{
+			name:      "AES-128/8 bytes",
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0100000000000000",
+			result:    "b5d839330ac7b786578782fff6013b815b287c22493a364c",
+		},
+		{
+			name:      "AES-128/AAD",
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			aad:       "01",
+			plaintext: "0200000000000000",
+			result:    "1e6daba35669f4273b0a1a2560969cdf790d99759abd1508",
+		},
+		{
+			name:   "AES-256/empty",
+			key:    "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:  "030000000000000000000000",
+			result: "07f5f4169bbf55a8400cd47ea6fd400f",
+		},
+		{
+			name:      "AES-256/8 bytes",
+			key:       "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0100000000000000",
+			result:    "c2ef328e5c71c83b843122130f7364b761e0b97427e3df28",
+		},
+		{
+			name:      "AES-256/counterWrap",
+			key:       "0000000000000000000000000000000000000000000000000000000000000000",
+			nonce:     "000000000000000000000000",
+			plaintext: "000000000000000000000000000000004db923dc793ee6497c76dcc03a98e108",
+			result:    "f3f80f2cf0cb2dd9c5984fcda908456cc537703b5ba70324a6793a7bf218d3eaffffffff000000000000000000000000",
+		},
+	}
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			key, nonce := mustHex(t, v.key), mustHex(t, v.nonce)
+			aad, plaintext, result := mustHex(t, v.aad), mustHex(t, v.plaintext), mustHex(t, v.result)
+
+			aead, err := newAesGcmSiv(key)
+			if err != nil {
+				t.Fatalf("newAesGcmSiv: %v", err)
+			}
+			if got := aead.Seal(nil, nonce, plaintext, aad); !bytes.Equal(got, result) {
+				t.Errorf("Seal = %x, want %x", got, result)
+			}
+
+			got, err := DecryptByteAesGcmSivAAD(key, nonce, result, aad)
+			if err != nil {
+				t.Fatalf("DecryptByteAesGcmSivAAD: %v", err)
+			}
+			if !bytes.Equal(got, plaintext) {
+				t.Errorf("decrypt = %x, want %x", got, plaintext)
+			}
+		})
+	}
+}
+
+// aesGcmSivKeySizes maps a human name to the key length that selects each
+// AES-GCM-SIV variant.
+var aesGcmSivKeySizes = map[string]int{"AES-128": 16, "AES-256": 32}
+
+func TestAesGcmSivRoundTrip(t *testing.T) {
+	const text = "the quick brown fox jumps over the lazy dog"
+	const tagSize = 16
+	aad := []byte("record:42")
+
+	for name, size := range aesGcmSivKeySizes {
+		t.Run(name, func(t *testing.T) {
+			key := mustBytes(t, size)
+
+			t.Run("string", func(t *testing.T) {
+				ciphertext, nonce, err := EncryptAesGcmSiv(key, text)
+				if err != nil {
+					t.Fatalf("EncryptAesGcmSiv: %v", err)
+				}
+				got, err := DecryptAesGcmSiv(key, nonce, ciphertext)
+				if err != nil {
+					t.Fatalf("DecryptAesGcmSiv: %v", err)
+				}
+				if got != text {
+					t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+				}
+			})
+
+			t.Run("bytesAAD", func(t *testing.T) {
+				in := []byte(text)
+				ciphertext, nonce, err := EncryptByteAesGcmSivAAD(key, in, aad)
+				if err != nil {
+					t.Fatalf("EncryptByteAesGcmSivAAD: %v", err)
+				}
+				got, err := DecryptByteAesGcmSivAAD(key, nonce, ciphertext, aad)
+				if err != nil {
+					t.Fatalf("DecryptByteAesGcmSivAAD: %v", err)
+				}
+				if !bytes.Equal(got, in) {
+					t.Errorf("round-trip mismatch: got %q, want %q", got, in)
+				}
+			})
+
+			t.Run("nonceAppended", func(t *testing.T) {
+				ciphertext, err := EncryptAesGcmSivWithNonceAppended(key, text)
+				if err != nil {
+					t.Fatalf("EncryptAesGcmSivWithNonceAppended: %v", err)
+				}
+				if want := aesGcmNonceSize + len(text) + tagSize; len(ciphertext) != want {
+					t.Errorf("ciphertext len = %d, want %d (nonce+plaintext+tag)", len(ciphertext), want)
+				}
+				got, err := DecryptAesGcmSivWithNonceAppended(key, ciphertext)
+				if err != nil {
+					t.Fatalf("DecryptAesGcmSivWithNonceAppended: %v", err)
+				}
+				if got != text {
+					t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+				}
+			})
+
+			t.Run("nonceAppendedAAD", func(t *testing.T) {
+				ciphertext, err := EncryptAesGcmSivWithNonceAppendedAAD(key, text, aad)
+				if err != nil {
+					t.Fatalf("EncryptAesGcmSivWithNonceAppendedAAD: %v", err)
+				}
+				got, err := DecryptAesGcmSivWithNonceAppendedAAD(key, ciphertext, aad)
+				if err != nil {
+					t.Fatalf("DecryptAesGcmSivWithNonceAppendedAAD: %v", err)
+				}
+				if got != text {
+					t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+				}
+				if _, err := DecryptAesGcmSivWithNonceAppendedAAD(key, ciphertext, []byte("record:7")); err == nil {
+					t.Error("decryption with wrong AAD succeeded, want failure")
+				}
+				if _, err := DecryptAesGcmSivWithNonceAppended(key, ciphertext); err == nil {
+					t.Error("decryption without AAD succeeded, want failure")
+				}
+			})
+		})
+	}
+}
+
+func TestAesGcmSivNonceReuse(t *testing.T) {
+	// the point of SIV: a repeated nonce leaks only message equality
+	key := mustBytes(t, 32)
+	nonce := mustBytes(t, gcmSivNonceSize)
+	aead, err := newAesGcmSiv(key)
+	if err != nil {
+		t.Fatalf("newAesGcmSiv: %v", err)
+	}
+
+	a := aead.Seal(nil, nonce, []byte("message one"), nil)
+	b := aead.Seal(nil, nonce, []byte("message two"), nil)
+	c := aead.Seal(nil, nonce, []byte("message one"), nil)
+	if !bytes.Equal(a, c) {
+		t.Error("same (key, nonce, plaintext) produced different ciphertexts")
+	}
+	// distinct messages get distinct synthetic IVs, so their keystreams
+	// differ and the XOR of the ciphertexts is not the XOR of the plaintexts
+	if bytes.Equal(a[len(a)-gcmSivTagSize:], b[len(b)-gcmSivTagSize:]) {
+		t.Error("distinct messages produced the same tag")
+	}
+}
+
+func TestAesGcmSivErrors(t *testing.T) {
+	key := mustBytes(t, 32)
+
+	t.Run("invalidKeySize", func(t *testing.T) {
+		// AES-192 is valid for AES-GCM but not defined for AES-GCM-SIV
+		for _, size := range []int{0, 9, 24, 33} {
+			if _, _, err := EncryptAesGcmSiv(make([]byte, size), "x"); err == nil {
+				t.Errorf("encrypt with %d-byte key succeeded, want error", size)
+			}
+		}
+	})
+
+	t.Run("wrongKey", func(t *testing.T) {
+		ciphertext, err := EncryptAesGcmSivWithNonceAppended(key, "secret")
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		if _, err := DecryptAesGcmSivWithNonceAppended(mustBytes(t, 32), ciphertext); err == nil {
+			t.Error("decryption with wrong key succeeded, want failure")
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		ciphertext, err := EncryptAesGcmSivWithNonceAppended(key, "secret")
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		for i := range ciphertext {
+			bad := bytes.Clone(ciphertext)
+			bad[i] ^= 0x01
+			if _, err := DecryptByteAesGcmSivWithNonceAppended(key, bad); err == nil {
+				t.Fatalf("decryption with byte %d flipped succeeded, want failure", i)
+			}
+		}
+	})
+
+	t.Run("tooShort", func(t *testing.T) {
+		if _, err := DecryptByteAesGcmSivWithNonceAppended(key, []byte{1, 2, 3}); err == nil {
+			t.Error("expected error for too-short ciphertext, got nil")
+		}
+		if _, err := DecryptByteAesGcmSiv(key, mustBytes(t, gcmSivNonceSize), []byte{1, 2, 3}); err == nil {
+			t.Error("expected error for ciphertext shorter than a tag, got nil")
+		}
+	})
+
+	t.Run("wrongNonceLength", func(t *testing.T) {
+		// a wrong-length nonce must return an error, not panic
+		ciphertext, nonce, err := EncryptByteAesGcmSiv(key, []byte("secret"))
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		for _, badLen := range []int{0, gcmSivNonceSize - 1, gcmSivNonceSize + 1} {
+			if _, err := DecryptByteAesGcmSiv(key, make([]byte, badLen), ciphertext); err == nil {
+				t.Errorf("decrypt with %d-byte nonce succeeded, want error", badLen)
+			}
+		}
+		if _, err := DecryptByteAesGcmSiv(key, nonce, ciphertext); err != nil {
+			t.Errorf("decrypt with correct nonce failed: %v", err)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"fmt"
+)
+
+// Sizes and limits of AES-SIV (RFC 5297).
+const (
+	// sivSize is the length of the synthetic IV that leads every AES-SIV
+	// ciphertext. It doubles as the authentication tag.
+	sivSize = 16
+
+	// sivMaxAssociatedData is the largest number of associated-data strings
+	// S2V accepts: RFC 5297 caps the whole vector, including the plaintext,
+	// at 127 components.
+	sivMaxAssociatedData = 126
+)
+
+// aesSivKeys splits an AES-SIV key into its two halves: the S2V (CMAC) key
+// and the CTR key. A 32, 48 or 64-byte key selects AES-SIV with AES-128,
+// AES-192 or AES-256 respectively.
+func aesSivKeys(key []byte) (mac *cmac, ctr cipher.Block, err error) {
+	switch len(key) {
+	case 32, 48, 64:
+	default:
+		return nil, nil, fmt.Errorf("%w: AES-SIV takes a 32, 48 or 64-byte key, got %d", ErrInvalidKeySize, len(key))
+	}
+
+	half := len(key) / 2
+	macBlock, err := aes.NewCipher(key[:half])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
+	}
+	ctr, err = aes.NewCipher(key[half:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
+	}
+	return newCMAC(macBlock), ctr, nil
+}
+
+// s2v is the S2V pseudo-random function of RFC 5297 section 2.4 over the
+// associated-data strings followed by the plaintext.
+func s2v(mac *cmac, additionalData [][]byte, plaintext []byte) [sivSize]byte {
+	var zero [sivSize]byte
+	d := mac.sum(zero[:])
+	for _, ad := range additionalData {
+		m := mac.sum(ad)
+		d = dbl(d)
+		subtle.XORBytes(d[:], d[:], m[:])
+	}
+
+	// the plaintext is always the last component
+	if len(plaintext) >= sivSize {
+		// xorend: fold D into the last 16 bytes
+		t := make([]byte, len(plaintext))
+		copy(t, plaintext)
+		tail := t[len(t)-sivSize:]
+		subtle.XORBytes(tail, tail, d[:])
+		v := mac.sum(t)
+		clear(t)
+		return v
+	}
+
+	var t [sivSize]byte
+	copy(t[:], plaintext)
+	t[len(plaintext)] = 0x80
+	d = dbl(d)
+	subtle.XORBytes(t[:], t[:], d[:])
+	return mac.sum(t[:])
+}
+
+// sivCTR runs AES-CTR keyed by ctr from the synthetic IV v, with the two bits
+// RFC 5297 section 2.5 clears so implementations may use 32/64-bit counters.
+func sivCTR(ctr cipher.Block, v [sivSize]byte, dst, src []byte) {
+	v[8] &= 0x7f
+	v[12] &= 0x7f
+	cipher.NewCTR(ctr, v[:]).XORKeyStream(dst, src)
+}
+
+// EncryptByteAesSiv deterministically encrypts and authenticates the given
+// message (bytes) with AES-SIV (RFC 5297) using the given 256, 384 or 512-bit
+// key, additionally authenticating each additionalData string as a separate
+// S2V component (up to 126, order-sensitive). No nonce is used: the same key,
+// message and associated data always produce the same ciphertext, which is
+// what makes AES-SIV suitable for equality lookups, de-duplication and key
+// wrapping. To encrypt non-deterministically, pass a random nonce as the last
+// associated-data string.
+// The output is the synthetic IV followed by the ciphertext [ciphertext = SIV + ciphertext].
+func EncryptByteAesSiv(key []byte, input []byte, additionalData ...[]byte) (ciphertext []byte, err error) {
+	if len(additionalData) > sivMaxAssociatedData {
+		err = fmt.Errorf("%w: more than %d strings", ErrAssociatedDataTooLarge, sivMaxAssociatedData)
+		return
+	}
+
+	mac, ctr, err := aesSivKeys(key)
+	if err != nil {
+		return
+	}
+
+	v := s2v(mac, additionalData, input)
+	ciphertext = make([]byte, sivSize+len(input))
+	copy(ciphertext, v[:])
+	sivCTR(ctr, v, ciphertext[sivSize:], input)
+	return
+}
+
+// EncryptAesSiv deterministically encrypts and authenticates the given message
+// (string) with AES-SIV (RFC 5297) using the given 256, 384 or 512-bit key; see
+// EncryptByteAesSiv for how additionalData is used.
+// The output is the synthetic IV followed by the ciphertext [ciphertext = SIV + ciphertext].
+func EncryptAesSiv(key []byte, text string, additionalData ...[]byte) (ciphertext []byte, err error) {
+	return EncryptByteAesSiv(key, []byte(text), additionalData...)
+}
+
+// DecryptByteAesSiv decrypts and authenticates the given ciphertext with
+// AES-SIV (RFC 5297) using the given 256, 384 or 512-bit key, verifying the
+// additionalData strings against those supplied at encryption (same values,
+// same order). No plaintext is returned unless authentication succeeds.
+// It expects the synthetic IV followed by the ciphertext [ciphertext = SIV + ciphertext].
+func DecryptByteAesSiv(key, ciphertext []byte, additionalData ...[]byte) (plaintext []byte, err error) {
+	if len(additionalData) > sivMaxAssociatedData {
+		err = fmt.Errorf("%w: more than %d strings", ErrAssociatedDataTooLarge, sivMaxAssociatedData)
+		return
+	}
+
+	mac, ctr, err := aesSivKeys(key)
+	if err != nil {
+		return
+	}
+
+	if len(ciphertext) < sivSize {
+		err = ErrCiphertextTooShort
+		return
+	}
+
+	var v [sivSize]byte
+	copy(v[:], ciphertext[:sivSize])
+	plaintext = make([]byte, len(ciphertext)-sivSize)
+	sivCTR(ctr, v, plaintext, ciphertext[sivSize:])
+
+	expected := s2v(mac, additionalData, plaintext)
+	if subtle.ConstantTimeCompare(expected[:], v[:]) != 1 {
+		// never release unauthenticated plaintext
+		clear(plaintext)
+		plaintext = nil
+		err = ErrAuthenticationFailed
+		return
+	}
+
+	return
+}
+
+// DecryptAesSiv decrypts and authenticates the given ciphertext with AES-SIV
+// (RFC 5297) using the given 256, 384 or 512-bit key; see DecryptByteAesSiv.
+// It expects the synthetic IV followed by the ciphertext [ciphertext = SIV + ciphertext].
+func DecryptAesSiv(key, ciphertext []byte, additionalData ...[]byte) (text string, err error) {
+	plaintext, err := DecryptByteAesSiv(key, ciphertext, additionalData...)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
@@ -0,0 +1,141 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAesSivKnownAnswer(t *testing.T) {
+	// RFC 5297 Appendix A
+	t.Run("deterministic", func(t *testing.T) {
+		key := mustHex(t, "fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+		ad := mustHex(t, "101112131415161718191a1b1c1d1e1f2021222324252627")
+		plaintext := mustHex(t, "112233445566778899aabbccddee")
+		want := mustHex(t, "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c")
+
+		got, err := EncryptByteAesSiv(key, plaintext, ad)
+		if err != nil {
+			t.Fatalf("EncryptByteAesSiv: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("EncryptByteAesSiv = %x, want %x", got, want)
+		}
+		opened, err := DecryptByteAesSiv(key, want, ad)
+		if err != nil || !bytes.Equal(opened, plaintext) {
+			t.Errorf("DecryptByteAesSiv = %x, %v; want %x", opened, err, plaintext)
+		}
+	})
+
+	t.Run("nonceBased", func(t *testing.T) {
+		key := mustHex(t, "7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f")
+		ad1 := mustHex(t, "00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100")
+		ad2 := mustHex(t, "102030405060708090a0")
+		nonce := mustHex(t, "09f911029d74e35bd84156c5635688c0")
+		plaintext := mustHex(t, "7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553")
+		want := mustHex(t, "7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d")
+
+		got, err := EncryptByteAesSiv(key, plaintext, ad1, ad2, nonce)
+		if err != nil {
+			t.Fatalf("EncryptByteAesSiv: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("EncryptByteAesSiv = %x, want %x", got, want)
+		}
+		opened, err := DecryptByteAesSiv(key, want, ad1, ad2, nonce)
+		if err != nil || !bytes.Equal(opened, plaintext) {
+			t.Errorf("DecryptByteAesSiv = %x, %v; want %x", opened, err, plaintext)
+		}
+	})
+}
+
+func TestAesSivRoundTrip(t *testing.T) {
+	const text = "the quick brown fox jumps over the lazy dog"
+
+	for _, size := range []int{32, 48, 64} {
+		key := mustBytes(t, size)
+
+		for _, in := range []string{"", "short", text} {
+			ciphertext, err := EncryptAesSiv(key, in, []byte("table:users"), []byte("column:email"))
+			if err != nil {
+				t.Fatalf("EncryptAesSiv(%d-byte key): %v", size, err)
+			}
+			if want := sivSize + len(in); len(ciphertext) != want {
+				t.Errorf("ciphertext len = %d, want %d (SIV+plaintext)", len(ciphertext), want)
+			}
+			got, err := DecryptAesSiv(key, ciphertext, []byte("table:users"), []byte("column:email"))
+			if err != nil {
+				t.Fatalf("DecryptAesSiv(%d-byte key): %v", size, err)
+			}
+			if got != in {
+				t.Errorf("round-trip mismatch: got %q, want %q", got, in)
+			}
+		}
+	}
+}
+
+func TestAesSivDeterministic(t *testing.T) {
+	key := mustBytes(t, 64)
+
+	a, _ := EncryptAesSiv(key, "alice@example.com")
+	b, _ := EncryptAesSiv(key, "alice@example.com")
+	if !bytes.Equal(a, b) {
+		t.Error("same key and plaintext produced different ciphertexts")
+	}
+	c, _ := EncryptAesSiv(key, "bob@example.com")
+	if bytes.Equal(a, c) {
+		t.Error("different plaintexts produced the same ciphertext")
+	}
+}
+
+func TestAesSivErrors(t *testing.T) {
+	key := mustBytes(t, 32)
+	ciphertext, err := EncryptAesSiv(key, "secret", []byte("a"), []byte("b"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	t.Run("invalidKeySize", func(t *testing.T) {
+		for _, size := range []int{0, 16, 24, 33} {
+			if _, err := EncryptAesSiv(make([]byte, size), "x"); err == nil {
+				t.Errorf("encrypt with %d-byte key succeeded, want error", size)
+			}
+		}
+	})
+
+	t.Run("associatedData", func(t *testing.T) {
+		cases := map[string][][]byte{
+			"missing":   {[]byte("a")},
+			"reordered": {[]byte("b"), []byte("a")},
+			"changed":   {[]byte("a"), []byte("c")},
+			"joined":    {[]byte("ab")},
+		}
+		for name, ad := range cases {
+			if _, err := DecryptAesSiv(key, ciphertext, ad...); err == nil {
+				t.Errorf("%s: decryption succeeded, want failure", name)
+			}
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		for i := range ciphertext {
+			bad := bytes.Clone(ciphertext)
+			bad[i] ^= 0x01
+			if _, err := DecryptByteAesSiv(key, bad, []byte("a"), []byte("b")); err == nil {
+				t.Fatalf("decryption with byte %d flipped succeeded, want failure", i)
+			}
+		}
+	})
+
+	t.Run("tooShort", func(t *testing.T) {
+		if _, err := DecryptByteAesSiv(key, []byte{1, 2, 3}); err == nil {
+			t.Error("expected error for too-short ciphertext, got nil")
+		}
+	})
+
+	t.Run("tooManyAssociatedData", func(t *testing.T) {
+		ad := make([][]byte, sivMaxAssociatedData+1)
+		if _, err := EncryptByteAesSiv(key, nil, ad...); err == nil {
+			t.Error("expected error for too many associated data strings, got nil")
+		}
+	})
+}
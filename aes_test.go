@@ -193,3 +193,82 @@ func TestAesGcmErrors(t *testing.T) {
 		}
 	})
 }
+
+func TestAesGcmAAD(t *testing.T) {
+	const text = "attack at dawn"
+	const tagSize = 16 // GCM authentication tag
+	aad := []byte("record:42")
+
+	for name, size := range aesKeySizes {
+		t.Run(name, func(t *testing.T) {
+			key := mustBytes(t, size)
+
+			t.Run("separateNonce", func(t *testing.T) {
+				ciphertext, nonce, err := EncryptAesGcmAAD(key, text, aad)
+				if err != nil {
+					t.Fatalf("EncryptAesGcmAAD: %v", err)
+				}
+				got, err := DecryptAesGcmAAD(key, nonce, ciphertext, aad)
+				if err != nil {
+					t.Fatalf("DecryptAesGcmAAD: %v", err)
+				}
+				if got != text {
+					t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+				}
+				if _, err := DecryptByteAesGcmAAD(key, nonce, ciphertext, []byte("record:7")); err == nil {
+					t.Error("decryption with wrong AAD succeeded, want failure")
+				}
+				if _, err := DecryptByteAesGcm(key, nonce, ciphertext); err == nil {
+					t.Error("decryption without AAD succeeded, want failure")
+				}
+			})
+
+			t.Run("nonceAppended", func(t *testing.T) {
+				ciphertext, err := EncryptAesGcmWithNonceAppendedAAD(key, text, aad)
+				if err != nil {
+					t.Fatalf("EncryptAesGcmWithNonceAppendedAAD: %v", err)
+				}
+				if want := aesGcmNonceSize + len(text) + tagSize; len(ciphertext) != want {
+					t.Errorf("ciphertext len = %d, want %d (AAD must not be stored)", len(ciphertext), want)
+				}
+				got, err := DecryptAesGcmWithNonceAppendedAAD(key, ciphertext, aad)
+				if err != nil {
+					t.Fatalf("DecryptAesGcmWithNonceAppendedAAD: %v", err)
+				}
+				if got != text {
+					t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+				}
+				if _, err := DecryptByteAesGcmWithNonceAppendedAAD(key, ciphertext, []byte("record:7")); err == nil {
+					t.Error("decryption with wrong AAD succeeded, want failure")
+				}
+				if _, err := DecryptByteAesGcmWithNonceAppended(key, ciphertext); err == nil {
+					t.Error("decryption without AAD succeeded, want failure")
+				}
+			})
+
+			t.Run("nilAADMatchesPlainVariant", func(t *testing.T) {
+				ciphertext, err := EncryptByteAesGcmWithNonceAppendedAAD(key, []byte(text), nil)
+				if err != nil {
+					t.Fatalf("encrypt: %v", err)
+				}
+				if _, err := DecryptByteAesGcmWithNonceAppended(key, ciphertext); err != nil {
+					t.Errorf("plain decrypt of nil-AAD ciphertext failed: %v", err)
+				}
+
+				ciphertext, nonce, err := EncryptByteAesGcm(key, []byte(text))
+				if err != nil {
+					t.Fatalf("encrypt: %v", err)
+				}
+				if _, err := DecryptByteAesGcmAAD(key, nonce, ciphertext, nil); err != nil {
+					t.Errorf("nil-AAD decrypt of plain ciphertext failed: %v", err)
+				}
+			})
+
+			t.Run("tooShort", func(t *testing.T) {
+				if _, err := DecryptByteAesGcmWithNonceAppendedAAD(key, []byte{1, 2, 3}, aad); err == nil {
+					t.Error("expected error for too-short ciphertext, got nil")
+				}
+			})
+		})
+	}
+}
@@ -2,7 +2,6 @@ package crypt
 
 import (
 	"crypto/rand"
-	"errors"
 	"fmt"
 
 	"golang.org/x/crypto/chacha20poly1305"
@@ -25,13 +24,13 @@ func encryptByteChacha20poly1305(key, input, additionalData []byte) (ciphertext
 	// create a new ChaCha20-Poly1305 AEAD using the given 256-bit key
 	aead, err := chacha20poly1305.New(key)
 	if err != nil {
-		err = fmt.Errorf("error creating AEAD: %v", err)
+		err = fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
 		return
 	}
 
 	// reject oversized input so Seal returns an error rather than panicking
 	if uint64(len(input)) > chachaMaxPlaintextSize {
-		err = errors.New("plaintext too large")
+		err = ErrPlaintextTooLarge
 		return
 	}
 
@@ -39,7 +38,7 @@ func encryptByteChacha20poly1305(key, input, additionalData []byte) (ciphertext
 	nonce = make([]byte, aead.NonceSize())
 	_, err = rand.Read(nonce)
 	if err != nil {
-		err = fmt.Errorf("error generating nonce: %v", err)
+		err = fmt.Errorf("error generating nonce: %w", err)
 		return
 	}
 
@@ -54,25 +53,25 @@ func decryptByteChacha20poly1305(key, nonce, ciphertext, additionalData []byte)
 	// create a new ChaCha20-Poly1305 AEAD using the given 256-bit key
 	aead, err := chacha20poly1305.New(key)
 	if err != nil {
-		err = fmt.Errorf("error creating AEAD: %v", err)
+		err = fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
 		return
 	}
 
 	// reject a wrong-length nonce or oversized ciphertext so Open returns an
 	// error rather than panicking on caller-supplied input
 	if len(nonce) != aead.NonceSize() {
-		err = errors.New("invalid nonce length")
+		err = ErrInvalidNonce
 		return
 	}
 	if uint64(len(ciphertext)) > chachaMaxCiphertextSize {
-		err = errors.New("ciphertext too large")
+		err = ErrCiphertextTooLarge
 		return
 	}
 
 	// decrypt the data
 	plaintext, err = aead.Open(nil, nonce, ciphertext, additionalData)
 	if err != nil {
-		err = fmt.Errorf("error decrypting data: %v", err)
+		err = fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
 		return
 	}
 
@@ -169,7 +168,7 @@ func EncryptByteChacha20poly1305WithNonceAppendedAAD(key, input, additionalData
 func DecryptByteChacha20poly1305WithNonceAppendedAAD(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
 	nonceSize := chacha20poly1305.NonceSize
 	if len(ciphertext) < nonceSize {
-		err = errors.New("ciphertext is too short")
+		err = ErrCiphertextTooShort
 		return
 	}
 
@@ -184,13 +183,13 @@ func encryptByteXChacha20poly1305(key, input, additionalData []byte) (ciphertext
 	// create a new XChaCha20-Poly1305 AEAD using the given 256-bit key
 	aead, err := chacha20poly1305.NewX(key)
 	if err != nil {
-		err = fmt.Errorf("error creating AEAD: %v", err)
+		err = fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
 		return
 	}
 
 	// reject oversized input so Seal returns an error rather than panicking
 	if uint64(len(input)) > chachaMaxPlaintextSize {
-		err = errors.New("plaintext too large")
+		err = ErrPlaintextTooLarge
 		return
 	}
 
@@ -198,7 +197,7 @@ func encryptByteXChacha20poly1305(key, input, additionalData []byte) (ciphertext
 	nonce = make([]byte, aead.NonceSize())
 	_, err = rand.Read(nonce)
 	if err != nil {
-		err = fmt.Errorf("error generating nonce: %v", err)
+		err = fmt.Errorf("error generating nonce: %w", err)
 		return
 	}
 
@@ -213,25 +212,25 @@ func decryptByteXChacha20poly1305(key, nonce, ciphertext, additionalData []byte)
 	// create a new XChaCha20-Poly1305 AEAD using the given 256-bit key
 	aead, err := chacha20poly1305.NewX(key)
 	if err != nil {
-		err = fmt.Errorf("error creating AEAD: %v", err)
+		err = fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
 		return
 	}
 
 	// reject a wrong-length nonce or oversized ciphertext so Open returns an
 	// error rather than panicking on caller-supplied input
 	if len(nonce) != aead.NonceSize() {
-		err = errors.New("invalid nonce length")
+		err = ErrInvalidNonce
 		return
 	}
 	if uint64(len(ciphertext)) > chachaMaxCiphertextSize {
-		err = errors.New("ciphertext too large")
+		err = ErrCiphertextTooLarge
 		return
 	}
 
 	// decrypt the data
 	plaintext, err = aead.Open(nil, nonce, ciphertext, additionalData)
 	if err != nil {
-		err = fmt.Errorf("error decrypting data: %v", err)
+		err = fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
 		return
 	}
 
@@ -328,7 +327,7 @@ func EncryptByteXChacha20poly1305WithNonceAppendedAAD(key, input, additionalData
 func DecryptByteXChacha20poly1305WithNonceAppendedAAD(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
 	nonceSize := chacha20poly1305.NonceSizeX
 	if len(ciphertext) < nonceSize {
-		err = errors.New("ciphertext is too short")
+		err = ErrCiphertextTooShort
 		return
 	}
 
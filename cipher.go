@@ -0,0 +1,302 @@
+package crypt
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"unsafe"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// aeadHandle is the shared core of the long-lived cipher handles. It wraps an
+// AEAD built once from the key and seals to / opens from the same
+// nonce-appended layout as the WithNonceAppended functions
+// [ciphertext = nonce + ciphertext + tag], so the two are interchangeable.
+//
+// The wrapped AEADs (crypto/cipher GCM and x/crypto ChaCha20-Poly1305) hold no
+// per-call state, so a handle is safe for concurrent use.
+type aeadHandle struct {
+	aead          cipher.AEAD
+	random        io.Reader // nonce source, crypto/rand unless injected
+	maxPlaintext  uint64    // Seal rejects larger input instead of panicking
+	maxCiphertext uint64    // Open rejects larger input instead of panicking
+}
+
+// randomSource returns r, or crypto/rand's Reader when r is nil.
+func randomSource(r io.Reader) io.Reader {
+	if r == nil {
+		return rand.Reader
+	}
+	return r
+}
+
+// seal appends nonce + ciphertext + tag to dst. The random nonce is read
+// straight into dst's spare capacity, so nothing is allocated when dst is
+// large enough.
+func (h *aeadHandle) seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	if uint64(len(plaintext)) > h.maxPlaintext {
+		return dst, ErrPlaintextTooLarge
+	}
+
+	nonceSize := h.aead.NonceSize()
+	ret, out := sliceForAppend(dst, nonceSize+len(plaintext)+h.aead.Overhead())
+	if anyOverlap(out, plaintext) {
+		return dst, errSealOverlap
+	}
+	nonce := out[:nonceSize]
+	if _, err := io.ReadFull(h.random, nonce); err != nil {
+		return dst, fmt.Errorf("error generating nonce: %w", err)
+	}
+
+	// Seal appends ciphertext + tag right after the nonce; the nonce itself
+	// is only read, so sharing the backing array with the output is fine.
+	h.aead.Seal(out[:nonceSize], nonce, plaintext, additionalData)
+	return ret, nil
+}
+
+// open authenticates and decrypts a nonce-appended ciphertext and appends the
+// plaintext to dst.
+func (h *aeadHandle) open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	nonceSize := h.aead.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return dst, ErrCiphertextTooShort
+	}
+
+	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	if uint64(len(ciphertext)) > h.maxCiphertext {
+		return dst, ErrCiphertextTooLarge
+	}
+
+	plaintext, err := h.aead.Open(dst, nonce, ciphertext, additionalData)
+	if err != nil {
+		return dst, fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
+	}
+	return plaintext, nil
+}
+
+// errSealOverlap is returned by a Seal whose plaintext shares memory with the
+// output: the nonce is written ahead of the ciphertext, so sealing in place
+// would overwrite plaintext that is yet to be encrypted.
+var errSealOverlap = fmt.Errorf("%w: plaintext overlaps the output; in-place sealing is not supported", ErrInvalidParameters)
+
+// anyOverlap reports whether x and y share memory at any index, as
+// crypto/internal/alias.AnyOverlap does.
+func anyOverlap(x, y []byte) bool {
+	// #nosec G103
+	return len(x) > 0 && len(y) > 0 &&
+		uintptr(unsafe.Pointer(&x[0])) <= uintptr(unsafe.Pointer(&y[len(y)-1])) &&
+		uintptr(unsafe.Pointer(&y[0])) <= uintptr(unsafe.Pointer(&x[len(x)-1]))
+}
+
+// sliceForAppend extends in by n bytes, reusing its capacity when possible.
+// It returns the whole extended slice and the n-byte tail to fill.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
+
+// AesGcm is a reusable AES-GCM cipher handle. Build it once per key with
+// [NewAesGcm] and call [AesGcm.Seal] / [AesGcm.Open] for every message,
+// instead of paying for the key schedule on each call the way
+// [EncryptByteAesGcmWithNonceAppended] does. It is safe for concurrent use.
+type AesGcm struct {
+	h aeadHandle
+}
+
+// NewAesGcm returns an AES-GCM handle for the given 128, 192 or 256-bit key.
+func NewAesGcm(key []byte) (*AesGcm, error) {
+	return NewAesGcmWithRand(key, nil)
+}
+
+// NewAesGcmWithRand is [NewAesGcm] with an explicit nonce source. A nil
+// random selects crypto/rand. Anything else is meant for reproducible test
+// vectors: a predictable source makes nonces repeat, which breaks AES-GCM.
+// The handle reads random from every Seal, so it must be safe for concurrent
+// use if the handle is shared.
+func NewAesGcmWithRand(key []byte, random io.Reader) (*AesGcm, error) {
+	aead, err := aesGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &AesGcm{h: aeadHandle{
+		aead:          aead,
+		random:        randomSource(random),
+		maxPlaintext:  gcmMaxPlaintextSize,
+		maxCiphertext: gcmMaxPlaintextSize + uint64(aead.Overhead()),
+	}}, nil
+}
+
+// Seal encrypts and authenticates plaintext and additionalData (which may be
+// nil) under a fresh random 96-bit nonce and appends the result to dst
+// [dst + nonce + ciphertext + tag]. The output is readable by
+// [DecryptByteAesGcmWithNonceAppendedAAD]. No allocation happens when dst has
+// room for [AesGcm.Overhead] bytes more than the plaintext. plaintext must
+// not overlap the output: in-place sealing is not supported and is refused
+// with [ErrInvalidParameters].
+func (c *AesGcm) Seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	return c.h.seal(dst, plaintext, additionalData)
+}
+
+// Open authenticates and decrypts a nonce-appended ciphertext
+// [nonce + ciphertext + tag], such as the output of [AesGcm.Seal] or
+// [EncryptByteAesGcmWithNonceAppendedAAD], and appends the plaintext to dst.
+// additionalData must match the value supplied at encryption. To decrypt in
+// place, pass ciphertext[NonceSize:NonceSize] as dst.
+func (c *AesGcm) Open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	return c.h.open(dst, ciphertext, additionalData)
+}
+
+// Algorithm returns [AlgAesGcm].
+func (c *AesGcm) Algorithm() Algorithm {
+	return AlgAesGcm
+}
+
+// NonceSize returns the length of the nonce at the start of every ciphertext.
+func (c *AesGcm) NonceSize() int {
+	return c.h.aead.NonceSize()
+}
+
+// Overhead returns the difference between the ciphertext and plaintext
+// lengths: the nonce plus the authentication tag.
+func (c *AesGcm) Overhead() int {
+	return c.h.aead.NonceSize() + c.h.aead.Overhead()
+}
+
+// Chacha20poly1305 is a reusable ChaCha20-Poly1305 (96-bit nonce) cipher
+// handle. Build it once per key with [NewChacha20poly1305]; it is safe for
+// concurrent use. Random 96-bit nonces limit how many messages one key may
+// safely seal; prefer [XChacha20poly1305] for high volumes.
+type Chacha20poly1305 struct {
+	h aeadHandle
+}
+
+// NewChacha20poly1305 returns a ChaCha20-Poly1305 handle for the given
+// 256-bit key.
+func NewChacha20poly1305(key []byte) (*Chacha20poly1305, error) {
+	return NewChacha20poly1305WithRand(key, nil)
+}
+
+// NewChacha20poly1305WithRand is [NewChacha20poly1305] with an explicit nonce
+// source; see [NewAesGcmWithRand].
+func NewChacha20poly1305WithRand(key []byte, random io.Reader) (*Chacha20poly1305, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
+	}
+	return &Chacha20poly1305{h: aeadHandle{
+		aead:          aead,
+		random:        randomSource(random),
+		maxPlaintext:  chachaMaxPlaintextSize,
+		maxCiphertext: chachaMaxCiphertextSize,
+	}}, nil
+}
+
+// Seal encrypts and authenticates plaintext and additionalData (which may be
+// nil) under a fresh random 96-bit nonce and appends the result to dst
+// [dst + nonce + ciphertext + tag]. The output is readable by
+// [DecryptByteChacha20poly1305WithNonceAppendedAAD]. No allocation happens
+// when dst has room for [Chacha20poly1305.Overhead] bytes more than the
+// plaintext. plaintext must not overlap the output: in-place sealing is not
+// supported and is refused with [ErrInvalidParameters].
+func (c *Chacha20poly1305) Seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	return c.h.seal(dst, plaintext, additionalData)
+}
+
+// Open authenticates and decrypts a nonce-appended ciphertext
+// [nonce + ciphertext + tag], such as the output of [Chacha20poly1305.Seal]
+// or [EncryptByteChacha20poly1305WithNonceAppendedAAD], and appends the
+// plaintext to dst. additionalData must match the value supplied at
+// encryption. To decrypt in place, pass ciphertext[NonceSize:NonceSize] as
+// dst.
+func (c *Chacha20poly1305) Open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	return c.h.open(dst, ciphertext, additionalData)
+}
+
+// Algorithm returns [AlgChacha20poly1305].
+func (c *Chacha20poly1305) Algorithm() Algorithm {
+	return AlgChacha20poly1305
+}
+
+// NonceSize returns the length of the nonce at the start of every ciphertext.
+func (c *Chacha20poly1305) NonceSize() int {
+	return c.h.aead.NonceSize()
+}
+
+// Overhead returns the difference between the ciphertext and plaintext
+// lengths: the nonce plus the authentication tag.
+func (c *Chacha20poly1305) Overhead() int {
+	return c.h.aead.NonceSize() + c.h.aead.Overhead()
+}
+
+// XChacha20poly1305 is a reusable XChaCha20-Poly1305 (192-bit nonce) cipher
+// handle. Build it once per key with [NewXChacha20poly1305]; it is safe for
+// concurrent use.
+type XChacha20poly1305 struct {
+	h aeadHandle
+}
+
+// NewXChacha20poly1305 returns an XChaCha20-Poly1305 handle for the given
+// 256-bit key.
+func NewXChacha20poly1305(key []byte) (*XChacha20poly1305, error) {
+	return NewXChacha20poly1305WithRand(key, nil)
+}
+
+// NewXChacha20poly1305WithRand is [NewXChacha20poly1305] with an explicit
+// nonce source; see [NewAesGcmWithRand].
+func NewXChacha20poly1305WithRand(key []byte, random io.Reader) (*XChacha20poly1305, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
+	}
+	return &XChacha20poly1305{h: aeadHandle{
+		aead:          aead,
+		random:        randomSource(random),
+		maxPlaintext:  chachaMaxPlaintextSize,
+		maxCiphertext: chachaMaxCiphertextSize,
+	}}, nil
+}
+
+// Seal encrypts and authenticates plaintext and additionalData (which may be
+// nil) under a fresh random 192-bit nonce and appends the result to dst
+// [dst + nonce + ciphertext + tag]. The output is readable by
+// [DecryptByteXChacha20poly1305WithNonceAppendedAAD]. No allocation happens
+// when dst has room for [XChacha20poly1305.Overhead] bytes more than the
+// plaintext. plaintext must not overlap the output: in-place sealing is not
+// supported and is refused with [ErrInvalidParameters].
+func (c *XChacha20poly1305) Seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	return c.h.seal(dst, plaintext, additionalData)
+}
+
+// Open authenticates and decrypts a nonce-appended ciphertext
+// [nonce + ciphertext + tag], such as the output of [XChacha20poly1305.Seal]
+// or [EncryptByteXChacha20poly1305WithNonceAppendedAAD], and appends the
+// plaintext to dst. additionalData must match the value supplied at
+// encryption. To decrypt in place, pass ciphertext[NonceSize:NonceSize] as
+// dst.
+func (c *XChacha20poly1305) Open(dst, ciphertext, additionalData []byte) ([]byte, error) {
+	return c.h.open(dst, ciphertext, additionalData)
+}
+
+// Algorithm returns [AlgXChacha20poly1305].
+func (c *XChacha20poly1305) Algorithm() Algorithm {
+	return AlgXChacha20poly1305
+}
+
+// NonceSize returns the length of the nonce at the start of every ciphertext.
+func (c *XChacha20poly1305) NonceSize() int {
+	return c.h.aead.NonceSize()
+}
+
+// Overhead returns the difference between the ciphertext and plaintext
+// lengths: the nonce plus the authentication tag.
+func (c *XChacha20poly1305) Overhead() int {
+	return c.h.aead.NonceSize() + c.h.aead.Overhead()
+}
@@ -0,0 +1,236 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// handle is the method set shared by the cipher handles, so they can share
+// the same table-driven tests.
+type handle interface {
+	Seal(dst, plaintext, additionalData []byte) ([]byte, error)
+	Open(dst, ciphertext, additionalData []byte) ([]byte, error)
+	NonceSize() int
+	Overhead() int
+}
+
+// handleCase pairs a handle constructor with the package function that opens
+// the same nonce-appended layout, to check the two stay wire-compatible.
+type handleCase struct {
+	name      string
+	keySize   int
+	nonceSize int
+	newHandle func(key []byte) (handle, error)
+	encNAAAD  func(key, input, additionalData []byte) ([]byte, error)
+	decNAAAD  func(key, ciphertext, additionalData []byte) ([]byte, error)
+}
+
+func handleCases() []handleCase {
+	return []handleCase{
+		{
+			name: "AES-GCM", keySize: 32, nonceSize: 12,
+			newHandle: func(key []byte) (handle, error) { return NewAesGcm(key) },
+			encNAAAD:  EncryptByteAesGcmWithNonceAppendedAAD,
+			decNAAAD:  DecryptByteAesGcmWithNonceAppendedAAD,
+		},
+		{
+			name: "ChaCha20-Poly1305", keySize: 32, nonceSize: 12,
+			newHandle: func(key []byte) (handle, error) { return NewChacha20poly1305(key) },
+			encNAAAD:  EncryptByteChacha20poly1305WithNonceAppendedAAD,
+			decNAAAD:  DecryptByteChacha20poly1305WithNonceAppendedAAD,
+		},
+		{
+			name: "XChaCha20-Poly1305", keySize: 32, nonceSize: 24,
+			newHandle: func(key []byte) (handle, error) { return NewXChacha20poly1305(key) },
+			encNAAAD:  EncryptByteXChacha20poly1305WithNonceAppendedAAD,
+			decNAAAD:  DecryptByteXChacha20poly1305WithNonceAppendedAAD,
+		},
+	}
+}
+
+func TestCipherHandleRoundTrip(t *testing.T) {
+	in := []byte("the quick brown fox jumps over the lazy dog")
+	aad := []byte("record:42")
+
+	for _, c := range handleCases() {
+		t.Run(c.name, func(t *testing.T) {
+			key := mustBytes(t, c.keySize)
+			h, err := c.newHandle(key)
+			if err != nil {
+				t.Fatalf("new handle: %v", err)
+			}
+			if h.NonceSize() != c.nonceSize {
+				t.Errorf("NonceSize = %d, want %d", h.NonceSize(), c.nonceSize)
+			}
+
+			t.Run("appendsToDst", func(t *testing.T) {
+				prefix := []byte("hdr:")
+				sealed, err := h.Seal(append([]byte(nil), prefix...), in, aad)
+				if err != nil {
+					t.Fatalf("Seal: %v", err)
+				}
+				if !bytes.HasPrefix(sealed, prefix) {
+					t.Fatal("Seal did not preserve dst")
+				}
+				if want := len(prefix) + len(in) + h.Overhead(); len(sealed) != want {
+					t.Errorf("sealed len = %d, want %d", len(sealed), want)
+				}
+
+				opened, err := h.Open(append([]byte(nil), prefix...), sealed[len(prefix):], aad)
+				if err != nil {
+					t.Fatalf("Open: %v", err)
+				}
+				if !bytes.Equal(opened, append(prefix, in...)) {
+					t.Errorf("Open = %q, want prefix+plaintext", opened)
+				}
+			})
+
+			t.Run("wireCompatible", func(t *testing.T) {
+				sealed, err := h.Seal(nil, in, aad)
+				if err != nil {
+					t.Fatalf("Seal: %v", err)
+				}
+				got, err := c.decNAAAD(key, sealed, aad)
+				if err != nil || !bytes.Equal(got, in) {
+					t.Errorf("package decrypt of handle output = %q, %v", got, err)
+				}
+
+				legacy, err := c.encNAAAD(key, in, aad)
+				if err != nil {
+					t.Fatalf("package encrypt: %v", err)
+				}
+				got, err = h.Open(nil, legacy, aad)
+				if err != nil || !bytes.Equal(got, in) {
+					t.Errorf("handle Open of package output = %q, %v", got, err)
+				}
+			})
+
+			t.Run("inPlace", func(t *testing.T) {
+				sealed, err := h.Seal(nil, in, aad)
+				if err != nil {
+					t.Fatalf("Seal: %v", err)
+				}
+				ns := h.NonceSize()
+				got, err := h.Open(sealed[ns:ns], sealed, aad)
+				if err != nil || !bytes.Equal(got, in) {
+					t.Errorf("in-place Open = %q, %v", got, err)
+				}
+			})
+
+			t.Run("inPlaceSealRefused", func(t *testing.T) {
+				// the stdlib in-place form, and a plaintext further into dst
+				buf := make([]byte, len(in), len(in)+h.Overhead())
+				copy(buf, in)
+				if _, err := h.Seal(buf[:0], buf, aad); !errors.Is(err, ErrInvalidParameters) {
+					t.Errorf("in-place Seal err = %v, want ErrInvalidParameters", err)
+				}
+				big := make([]byte, 0, 2*len(in)+h.Overhead())
+				if _, err := h.Seal(big, big[h.NonceSize():h.NonceSize()+len(in)], aad); !errors.Is(err, ErrInvalidParameters) {
+					t.Errorf("overlapping Seal err = %v, want ErrInvalidParameters", err)
+				}
+				if !bytes.Equal(buf, in) {
+					t.Error("refused Seal modified the plaintext")
+				}
+				// disjoint parts of one array are fine
+				sealed, err := h.Seal(big[len(in):len(in)], big[:len(in)], aad)
+				if err != nil {
+					t.Fatalf("Seal after the plaintext: %v", err)
+				}
+				if got, err := h.Open(nil, sealed, aad); err != nil || !bytes.Equal(got, big[:len(in)]) {
+					t.Errorf("Open = %q, %v", got, err)
+				}
+			})
+
+			t.Run("wrongAADFails", func(t *testing.T) {
+				sealed, err := h.Seal(nil, in, aad)
+				if err != nil {
+					t.Fatalf("Seal: %v", err)
+				}
+				if _, err := h.Open(nil, sealed, []byte("record:7")); err == nil {
+					t.Error("Open with wrong AAD succeeded, want failure")
+				}
+			})
+
+			t.Run("tooShort", func(t *testing.T) {
+				if _, err := h.Open(nil, []byte{1, 2, 3}, nil); err == nil {
+					t.Error("expected error for too-short ciphertext, got nil")
+				}
+			})
+		})
+	}
+}
+
+func TestCipherHandleInvalidKey(t *testing.T) {
+	for _, c := range handleCases() {
+		t.Run(c.name, func(t *testing.T) {
+			if _, err := c.newHandle([]byte("too-short")); err == nil {
+				t.Error("expected error for invalid key size, got nil")
+			}
+		})
+	}
+}
+
+func TestCipherHandleNoAllocs(t *testing.T) {
+	in := make([]byte, 256)
+	aad := []byte("record:42")
+
+	for _, c := range handleCases() {
+		t.Run(c.name, func(t *testing.T) {
+			h, err := c.newHandle(mustBytes(t, c.keySize))
+			if err != nil {
+				t.Fatalf("new handle: %v", err)
+			}
+			sealBuf := make([]byte, 0, len(in)+h.Overhead())
+			openBuf := make([]byte, 0, len(in))
+			sealed, err := h.Seal(sealBuf, in, aad)
+			if err != nil {
+				t.Fatalf("Seal: %v", err)
+			}
+
+			allocs := testing.AllocsPerRun(100, func() {
+				if _, err := h.Seal(sealBuf, in, aad); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := h.Open(openBuf, sealed, aad); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if allocs != 0 {
+				t.Errorf("Seal+Open allocated %.1f times per run, want 0", allocs)
+			}
+		})
+	}
+}
+
+func TestCipherHandleConcurrent(t *testing.T) {
+	for _, c := range handleCases() {
+		t.Run(c.name, func(t *testing.T) {
+			h, err := c.newHandle(mustBytes(t, c.keySize))
+			if err != nil {
+				t.Fatalf("new handle: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			for i := range 8 {
+				wg.Go(func() {
+					in := []byte{byte(i), 1, 2, 3}
+					for range 100 {
+						sealed, err := h.Seal(nil, in, nil)
+						if err != nil {
+							t.Errorf("Seal: %v", err)
+							return
+						}
+						got, err := h.Open(nil, sealed, nil)
+						if err != nil || !bytes.Equal(got, in) {
+							t.Errorf("Open = %q, %v", got, err)
+							return
+						}
+					}
+				})
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package crypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+)
+
+// cmacBlockSize is the AES block size CMAC operates on.
+const cmacBlockSize = 16
+
+// cmac computes AES-CMAC (RFC 4493, NIST SP 800-38B) over a message. It backs
+// the S2V construction of AES-SIV and the key derivation of XAES-256-GCM, so
+// it holds the subkeys for one block cipher and can MAC many messages.
+type cmac struct {
+	block  cipher.Block
+	k1, k2 [cmacBlockSize]byte
+}
+
+// newCMAC derives the CMAC subkeys K1 and K2 for block.
+func newCMAC(block cipher.Block) *cmac {
+	c := &cmac{block: block}
+	var l [cmacBlockSize]byte
+	block.Encrypt(l[:], l[:])
+	c.k1 = dbl(l)
+	c.k2 = dbl(c.k1)
+	clear(l[:])
+	return c
+}
+
+// sum returns the CMAC of msg.
+func (c *cmac) sum(msg []byte) (mac [cmacBlockSize]byte) {
+	// every block but the last is plain CBC-MAC
+	for len(msg) > cmacBlockSize {
+		subtle.XORBytes(mac[:], mac[:], msg[:cmacBlockSize])
+		c.block.Encrypt(mac[:], mac[:])
+		msg = msg[cmacBlockSize:]
+	}
+
+	// the last block is masked with K1 if complete, or padded with
+	// 10* and masked with K2 if partial (or empty)
+	var last [cmacBlockSize]byte
+	copy(last[:], msg)
+	if len(msg) == cmacBlockSize {
+		subtle.XORBytes(last[:], last[:], c.k1[:])
+	} else {
+		last[len(msg)] = 0x80
+		subtle.XORBytes(last[:], last[:], c.k2[:])
+	}
+	subtle.XORBytes(mac[:], mac[:], last[:])
+	c.block.Encrypt(mac[:], mac[:])
+	return mac
+}
+
+// dbl multiplies a 128-bit string by x in GF(2^128) with the CMAC polynomial
+// x^128 + x^7 + x^2 + x + 1, in constant time.
+func dbl(in [cmacBlockSize]byte) (out [cmacBlockSize]byte) {
+	var carry byte
+	for i := cmacBlockSize - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+	// 0x87 if the top bit was set, 0 otherwise, without branching on it
+	out[cmacBlockSize-1] ^= 0x87 & -carry
+	return out
+}
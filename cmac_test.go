@@ -0,0 +1,40 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestCMACKnownAnswer(t *testing.T) {
+	// RFC 4493 section 4
+	block, err := aes.NewCipher(mustHex(t, "2b7e151628aed2a6abf7158809cf4f3c"))
+	if err != nil {
+		t.Fatalf("aes.NewCipher: %v", err)
+	}
+	mac := newCMAC(block)
+
+	const msg = "6bc1bee22e409f96e93d7e117393172a" +
+		"ae2d8a571e03ac9c9eb76fac45af8e51" +
+		"30c81c46a35ce411e5fbc1191a0a52ef" +
+		"f69f2445df4f9b17ad2b417be66c3710"
+	vectors := []struct {
+		name string
+		len  int
+		want string
+	}{
+		{"empty", 0, "bb1d6929e95937287fa37d129b756746"},
+		{"oneBlock", 16, "070a16b46b4d4144f79bdd9dd04a287c"},
+		{"partialBlock", 40, "dfa66747de9ae63030ca32611497c827"},
+		{"fourBlocks", 64, "51f0bebf7e3b9d92fc49741779363cfe"},
+	}
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			got := mac.sum(mustHex(t, msg)[:v.len])
+			if hex.EncodeToString(got[:]) != v.want {
+				t.Errorf("CMAC = %x, want %s", got, v.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package crypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// Key-committing AEAD.
+//
+// AES-GCM and (X)ChaCha20-Poly1305 are not key-committing: an attacker who
+// chooses two keys can craft one ciphertext that authenticates under both and
+// decrypts to different plaintexts (partitioning-oracle and "invisible
+// salamander" attacks). That matters whenever the decrypting side tries more
+// than one key, for example password-derived keys or user-selected keys.
+//
+// The committing variants prepend a commitment to the nonce-appended layout:
+//
+//	commitment (32 bytes) || nonce || ciphertext || tag
+//	commitment = HMAC-SHA256(committingLabel, algorithm ID || len(key) (2 bytes) || key || nonce)
+//
+// The key is hashed as a length-prefixed message rather than used as the HMAC
+// key: HMAC zero-pads short keys, so a 16-byte key K and the 32-byte K||0^16
+// would commit identically. With the length bound in, the commitment inherits
+// SHA-256's collision resistance and no second key, of any length, can
+// reproduce it. Decryption checks the commitment in
+// constant time before the AEAD is even tried. Binding the nonce keeps the
+// commitment from becoming a per-key fingerprint that links ciphertexts;
+// binding the algorithm ID keeps one cipher's blob from opening under another.
+
+const (
+	// commitmentSize is the length of the commitment that leads every
+	// key-committing ciphertext.
+	commitmentSize = sha256.Size
+
+	// committingLabel is the fixed HMAC key of the commitment, which
+	// domain-separates it from any other SHA-256 use of the AEAD key. It is
+	// frozen: changing it orphans every committing ciphertext.
+	committingLabel = "pilinux/crypt:key-commitment:v1"
+)
+
+// keyCommitment computes the commitment of key to alg and nonce.
+func keyCommitment(alg Algorithm, key, nonce []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(committingLabel))
+	mac.Write([]byte{byte(alg)})
+	// AEAD keys are at most 32 bytes, far below the 16-bit length field
+	mac.Write(binary.BigEndian.AppendUint16(nil, uint16(len(key)))) // #nosec G115
+	mac.Write(key)
+	mac.Write(nonce)
+	return mac.Sum(nil)
+}
+
+// encryptCommitting is the shared key-committing encryption core. It seals
+// input with alg under key and prepends the commitment
+// [ciphertext = commitment + nonce + ciphertext].
+func encryptCommitting(alg Algorithm, key, input, additionalData []byte) (ciphertext []byte, err error) {
+	a, err := NewAEAD(alg, key)
+	if err != nil {
+		return
+	}
+
+	// seal straight behind a commitment-sized gap, then fill the gap
+	out := make([]byte, commitmentSize, commitmentSize+a.Overhead()+len(input))
+	out, err = a.Seal(out, input, additionalData)
+	if err != nil {
+		return
+	}
+
+	nonce := out[commitmentSize : commitmentSize+a.NonceSize()]
+	copy(out, keyCommitment(alg, key, nonce))
+	ciphertext = out
+	return
+}
+
+// decryptCommitting is the shared key-committing decryption core. It verifies
+// the commitment before attempting to decrypt.
+func decryptCommitting(alg Algorithm, key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	a, err := NewAEAD(alg, key)
+	if err != nil {
+		return
+	}
+
+	if len(ciphertext) < commitmentSize+a.NonceSize() {
+		err = ErrCiphertextTooShort
+		return
+	}
+
+	commitment, ciphertext := ciphertext[:commitmentSize], ciphertext[commitmentSize:]
+	if !hmac.Equal(commitment, keyCommitment(alg, key, ciphertext[:a.NonceSize()])) {
+		err = fmt.Errorf("%w: key commitment mismatch", ErrAuthenticationFailed)
+		return
+	}
+
+	return a.Open(nil, ciphertext, additionalData)
+}
+
+// EncryptByteAesGcmCommitting encrypts and authenticates the given message (bytes) with
+// AES in GCM mode using the given 128, 192 or 256-bit key, and commits the ciphertext to
+// that key so it can never decrypt under any other.
+// It prepends the commitment and the nonce [ciphertext = commitment + nonce + ciphertext].
+func EncryptByteAesGcmCommitting(key []byte, input []byte) (ciphertext []byte, err error) {
+	return encryptCommitting(AlgAesGcm, key, input, nil)
+}
+
+// EncryptAesGcmCommitting encrypts and authenticates the given message (string) with
+// AES in GCM mode using the given 128, 192 or 256-bit key, and commits the ciphertext to
+// that key so it can never decrypt under any other.
+// It prepends the commitment and the nonce [ciphertext = commitment + nonce + ciphertext].
+func EncryptAesGcmCommitting(key []byte, text string) (ciphertext []byte, err error) {
+	return EncryptByteAesGcmCommitting(key, []byte(text))
+}
+
+// DecryptByteAesGcmCommitting verifies the key commitment, then decrypts and authenticates
+// the given ciphertext with AES in GCM mode using the given 128, 192 or 256-bit key.
+// It expects [ciphertext = commitment + nonce + ciphertext].
+func DecryptByteAesGcmCommitting(key, ciphertext []byte) (plaintext []byte, err error) {
+	return decryptCommitting(AlgAesGcm, key, ciphertext, nil)
+}
+
+// DecryptAesGcmCommitting verifies the key commitment, then decrypts and authenticates
+// the given ciphertext with AES in GCM mode using the given 128, 192 or 256-bit key.
+// It expects [ciphertext = commitment + nonce + ciphertext].
+func DecryptAesGcmCommitting(key, ciphertext []byte) (text string, err error) {
+	plaintext, err := DecryptByteAesGcmCommitting(key, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteAesGcmCommittingAAD is EncryptByteAesGcmCommitting that additionally
+// authenticates additionalData (AAD). The AAD is neither encrypted nor included in the
+// output; the identical bytes must be supplied at decryption.
+func EncryptByteAesGcmCommittingAAD(key, input, additionalData []byte) (ciphertext []byte, err error) {
+	return encryptCommitting(AlgAesGcm, key, input, additionalData)
+}
+
+// DecryptByteAesGcmCommittingAAD is DecryptByteAesGcmCommitting that verifies
+// additionalData (AAD) against the value supplied at encryption.
+func DecryptByteAesGcmCommittingAAD(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	return decryptCommitting(AlgAesGcm, key, ciphertext, additionalData)
+}
+
+// EncryptByteChacha20poly1305Committing encrypts and authenticates the given message (bytes)
+// with ChaCha20-Poly1305 AEAD using the given 256-bit key and 96-bit nonce, and commits the
+// ciphertext to that key so it can never decrypt under any other.
+// It prepends the commitment and the nonce [ciphertext = commitment + nonce + ciphertext].
+func EncryptByteChacha20poly1305Committing(key []byte, input []byte) (ciphertext []byte, err error) {
+	return encryptCommitting(AlgChacha20poly1305, key, input, nil)
+}
+
+// EncryptChacha20poly1305Committing encrypts and authenticates the given message (string)
+// with ChaCha20-Poly1305 AEAD using the given 256-bit key and 96-bit nonce, and commits the
+// ciphertext to that key so it can never decrypt under any other.
+// It prepends the commitment and the nonce [ciphertext = commitment + nonce + ciphertext].
+func EncryptChacha20poly1305Committing(key []byte, text string) (ciphertext []byte, err error) {
+	return EncryptByteChacha20poly1305Committing(key, []byte(text))
+}
+
+// DecryptByteChacha20poly1305Committing verifies the key commitment, then decrypts and
+// authenticates the given ciphertext with ChaCha20-Poly1305 AEAD using the given 256-bit key.
+// It expects [ciphertext = commitment + nonce + ciphertext].
+func DecryptByteChacha20poly1305Committing(key, ciphertext []byte) (plaintext []byte, err error) {
+	return decryptCommitting(AlgChacha20poly1305, key, ciphertext, nil)
+}
+
+// DecryptChacha20poly1305Committing verifies the key commitment, then decrypts and
+// authenticates the given ciphertext with ChaCha20-Poly1305 AEAD using the given 256-bit key.
+// It expects [ciphertext = commitment + nonce + ciphertext].
+func DecryptChacha20poly1305Committing(key, ciphertext []byte) (text string, err error) {
+	plaintext, err := DecryptByteChacha20poly1305Committing(key, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteChacha20poly1305CommittingAAD is EncryptByteChacha20poly1305Committing that
+// additionally authenticates additionalData (AAD). The AAD is neither encrypted nor included
+// in the output; the identical bytes must be supplied at decryption.
+func EncryptByteChacha20poly1305CommittingAAD(key, input, additionalData []byte) (ciphertext []byte, err error) {
+	return encryptCommitting(AlgChacha20poly1305, key, input, additionalData)
+}
+
+// DecryptByteChacha20poly1305CommittingAAD is DecryptByteChacha20poly1305Committing that
+// verifies additionalData (AAD) against the value supplied at encryption.
+func DecryptByteChacha20poly1305CommittingAAD(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	return decryptCommitting(AlgChacha20poly1305, key, ciphertext, additionalData)
+}
+
+// EncryptByteXChacha20poly1305Committing encrypts and authenticates the given message (bytes)
+// with XChaCha20-Poly1305 AEAD using the given 256-bit key and 192-bit nonce, and commits the
+// ciphertext to that key so it can never decrypt under any other.
+// It prepends the commitment and the nonce [ciphertext = commitment + nonce + ciphertext].
+func EncryptByteXChacha20poly1305Committing(key []byte, input []byte) (ciphertext []byte, err error) {
+	return encryptCommitting(AlgXChacha20poly1305, key, input, nil)
+}
+
+// EncryptXChacha20poly1305Committing encrypts and authenticates the given message (string)
+// with XChaCha20-Poly1305 AEAD using the given 256-bit key and 192-bit nonce, and commits the
+// ciphertext to that key so it can never decrypt under any other.
+// It prepends the commitment and the nonce [ciphertext = commitment + nonce + ciphertext].
+func EncryptXChacha20poly1305Committing(key []byte, text string) (ciphertext []byte, err error) {
+	return EncryptByteXChacha20poly1305Committing(key, []byte(text))
+}
+
+// DecryptByteXChacha20poly1305Committing verifies the key commitment, then decrypts and
+// authenticates the given ciphertext with XChaCha20-Poly1305 AEAD using the given 256-bit key.
+// It expects [ciphertext = commitment + nonce + ciphertext].
+func DecryptByteXChacha20poly1305Committing(key, ciphertext []byte) (plaintext []byte, err error) {
+	return decryptCommitting(AlgXChacha20poly1305, key, ciphertext, nil)
+}
+
+// DecryptXChacha20poly1305Committing verifies the key commitment, then decrypts and
+// authenticates the given ciphertext with XChaCha20-Poly1305 AEAD using the given 256-bit key.
+// It expects [ciphertext = commitment + nonce + ciphertext].
+func DecryptXChacha20poly1305Committing(key, ciphertext []byte) (text string, err error) {
+	plaintext, err := DecryptByteXChacha20poly1305Committing(key, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteXChacha20poly1305CommittingAAD is EncryptByteXChacha20poly1305Committing that
+// additionally authenticates additionalData (AAD). The AAD is neither encrypted nor included
+// in the output; the identical bytes must be supplied at decryption.
+func EncryptByteXChacha20poly1305CommittingAAD(key, input, additionalData []byte) (ciphertext []byte, err error) {
+	return encryptCommitting(AlgXChacha20poly1305, key, input, additionalData)
+}
+
+// DecryptByteXChacha20poly1305CommittingAAD is DecryptByteXChacha20poly1305Committing that
+// verifies additionalData (AAD) against the value supplied at encryption.
+func DecryptByteXChacha20poly1305CommittingAAD(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	return decryptCommitting(AlgXChacha20poly1305, key, ciphertext, additionalData)
+}
@@ -0,0 +1,174 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// committingCase bundles the function set of one key-committing cipher so
+// they can share the same table-driven tests.
+type committingCase struct {
+	name    string
+	keySize int
+	encStr  func(key []byte, text string) ([]byte, error)
+	decStr  func(key, ciphertext []byte) (string, error)
+	encAAD  func(key, input, additionalData []byte) ([]byte, error)
+	decAAD  func(key, ciphertext, additionalData []byte) ([]byte, error)
+	decByte func(key, ciphertext []byte) ([]byte, error)
+	// decPlain opens the nonce-appended layout behind the commitment
+	decPlain func(key, ciphertext, additionalData []byte) ([]byte, error)
+}
+
+func committingCases() []committingCase {
+	return []committingCase{
+		{
+			name: "AES-GCM", keySize: 32,
+			encStr:   EncryptAesGcmCommitting,
+			decStr:   DecryptAesGcmCommitting,
+			encAAD:   EncryptByteAesGcmCommittingAAD,
+			decAAD:   DecryptByteAesGcmCommittingAAD,
+			decByte:  DecryptByteAesGcmCommitting,
+			decPlain: DecryptByteAesGcmWithNonceAppendedAAD,
+		},
+		{
+			name: "ChaCha20-Poly1305", keySize: 32,
+			encStr:   EncryptChacha20poly1305Committing,
+			decStr:   DecryptChacha20poly1305Committing,
+			encAAD:   EncryptByteChacha20poly1305CommittingAAD,
+			decAAD:   DecryptByteChacha20poly1305CommittingAAD,
+			decByte:  DecryptByteChacha20poly1305Committing,
+			decPlain: DecryptByteChacha20poly1305WithNonceAppendedAAD,
+		},
+		{
+			name: "XChaCha20-Poly1305", keySize: 32,
+			encStr:   EncryptXChacha20poly1305Committing,
+			decStr:   DecryptXChacha20poly1305Committing,
+			encAAD:   EncryptByteXChacha20poly1305CommittingAAD,
+			decAAD:   DecryptByteXChacha20poly1305CommittingAAD,
+			decByte:  DecryptByteXChacha20poly1305Committing,
+			decPlain: DecryptByteXChacha20poly1305WithNonceAppendedAAD,
+		},
+	}
+}
+
+func TestCommittingRoundTrip(t *testing.T) {
+	const text = "the quick brown fox jumps over the lazy dog"
+	aad := []byte("record:42")
+
+	for _, c := range committingCases() {
+		t.Run(c.name, func(t *testing.T) {
+			key := mustBytes(t, c.keySize)
+
+			t.Run("string", func(t *testing.T) {
+				ciphertext, err := c.encStr(key, text)
+				if err != nil {
+					t.Fatalf("encrypt: %v", err)
+				}
+				got, err := c.decStr(key, ciphertext)
+				if err != nil {
+					t.Fatalf("decrypt: %v", err)
+				}
+				if got != text {
+					t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+				}
+			})
+
+			t.Run("AAD", func(t *testing.T) {
+				ciphertext, err := c.encAAD(key, []byte(text), aad)
+				if err != nil {
+					t.Fatalf("encrypt: %v", err)
+				}
+				got, err := c.decAAD(key, ciphertext, aad)
+				if err != nil || string(got) != text {
+					t.Fatalf("decrypt = %q, %v", got, err)
+				}
+				if _, err := c.decAAD(key, ciphertext, []byte("record:7")); err == nil {
+					t.Error("decryption with wrong AAD succeeded, want failure")
+				}
+				if _, err := c.decByte(key, ciphertext); err == nil {
+					t.Error("decryption without AAD succeeded, want failure")
+				}
+			})
+
+			t.Run("layout", func(t *testing.T) {
+				// the commitment is prepended to the ordinary nonce-appended
+				// layout, which stays readable by the plain functions
+				ciphertext, err := c.encAAD(key, []byte(text), aad)
+				if err != nil {
+					t.Fatalf("encrypt: %v", err)
+				}
+				got, err := c.decPlain(key, ciphertext[commitmentSize:], aad)
+				if err != nil || string(got) != text {
+					t.Errorf("plain decrypt behind commitment = %q, %v", got, err)
+				}
+			})
+		})
+	}
+}
+
+func TestCommittingRejects(t *testing.T) {
+	for _, c := range committingCases() {
+		t.Run(c.name, func(t *testing.T) {
+			key := mustBytes(t, c.keySize)
+			ciphertext, err := c.encStr(key, "secret")
+			if err != nil {
+				t.Fatalf("encrypt: %v", err)
+			}
+
+			t.Run("wrongKeyFailsCommitment", func(t *testing.T) {
+				// a second key is refused by the commitment itself, before
+				// the (non-committing) AEAD gets a chance to accept it
+				_, err := c.decByte(mustBytes(t, c.keySize), ciphertext)
+				if !errors.Is(err, ErrAuthenticationFailed) {
+					t.Errorf("err = %v, want ErrAuthenticationFailed", err)
+				}
+			})
+
+			t.Run("tampered", func(t *testing.T) {
+				for i := range ciphertext {
+					bad := bytes.Clone(ciphertext)
+					bad[i] ^= 0x01
+					if _, err := c.decByte(key, bad); err == nil {
+						t.Fatalf("decryption with byte %d flipped succeeded, want failure", i)
+					}
+				}
+			})
+
+			t.Run("crossAlgorithm", func(t *testing.T) {
+				for _, other := range committingCases() {
+					if other.name == c.name {
+						continue
+					}
+					if _, err := other.decByte(key, ciphertext); err == nil {
+						t.Errorf("%s opened a %s ciphertext", other.name, c.name)
+					}
+				}
+			})
+
+			t.Run("tooShort", func(t *testing.T) {
+				if _, err := c.decByte(key, make([]byte, commitmentSize)); err == nil {
+					t.Error("expected error for too-short ciphertext, got nil")
+				}
+			})
+		})
+	}
+}
+
+func TestCommittingKeyLength(t *testing.T) {
+	// HMAC zero-pads short keys, so the commitment must bind the key length:
+	// an AES-128 key K and the AES-256 key K||0^16 are different keys.
+	key := mustBytes(t, 16)
+	padded := append(bytes.Clone(key), make([]byte, 16)...)
+	ciphertext, err := EncryptByteAesGcmCommitting(key, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptByteAesGcmCommitting(padded, ciphertext); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("K||0^16 err = %v, want ErrAuthenticationFailed", err)
+	}
+	nonce := ciphertext[commitmentSize : commitmentSize+12]
+	if bytes.Equal(keyCommitment(AlgAesGcm, key, nonce), keyCommitment(AlgAesGcm, padded, nonce)) {
+		t.Error("K and K||0^16 share a commitment")
+	}
+}
@@ -0,0 +1,271 @@
+package cryptotest_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pilinux/crypt"
+	"github.com/pilinux/crypt/cryptotest"
+	"github.com/pilinux/crypt/envelope"
+)
+
+// update rewrites vectors.json from vectorSpecs. The vectors are frozen:
+// only regenerate them for a deliberate, versioned format change.
+var update = flag.Bool("update", false, "rewrite vectors.json")
+
+// pattern returns n bytes of a simple repeating sequence, so the larger
+// plaintexts are easy to regenerate in other languages.
+func pattern(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i % 251)
+	}
+	return b
+}
+
+// masterKey is 00 01 02 ... 1f.
+func masterKey() []byte {
+	return pattern(envelope.KeySize)
+}
+
+// vectorSpecs are the inputs of the frozen vectors; Ciphertext is filled in
+// by sealing.
+func vectorSpecs() []cryptotest.Vector {
+	seed := func(name string) []byte { return []byte("pilinux/crypt vector " + name) }
+	v := func(name, format string, chunk int, aad, plaintext []byte) cryptotest.Vector {
+		return cryptotest.Vector{
+			Name: name, Format: format, MasterKey: masterKey(), Seed: seed(name),
+			AAD: aad, ChunkSize: chunk, Plaintext: plaintext,
+		}
+	}
+	chunk := envelope.MinChunkSize
+	return []cryptotest.Vector{
+		v("token-empty", cryptotest.FormatToken, 0, nil, []byte{}),
+		v("token-hello", cryptotest.FormatToken, 0, nil, []byte("hello, world")),
+		v("token-aad", cryptotest.FormatToken, 0, []byte("user:42:email"), []byte("alice@example.com")),
+		v("stream-empty", cryptotest.FormatStream, chunk, nil, []byte{}),
+		v("stream-short", cryptotest.FormatStream, chunk, nil, pattern(100)),
+		v("stream-exact-chunk", cryptotest.FormatStream, chunk, nil, pattern(chunk)),
+		v("stream-multi-chunk-aad", cryptotest.FormatStream, chunk, []byte("file:7"), pattern(2*chunk+5)),
+		v("padded-empty", cryptotest.FormatPadded, chunk, nil, []byte{}),
+		v("padded-short", cryptotest.FormatPadded, chunk, []byte("doc:1"), pattern(10)),
+		v("padded-multi-chunk", cryptotest.FormatPadded, chunk, nil, pattern(1500)),
+	}
+}
+
+// scheme returns a default-label scheme drawing from v's seed.
+func scheme(v cryptotest.Vector) *envelope.Scheme {
+	return envelope.New(envelope.Config{
+		ChunkSize: v.ChunkSize,
+		Rand:      cryptotest.NewDeterministicReader(v.Seed),
+	})
+}
+
+// sealVector seals v.Plaintext in v's format.
+func sealVector(t *testing.T, v cryptotest.Vector) []byte {
+	t.Helper()
+	s := scheme(v)
+	switch v.Format {
+	case cryptotest.FormatToken:
+		out, err := s.SealBytesAAD(v.MasterKey, v.Plaintext, v.AAD)
+		if err != nil {
+			t.Fatalf("SealBytesAAD: %v", err)
+		}
+		return out
+	case cryptotest.FormatStream:
+		var out bytes.Buffer
+		if _, err := s.SealStreamAAD(v.MasterKey, &out, bytes.NewReader(v.Plaintext), v.AAD); err != nil {
+			t.Fatalf("SealStreamAAD: %v", err)
+		}
+		return out.Bytes()
+	case cryptotest.FormatPadded:
+		dir := t.TempDir()
+		src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+		if err := os.WriteFile(src, v.Plaintext, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.SealPaddedFileAAD(v.MasterKey, dst, src, v.AAD); err != nil {
+			t.Fatalf("SealPaddedFileAAD: %v", err)
+		}
+		out, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	t.Fatalf("unknown format %q", v.Format)
+	return nil
+}
+
+// openVector opens v.Ciphertext in v's format.
+func openVector(t *testing.T, v cryptotest.Vector) []byte {
+	t.Helper()
+	s := envelope.Default()
+	switch v.Format {
+	case cryptotest.FormatToken:
+		out, err := s.OpenBytesAAD(v.MasterKey, v.Ciphertext, v.AAD)
+		if err != nil {
+			t.Fatalf("OpenBytesAAD: %v", err)
+		}
+		return out
+	case cryptotest.FormatStream:
+		var out bytes.Buffer
+		if _, err := s.OpenStreamAAD(v.MasterKey, &out, bytes.NewReader(v.Ciphertext), v.AAD); err != nil {
+			t.Fatalf("OpenStreamAAD: %v", err)
+		}
+		return out.Bytes()
+	case cryptotest.FormatPadded:
+		dir := t.TempDir()
+		src, dst := filepath.Join(dir, "src"), filepath.Join(dir, "dst")
+		if err := os.WriteFile(src, v.Ciphertext, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.OpenPaddedFileAAD(v.MasterKey, dst, src, v.AAD); err != nil {
+			t.Fatalf("OpenPaddedFileAAD: %v", err)
+		}
+		out, err := os.ReadFile(dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	t.Fatalf("unknown format %q", v.Format)
+	return nil
+}
+
+func TestUpdateVectors(t *testing.T) {
+	if !*update {
+		t.Skip("run with -update to regenerate vectors.json")
+	}
+	specs := vectorSpecs()
+	for i := range specs {
+		specs[i].Ciphertext = sealVector(t, specs[i])
+	}
+	data, err := cryptotest.MarshalVectors(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("vectors.json", data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKnownAnswerVectors(t *testing.T) {
+	if *update {
+		t.Skip("vectors are being regenerated")
+	}
+	sets := map[string][]cryptotest.Vector{
+		cryptotest.FormatToken:  cryptotest.TokenVectors(),
+		cryptotest.FormatStream: cryptotest.StreamVectors(),
+		cryptotest.FormatPadded: cryptotest.PaddedVectors(),
+	}
+	specs := vectorSpecs()
+	total := 0
+	for format, vs := range sets {
+		if len(vs) == 0 {
+			t.Errorf("no %s vectors", format)
+		}
+		total += len(vs)
+	}
+	if total != len(specs) {
+		t.Errorf("vectors.json has %d vectors, specs have %d", total, len(specs))
+	}
+
+	for _, vs := range sets {
+		for _, v := range vs {
+			t.Run(v.Name, func(t *testing.T) {
+				if got := sealVector(t, v); !bytes.Equal(got, v.Ciphertext) {
+					t.Errorf("sealed output differs from the frozen ciphertext")
+				}
+				if got := openVector(t, v); !bytes.Equal(got, v.Plaintext) {
+					t.Errorf("opened plaintext differs")
+				}
+			})
+		}
+	}
+}
+
+func TestDeterministicReader(t *testing.T) {
+	seed := []byte("seed")
+	a, b := cryptotest.NewDeterministicReader(seed), cryptotest.NewDeterministicReader(seed)
+
+	// reading in odd pieces yields the same stream as one large read
+	want := make([]byte, 100)
+	if _, err := io.ReadFull(a, want); err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	for _, n := range []int{1, 31, 33, 35} {
+		p := make([]byte, n)
+		if _, err := io.ReadFull(b, p); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, p...)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("chunked reads differ from a single read")
+	}
+
+	// the documented construction: SHA-256(seed || uint64be(counter))
+	var ctr [8]byte
+	first := sha256.Sum256(append(append([]byte{}, seed...), ctr[:]...))
+	binary.BigEndian.PutUint64(ctr[:], 1)
+	second := sha256.Sum256(append(append([]byte{}, seed...), ctr[:]...))
+	if !bytes.Equal(want[:32], first[:]) || !bytes.Equal(want[32:64], second[:]) {
+		t.Error("output does not match SHA-256(seed || counter)")
+	}
+
+	other := make([]byte, 32)
+	_, _ = cryptotest.NewDeterministicReader([]byte("other")).Read(other)
+	if bytes.Equal(other, want[:32]) {
+		t.Error("different seeds produce the same output")
+	}
+}
+
+func TestFixedReaderDictatesNonce(t *testing.T) {
+	nonce := bytes.Repeat([]byte{0xAB}, 24)
+	r := cryptotest.NewFixedReader(nonce)
+	h, err := crypt.NewXChacha20poly1305WithRand(masterKey(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ct, err := h.Seal(nil, []byte("msg"), nil)
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if !bytes.Equal(ct[:24], nonce) {
+		t.Errorf("nonce = %x, want %x", ct[:24], nonce)
+	}
+	if r.Remaining() != 0 {
+		t.Errorf("%d bytes left unread", r.Remaining())
+	}
+
+	// the source is exhausted, so the next Seal must fail, not reuse a nonce
+	if _, err := h.Seal(nil, []byte("msg"), nil); err == nil {
+		t.Error("Seal with an exhausted source succeeded")
+	}
+}
+
+func TestFailingReader(t *testing.T) {
+	boom := errors.New("entropy unavailable")
+	s := envelope.New(envelope.Config{Rand: cryptotest.FailingReader{Err: boom}})
+	if _, err := s.SealBytes(masterKey(), []byte("x")); !errors.Is(err, boom) {
+		t.Errorf("SealBytes err = %v, want %v", err, boom)
+	}
+
+	h, err := crypt.NewAesGcmWithRand(pattern(16), cryptotest.FailingReader{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.Seal(nil, []byte("x"), nil); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("AesGcm.Seal err = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
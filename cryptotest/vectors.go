@@ -0,0 +1,152 @@
+package cryptotest
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Vector formats.
+const (
+	// FormatToken is a byte envelope from envelope.Scheme.SealBytesAAD. The
+	// base64 token of SealStringAAD is the standard base64 encoding of the
+	// same bytes.
+	FormatToken = "token"
+
+	// FormatStream is a stream from envelope.Scheme.SealStreamAAD.
+	FormatStream = "stream"
+
+	// FormatPadded is a file from envelope.Scheme.SealPaddedFileAAD.
+	FormatPadded = "padded"
+)
+
+// Vector is one known-answer test. Sealing Plaintext under MasterKey and AAD
+// with a scheme that uses the default labels, the given ChunkSize and
+// NewDeterministicReader(Seed) as its randomness source yields exactly
+// Ciphertext; opening Ciphertext yields Plaintext.
+//
+// The reader is consumed in format order: a token draws its 16-byte salt and
+// then its 24-byte nonce; a stream or padded file draws its 16-byte salt and
+// then its 15-byte nonce prefix.
+type Vector struct {
+	Name       string
+	Format     string // FormatToken, FormatStream or FormatPadded
+	MasterKey  []byte
+	Seed       []byte
+	AAD        []byte // nil when the vector uses no AAD
+	ChunkSize  int    // streaming chunk size; 0 for tokens
+	Plaintext  []byte
+	Ciphertext []byte
+}
+
+// vectorJSON is the hex-encoded form of a Vector in vectors.json.
+type vectorJSON struct {
+	Name       string `json:"name"`
+	Format     string `json:"format"`
+	MasterKey  string `json:"masterKey"`
+	Seed       string `json:"seed"`
+	AAD        string `json:"aad,omitempty"`
+	ChunkSize  int    `json:"chunkSize,omitempty"`
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+//go:embed vectors.json
+var vectorsJSON []byte
+
+// ParseVectors decodes vectors in the vectors.json format.
+func ParseVectors(data []byte) ([]Vector, error) {
+	var raw []vectorJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("cryptotest: %w", err)
+	}
+
+	vs := make([]Vector, 0, len(raw))
+	for _, r := range raw {
+		v := Vector{Name: r.Name, Format: r.Format, ChunkSize: r.ChunkSize}
+		fields := []struct {
+			dst *[]byte
+			src string
+		}{
+			{&v.MasterKey, r.MasterKey},
+			{&v.Seed, r.Seed},
+			{&v.AAD, r.AAD},
+			{&v.Plaintext, r.Plaintext},
+			{&v.Ciphertext, r.Ciphertext},
+		}
+		for _, f := range fields {
+			if f.src == "" {
+				continue
+			}
+			b, err := hex.DecodeString(f.src)
+			if err != nil {
+				return nil, fmt.Errorf("cryptotest: vector %q: %w", r.Name, err)
+			}
+			*f.dst = b
+		}
+		if v.Plaintext == nil {
+			v.Plaintext = []byte{}
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+
+// MarshalVectors encodes vectors in the vectors.json format.
+func MarshalVectors(vs []Vector) ([]byte, error) {
+	raw := make([]vectorJSON, len(vs))
+	for i, v := range vs {
+		raw[i] = vectorJSON{
+			Name:       v.Name,
+			Format:     v.Format,
+			MasterKey:  hex.EncodeToString(v.MasterKey),
+			Seed:       hex.EncodeToString(v.Seed),
+			AAD:        hex.EncodeToString(v.AAD),
+			ChunkSize:  v.ChunkSize,
+			Plaintext:  hex.EncodeToString(v.Plaintext),
+			Ciphertext: hex.EncodeToString(v.Ciphertext),
+		}
+	}
+	out, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// vectorsOf returns the vectors of one format. It parses vectors.json on
+// every call, so callers get their own copy and cannot alter the shared set.
+// The file is frozen and checked by the package tests, so a parse error is a
+// build defect and panics.
+func vectorsOf(format string) []Vector {
+	all, err := ParseVectors(vectorsJSON)
+	if err != nil {
+		panic(err)
+	}
+
+	var out []Vector
+	for _, v := range all {
+		if v.Format == format {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// TokenVectors returns the known-answer vectors of the envelope token format.
+func TokenVectors() []Vector {
+	return vectorsOf(FormatToken)
+}
+
+// StreamVectors returns the known-answer vectors of the envelope stream
+// format.
+func StreamVectors() []Vector {
+	return vectorsOf(FormatStream)
+}
+
+// PaddedVectors returns the known-answer vectors of the envelope padded-file
+// format.
+func PaddedVectors() []Vector {
+	return vectorsOf(FormatPadded)
+}
@@ -1,37 +1,154 @@
 package crypt
 
 import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"os"
 )
 
-// Decoder holds a PEM-decoded RSA private key and is the entry point for
-// [Decoder.DecryptRSA] and the Base64 decoding helpers.
+// Decoder holds a PEM-decoded RSA, ECDSA or Ed25519 private key and is the
+// entry point for [Decoder.DecryptRSA], [Decoder.SignRSA],
+// [Decoder.SignECDSA], [Decoder.SignEd25519] and the Base64 decoding
+// helpers. It also implements [crypto.Signer] and [crypto.Decrypter], so it
+// can be handed to crypto/tls or [x509.CreateCertificate] (see
+// [PrivateKey]).
 //
 // Construct one with [NewDecoder] and check Err before use: the constructor
 // reports a bad PEM input on the Err field instead of returning an error.
 type Decoder struct {
 	// PriKeyBlock is the decoded PEM block of the private key.
 	PriKeyBlock *pem.Block
-	// HashAlg is the hash used by DecryptRSA; the zero value is SHA256.
+	// HashAlg is the hash used by DecryptRSA, SignRSA and SignECDSA; the
+	// zero value is SHA256.
 	HashAlg HashAlgorithm
-	// Err is non-nil when NewDecoder could not decode the private key PEM.
+	// MGF1Hash is the hash used by the OAEP mask generation function; see
+	// [Encoder]. Nil means HashAlg.
+	MGF1Hash *HashAlgorithm
+	// Label is the OAEP label the ciphertext was made with.
+	Label []byte
+	// AllowLegacyHash permits SHA1 as HashAlg or MGF1Hash.
+	AllowLegacyHash bool
+	// SignScheme is the signature scheme SignRSA uses; the zero value is
+	// RSAPSS.
+	SignScheme RSASignatureScheme
+	// PSSSaltLength is the RSA-PSS salt length SignRSA uses. Zero selects a
+	// salt as long as the hash, the length most verifiers expect.
+	PSSSaltLength int
+	// RawECDSASignature makes SignECDSA return the fixed-width r||s form;
+	// see [Encoder].
+	RawECDSASignature bool
+	// Err is non-nil when the constructor could not decode, decrypt or parse
+	// the private key.
 	Err error
+
+	// priv is the key parsed from privBlock by the constructor; see the
+	// Encoder's equivalent.
+	privBlock *pem.Block
+	priv      crypto.PrivateKey
 }
 
-// NewDecoder decodes a PEM-encoded RSA private key (a PKCS#8 "PRIVATE KEY"
-// block) and returns a Decoder for it. It never returns nil; if the input is
-// not a valid private-key PEM block, the returned Decoder has its Err field
-// set, so callers should check Err before calling DecryptRSA.
+// NewDecoder decodes a PEM-encoded RSA, ECDSA or Ed25519 private key and
+// returns a Decoder for it. It accepts a PKCS#8 "PRIVATE KEY" block, a
+// PKCS#1 "RSA PRIVATE KEY" block or a SEC 1 "EC PRIVATE KEY" block; an
+// "ENCRYPTED PRIVATE KEY" needs [NewDecoderWithPassphrase]. The key is
+// parsed once, here. It never returns nil; if the input is not a usable
+// private-key PEM block, the returned Decoder has its Err field set, so
+// callers should check Err before calling DecryptRSA.
 func NewDecoder(privateKeyPEM string) *Decoder {
-	var err error
-	priKeyBlock, _ := pem.Decode([]byte(privateKeyPEM))
-	if priKeyBlock == nil || priKeyBlock.Type != "PRIVATE KEY" {
-		err = fmt.Errorf("failed to decode private key")
+	priKeyBlock, err := decodePrivateKeyPEM(privateKeyPEM)
+	if err == nil && priKeyBlock.Type == pemEncryptedPrivateKey {
+		err = fmt.Errorf("%w: private key is encrypted; use NewDecoderWithPassphrase", ErrInvalidPEM)
+	}
+	if err != nil {
+		return &Decoder{
+			PriKeyBlock: priKeyBlock,
+			Err:         err,
+		}
+	}
+	return newDecoderFromBlock(priKeyBlock)
+}
+
+// NewDecoderWithPassphrase is like [NewDecoder] but also accepts a PKCS#8
+// "ENCRYPTED PRIVATE KEY" block protected with PBES2 (PBKDF2 and AES-CBC),
+// as written by "openssl pkcs8 -topk8" and "openssl genpkey -aes256". The key
+// is decrypted once, here, and PriKeyBlock holds the decrypted "PRIVATE KEY"
+// block. An unencrypted key is accepted as is. A wrong passphrase sets Err to
+// an error wrapping [ErrAuthenticationFailed].
+func NewDecoderWithPassphrase(privateKeyPEM string, passphrase []byte) *Decoder {
+	priKeyBlock, err := decodePrivateKeyPEM(privateKeyPEM)
+	if err == nil && priKeyBlock.Type == pemEncryptedPrivateKey {
+		priKeyBlock, err = decryptPrivateKeyBlock(priKeyBlock, passphrase)
+	}
+	if err != nil {
+		return &Decoder{
+			PriKeyBlock: priKeyBlock,
+			Err:         err,
+		}
+	}
+	return newDecoderFromBlock(priKeyBlock)
+}
+
+// NewDecoderFromKey returns a Decoder for an RSA private key already in
+// memory, after checking it with [rsa.PrivateKey.Validate]. PriKeyBlock is
+// set to the key's PKCS#8 "PRIVATE KEY" block.
+func NewDecoderFromKey(priv *rsa.PrivateKey) *Decoder {
+	if priv == nil || priv.N == nil {
+		return &Decoder{Err: fmt.Errorf("%w: nil RSA private key", ErrUnsupportedKeyType)}
+	}
+	if err := priv.Validate(); err != nil {
+		return &Decoder{Err: fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)}
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return &Decoder{Err: fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)}
 	}
 
-	return &Decoder{
-		PriKeyBlock: priKeyBlock,
-		Err:         err,
+	block := &pem.Block{Type: pemPrivateKey, Bytes: der}
+	return &Decoder{PriKeyBlock: block, privBlock: block, priv: priv}
+}
+
+// NewDecoderFromFile reads a PEM-encoded private key from path and returns
+// [NewDecoder] for it. A read error is reported on Err.
+func NewDecoderFromFile(path string) *Decoder {
+	b, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return &Decoder{Err: fmt.Errorf("error reading private key: %w", err)}
+	}
+	return NewDecoder(string(b))
+}
+
+// NewDecoderFromDER returns a Decoder for a DER-encoded private key: a PKCS#8
+// PrivateKeyInfo, a PKCS#1 RSAPrivateKey or a SEC 1 ECPrivateKey.
+func NewDecoderFromDER(der []byte) *Decoder {
+	for _, blockType := range []string{pemPrivateKey, pemRSAPrivateKey, pemECPrivateKey} {
+		if d := newDecoderFromBlock(&pem.Block{Type: blockType, Bytes: der}); d.Err == nil {
+			return d
+		}
+	}
+	return &Decoder{Err: fmt.Errorf("%w: DER is not a PKCS#8, PKCS#1 or SEC 1 private key", ErrInvalidPEM)}
+}
+
+// newDecoderFromBlock parses block and returns a Decoder that keeps the
+// parsed key.
+func newDecoderFromBlock(block *pem.Block) *Decoder {
+	priv, err := parsePrivateKeyBlock(block)
+	if err != nil {
+		return &Decoder{PriKeyBlock: block, Err: err}
+	}
+	return &Decoder{PriKeyBlock: block, privBlock: block, priv: priv}
+}
+
+// privateKey returns the Decoder's parsed private key. A Decoder built as a
+// struct literal, or whose PriKeyBlock was replaced, is parsed on each call.
+func (d *Decoder) privateKey() (crypto.PrivateKey, error) {
+	if d.PriKeyBlock == nil {
+		return nil, fmt.Errorf("%w: no private key", ErrInvalidPEM)
+	}
+	if d.priv != nil && d.privBlock == d.PriKeyBlock {
+		return d.priv, nil
 	}
+	return parsePrivateKeyBlock(d.PriKeyBlock)
 }
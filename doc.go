@@ -5,9 +5,25 @@
 // It offers:
 //
 //   - AES-GCM authenticated encryption (AES-128/192/256);
+//   - AES-GCM-SIV (RFC 8452), a nonce-misuse-resistant AES mode
+//     (AES-128/256);
+//   - XAES-256-GCM (c2sp.org/XAES-256-GCM), AES-256-GCM with a 192-bit
+//     random-safe nonce;
+//   - AES-SIV (RFC 5297) deterministic authenticated encryption, for
+//     equality lookups and key wrapping;
+//   - AES Key Wrap (RFC 3394) and AES Key Wrap with Padding (RFC 5649);
 //   - ChaCha20-Poly1305 (96-bit nonce) and XChaCha20-Poly1305 (192-bit nonce)
 //     AEAD;
-//   - RSA-OAEP public-key encryption with SHA-256 or SHA-512;
+//   - RSA-OAEP public-key encryption with SHA-256, SHA-384 or SHA-512 (SHA-1
+//     on request), an optional label and MGF1 hash, and hybrid
+//     RSA-OAEP + AES-256-GCM sealing for payloads of any size;
+//   - RSA-PSS and PKCS#1 v1.5 signatures;
+//   - anonymous X25519 sealed boxes compatible with libsodium's
+//     crypto_box_seal;
+//   - ECDSA (P-256, P-384, P-521) signatures in ASN.1 or raw r||s form;
+//   - Ed25519 and Ed25519ph signatures;
+//   - [crypto.Signer] and [crypto.Decrypter] implementations for RSA, ECDSA
+//     and Ed25519 private keys;
 //   - Base64 encoding helpers (standard, raw, and URL alphabets).
 //
 // # Symmetric API conventions
@@ -20,15 +36,133 @@
 // encryption generates its nonce with crypto/rand, and every decryption
 // authenticates the ciphertext, returning an error on tampering or a wrong key.
 //
-// The package does not derive keys. Callers pass a key of the correct length
-// (AES accepts 16, 24, or 32 bytes; ChaCha20 and XChaCha20 require 32) and
-// should derive keys from passwords with a KDF such as Argon2id.
+// These functions do not derive keys. Callers pass a key of the correct length
+// (AES accepts 16, 24, or 32 bytes; XAES-256-GCM, ChaCha20 and XChaCha20
+// require 32). To encrypt under a password instead, use
+// [EncryptByteWithPassword] and [DecryptByteWithPassword], which run Argon2id
+// and store its parameters and salt inside the ciphertext.
+//
+// The [Key] type wraps key material for callers that want more than a bare
+// []byte: generators such as [GenerateAES256Key], decoding from hex, base64,
+// an environment variable or a file, and formatting, JSON and slog output that
+// always redacts it. Its underlying type is []byte, so a Key can be passed to
+// every function above unchanged.
+//
+// # Reusable cipher handles
+//
+// The functions above rebuild the cipher on every call. For hot paths,
+// [NewAesGcm], [NewChacha20poly1305] and [NewXChacha20poly1305] build a handle
+// once per key whose Seal and Open methods append to a caller buffer in the
+// style of [crypto/cipher.AEAD], allocate nothing when the buffer is large
+// enough, and are safe for concurrent use. They read and write the same
+// nonce-appended layout as the WithNonceAppended functions. The WithRand
+// constructors, such as [NewAesGcmWithRand], take an explicit nonce source
+// for reproducible test vectors; see the cryptotest subpackage.
+//
+// All three handles implement the [AEAD] interface. [SealTagged] and
+// [EncryptByteAuto] write a self-describing layout that leads with an
+// [Algorithm] ID byte, and [DecryptByteAuto] dispatches on it, so stored data
+// records which cipher sealed it.
+//
+// Random 96-bit nonces cap an AES-GCM key at 2^32 messages. [NewAesGcmSealer]
+// returns a sealer that counts messages and bytes per key, can switch to the
+// deterministic counter nonces of NIST SP 800-38D §8.2.1, and either fails
+// with [ErrKeyUsageLimit] or calls a rotation callback once a configured limit
+// is reached. The counts are only kept across restarts if [AesGcmSealer.Usage]
+// is persisted with the key and passed back as [SealerConfig.InitialUsage].
+//
+// # Streaming
+//
+// [EncryptStreamAesGcm] and [EncryptStreamXChacha20poly1305] encrypt an
+// io.Reader of any size to an io.Writer in fixed-size chunks under a caller
+// key, and the matching Decrypt functions reverse it; [NewStreamWriter] and
+// [NewStreamReader] expose the same format as an io.WriteCloser and an
+// io.Reader. Each stream derives its own key from a random salt, and chunk
+// nonces carry a counter and a final flag, so reordered, dropped or
+// truncated chunks fail to decrypt. Unlike the envelope streaming API this
+// needs no key hierarchy. [NewStreamWriterWithRand] takes an explicit salt
+// source for reproducible test vectors.
+//
+// # Key commitment
+//
+// AES-GCM and (X)ChaCha20-Poly1305 are not key-committing: a crafted
+// ciphertext can authenticate under two different keys. The Committing
+// variants (for example [EncryptByteAesGcmCommitting]) prepend a commitment to
+// the key, so any path that may try several keys, such as password-based
+// decryption, can refuse ambiguous ciphertexts.
+//
+// # Errors
+//
+// Failures are reported through exported sentinels such as
+// [ErrAuthenticationFailed], [ErrInvalidKeySize], [ErrInvalidNonce],
+// [ErrCiphertextTooShort], [ErrPlaintextTooLarge] and [ErrInvalidPEM]. Every
+// function wraps one of them, together with the underlying cause where there
+// is one, so callers can branch with [errors.Is] instead of matching message
+// text.
 //
 // # Public-key and Base64
 //
-// RSA-OAEP and the Base64 helpers are methods on the [Encoder] and [Decoder]
-// types, which are built from PEM-encoded keys with [NewEncoder] and
-// [NewDecoder].
+// RSA-OAEP, RSA signatures and the Base64 helpers are methods on the
+// [Encoder] and [Decoder] types, which are built from PEM-encoded keys with
+// [NewEncoder] (PKIX, PKCS#1 or a certificate) and [NewDecoder] (PKCS#8 or
+// PKCS#1), or [NewDecoderWithPassphrase] for an encrypted PKCS#8 key; keys
+// in memory, in files or in DER have their own constructors, and
+// [GenerateRSAKeyPair] creates a pair. Each constructor parses the key once.
+// The Decoder, holding the private key, decrypts and signs ([Decoder.SignRSA],
+// [Decoder.SignRSAReader]); the Encoder encrypts and verifies
+// ([Encoder.VerifyRSA]). Signatures use RSA-PSS unless SignScheme selects
+// [RSAPKCS1v15].
+//
+// ECDSA keys load the same way. [Decoder.SignECDSA] and [Encoder.VerifyECDSA]
+// hash with HashAlg and use ASN.1 DER signatures unless RawECDSASignature
+// selects the fixed-width r||s form of JWS and WebCrypto;
+// [ECDSASignatureToRaw] and [ECDSASignatureToASN1] convert between the two.
+//
+// The same types carry Ed25519 keys: [Decoder.SignEd25519] and
+// [Encoder.VerifyEd25519] (with Byte variants) sign and verify messages, and
+// [Decoder.SignEd25519phReader] streams a large input through Ed25519ph.
+// [GenerateEd25519KeyPair] creates a pair; raw 32-byte keys load with
+// [NewEncoderFromEd25519PublicKey] and [NewDecoderFromEd25519Seed].
+//
+// Raw OAEP only fits a short plaintext ([MaxOAEPPlaintextSize] reports the
+// limit, 190 bytes for a 2048-bit key with SHA-256). For anything larger,
+// [Encoder.SealRSA] wraps a fresh AES-256 data key with OAEP and encrypts the
+// payload under it; [Decoder.OpenRSA] reverses it. [Encoder.SealRSAStream] and
+// [Decoder.OpenRSAStream] do the same over an [io.Reader] in constant memory.
+//
+// For interoperability, both types carry the OAEP label (Label) and may use a
+// different hash for MGF1 (MGF1Hash), as Java's default OAEP provider does.
+// SHA-1 is refused unless AllowLegacyHash is set.
+//
+// A Decoder is also a [crypto.Signer] and, for RSA, a [crypto.Decrypter], so
+// it can serve crypto/tls or [x509.CreateCertificate] directly; those methods
+// take their hash and padding from opts, not from the Decoder's fields.
+// [PrivateKey], from [ParsePrivateKey] or [NewPrivateKey], does the same for
+// RSA, ECDSA and Ed25519 keys.
+//
+// # Sealed boxes
+//
+// [SealAnonymous] encrypts to a raw X25519 public key from
+// [GenerateX25519KeyPair] and [OpenAnonymous] decrypts with the private key.
+// The format is libsodium's crypto_box_seal, byte for byte, so boxes made by
+// libsodium in a browser or mobile app open here and the other way round.
+//
+// # HPKE
+//
+// [HPKESuite] implements RFC 9180 hybrid public-key encryption with the
+// DHKEM(X25519) and DHKEM(P-256) KEMs, HKDF-SHA256 or HKDF-SHA512, and
+// AES-GCM or ChaCha20-Poly1305. [HPKESuite.Seal] and [HPKESuite.Open]
+// encrypt one message; [HPKESuite.NewSender] and [HPKESuite.NewRecipient] set
+// up contexts for a sequence of messages and for secret export. [HPKEOptions]
+// selects the PSK, auth and auth-PSK modes.
+//
+// # Post-quantum encryption
+//
+// [SealXWing] encrypts to an X-Wing public key from [GenerateXWingKeyPair]:
+// an ML-KEM-768 + X25519 hybrid KEM whose shared secret keys
+// XChaCha20-Poly1305, so the message stays secret unless both components
+// fall. [OpenXWing] decrypts. [XWingEncapsulate] and [XWingDecapsulate]
+// expose the KEM itself.
 //
 // # Higher-level scheme
 //
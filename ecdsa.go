@@ -0,0 +1,188 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// ecdsaSignature is the ASN.1 Ecdsa-Sig-Value of RFC 3279.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ECDSASignatureToRaw converts an ASN.1 DER ECDSA signature, as Go, OpenSSL
+// and Java produce, into the fixed-width r||s form on curve that JWS (RFC
+// 7518), WebCrypto and most mobile APIs use: 64 bytes for P-256, 96 for
+// P-384 and 132 for P-521. Malformed or out-of-range input wraps
+// [ErrInvalidSignature].
+func ECDSASignatureToRaw(signature []byte, curve elliptic.Curve) ([]byte, error) {
+	size, err := ecdsaScalarSize(curve)
+	if err != nil {
+		return nil, err
+	}
+	var sig ecdsaSignature
+	rest, err := asn1.Unmarshal(signature, &sig)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("%w: trailing data after ASN.1 signature", ErrInvalidSignature)
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(8*size))
+	for _, v := range []*big.Int{sig.R, sig.S} {
+		if v.Sign() <= 0 || v.Cmp(limit) >= 0 {
+			return nil, fmt.Errorf("%w: signature value out of range for %s", ErrInvalidSignature, curve.Params().Name)
+		}
+	}
+
+	raw := make([]byte, 2*size)
+	sig.R.FillBytes(raw[:size])
+	sig.S.FillBytes(raw[size:])
+	return raw, nil
+}
+
+// ECDSASignatureToASN1 converts a fixed-width r||s ECDSA signature into ASN.1
+// DER, the form [ecdsa.VerifyASN1] and [Encoder.VerifyECDSA] accept by
+// default. The two halves must have the same length.
+func ECDSASignatureToASN1(raw []byte) ([]byte, error) {
+	if len(raw) == 0 || len(raw)%2 != 0 {
+		return nil, fmt.Errorf("%w: raw ECDSA signature of %d bytes", ErrInvalidSignature, len(raw))
+	}
+	half := len(raw) / 2
+	sig := ecdsaSignature{
+		R: new(big.Int).SetBytes(raw[:half]),
+		S: new(big.Int).SetBytes(raw[half:]),
+	}
+	if sig.R.Sign() == 0 || sig.S.Sign() == 0 {
+		return nil, fmt.Errorf("%w: zero signature value", ErrInvalidSignature)
+	}
+	der, err := asn1.Marshal(sig)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return der, nil
+}
+
+// ecdsaScalarSize returns the byte length of r and s in a raw signature on
+// curve.
+func ecdsaScalarSize(curve elliptic.Curve) (int, error) {
+	if curve == nil {
+		return 0, fmt.Errorf("%w: nil curve", ErrUnsupportedKeyType)
+	}
+	switch curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return (curve.Params().BitSize + 7) / 8, nil
+	}
+	return 0, fmt.Errorf("%w: curve %s", ErrUnsupportedKeyType, curve.Params().Name)
+}
+
+// SignECDSA signs message with the Decoder's ECDSA key (P-256, P-384 or
+// P-521), hashing it with HashAlg (SHA-256 by default; JWS ES384 and ES512
+// expect SHA384 and SHA512). The signature is ASN.1 DER, or r||s when
+// RawECDSASignature is set. ECDSA signatures are randomized: signing twice
+// gives two different, equally valid signatures.
+func (d *Decoder) SignECDSA(message []byte) ([]byte, error) {
+	return d.SignECDSAReader(bytes.NewReader(message))
+}
+
+// SignECDSAReader signs everything readable from r, as [Decoder.SignECDSA]
+// does for a message, hashing the input as it is read.
+func (d *Decoder) SignECDSAReader(r io.Reader) ([]byte, error) {
+	priv, err := d.ecdsaPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	h, err := d.HashAlg.checkedHash(d.AllowLegacyHash)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := digestReader(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := ecdsa.SignASN1(rand.Reader, priv, digest)
+	if err != nil {
+		return nil, fmt.Errorf("error signing data: %w", err)
+	}
+	if d.RawECDSASignature {
+		return ECDSASignatureToRaw(signature, priv.Curve)
+	}
+	return signature, nil
+}
+
+// VerifyECDSA checks signature over message with the Encoder's ECDSA key,
+// using HashAlg and RawECDSASignature. It returns nil only for a valid
+// signature and an error wrapping [ErrInvalidSignature] otherwise.
+func (e *Encoder) VerifyECDSA(message, signature []byte) error {
+	return e.VerifyECDSAReader(bytes.NewReader(message), signature)
+}
+
+// VerifyECDSAReader checks signature over everything readable from r, as
+// [Encoder.VerifyECDSA] does for a message, hashing the input as it is read.
+// A read error is returned as is, not as [ErrInvalidSignature].
+func (e *Encoder) VerifyECDSAReader(r io.Reader, signature []byte) error {
+	pub, err := e.ecdsaPublicKey()
+	if err != nil {
+		return err
+	}
+	h, err := e.HashAlg.checkedHash(e.AllowLegacyHash)
+	if err != nil {
+		return err
+	}
+	if e.RawECDSASignature {
+		size, err := ecdsaScalarSize(pub.Curve)
+		if err != nil {
+			return err
+		}
+		if len(signature) != 2*size {
+			return fmt.Errorf("%w: raw signature of %d bytes (want %d)", ErrInvalidSignature, len(signature), 2*size)
+		}
+		if signature, err = ECDSASignatureToASN1(signature); err != nil {
+			return err
+		}
+	}
+	digest, err := digestReader(h, r)
+	if err != nil {
+		return err
+	}
+
+	if !ecdsa.VerifyASN1(pub, digest, signature) {
+		return fmt.Errorf("%w: ECDSA verification failed", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// ecdsaPublicKey returns the Encoder's public key as an ECDSA key.
+func (e *Encoder) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	pubKey, err := e.publicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	ecPubKey, ok := pubKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: public key is not an ECDSA public key", ErrUnsupportedKeyType)
+	}
+	return ecPubKey, nil
+}
+
+// ecdsaPrivateKey returns the Decoder's private key as an ECDSA key.
+func (d *Decoder) ecdsaPrivateKey() (*ecdsa.PrivateKey, error) {
+	priKey, err := d.privateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	ecPriKey, ok := priKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: private key is not an ECDSA private key", ErrUnsupportedKeyType)
+	}
+	return ecPriKey, nil
+}
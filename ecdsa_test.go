@@ -0,0 +1,231 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testECDSAKeyPair returns PEM encodings of a fresh key on curve: a PKIX
+// "PUBLIC KEY" and a PKCS#8 "PRIVATE KEY".
+func testECDSAKeyPair(t *testing.T, curve elliptic.Curve) (pubPEM, privPEM string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))
+}
+
+func TestSignVerifyECDSA(t *testing.T) {
+	msg := []byte("release v1.2.3")
+	for _, tc := range []struct {
+		curve   elliptic.Curve
+		hashAlg HashAlgorithm
+		rawLen  int
+	}{
+		{elliptic.P256(), SHA256, 64},
+		{elliptic.P384(), SHA384, 96},
+		{elliptic.P521(), SHA512, 132},
+		{elliptic.P256(), SHA3_256, 64},
+	} {
+		name := tc.curve.Params().Name
+		pubPEM, privPEM := testECDSAKeyPair(t, tc.curve)
+		for _, raw := range []bool{false, true} {
+			dec := NewDecoder(privPEM)
+			dec.HashAlg, dec.RawECDSASignature = tc.hashAlg, raw
+			enc := NewEncoder(pubPEM)
+			enc.HashAlg, enc.RawECDSASignature = tc.hashAlg, raw
+
+			sig, err := dec.SignECDSA(msg)
+			if err != nil {
+				t.Fatalf("%s raw=%v: SignECDSA: %v", name, raw, err)
+			}
+			if raw && len(sig) != tc.rawLen {
+				t.Errorf("%s: raw signature is %d bytes, want %d", name, len(sig), tc.rawLen)
+			}
+			if err := enc.VerifyECDSA(msg, sig); err != nil {
+				t.Errorf("%s raw=%v: VerifyECDSA: %v", name, raw, err)
+			}
+			if err := enc.VerifyECDSA([]byte("release v1.2.4"), sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("%s raw=%v: altered message err = %v, want ErrInvalidSignature", name, raw, err)
+			}
+			sig[len(sig)-1] ^= 1
+			if err := enc.VerifyECDSA(msg, sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("%s raw=%v: altered signature err = %v, want ErrInvalidSignature", name, raw, err)
+			}
+		}
+	}
+}
+
+func TestECDSARFC6979(t *testing.T) {
+	// RFC 6979, appendix A.2.5: P-256, SHA-256, message "sample"
+	x, _ := new(big.Int).SetString("60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6", 16)
+	y, _ := new(big.Int).SetString("7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299", 16)
+	raw := mustHex(t, "EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716"+
+		"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8")
+
+	pubDER, err := x509.MarshalPKIXPublicKey(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y})
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := NewEncoderFromDER(pubDER)
+	enc.RawECDSASignature = true
+	if err := enc.VerifyECDSA([]byte("sample"), raw); err != nil {
+		t.Fatalf("VerifyECDSA: %v", err)
+	}
+
+	der, err := ECDSASignatureToASN1(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.RawECDSASignature = false
+	if err := enc.VerifyECDSA([]byte("sample"), der); err != nil {
+		t.Errorf("VerifyECDSA(ASN.1): %v", err)
+	}
+	back, err := ECDSASignatureToRaw(der, elliptic.P256())
+	if err != nil || !bytes.Equal(back, raw) {
+		t.Errorf("ECDSASignatureToRaw = %x, %v; want %x", back, err, raw)
+	}
+}
+
+func TestECDSAOpenSSL(t *testing.T) {
+	// testdata/ec-p384.pem and ec-p384-sha384.sig come from OpenSSL 3.0:
+	//
+	//	openssl ecparam -name secp384r1 -genkey -out ec-p384.pem
+	//	printf 'release v1.2.3' | openssl dgst -sha384 -sign ec-p384.pem
+	dec := NewDecoder(readTestdata(t, "ec-p384.pem"))
+	if dec.Err != nil {
+		t.Fatal(dec.Err)
+	}
+	pubPEM, err := dec.PublicKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := os.ReadFile(filepath.Join("testdata", "ec-p384-sha384.sig"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enc := NewEncoder(pubPEM)
+	enc.HashAlg = SHA384
+	if err := enc.VerifyECDSA([]byte("release v1.2.3"), sig); err != nil {
+		t.Errorf("VerifyECDSA of the OpenSSL signature: %v", err)
+	}
+	raw, err := ECDSASignatureToRaw(sig, elliptic.P384())
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc.RawECDSASignature = true
+	if err := enc.VerifyECDSA([]byte("release v1.2.3"), raw); err != nil {
+		t.Errorf("VerifyECDSA of the converted signature: %v", err)
+	}
+}
+
+func TestECDSASignatureConversion(t *testing.T) {
+	// small values are left-padded to the curve width
+	der, err := ECDSASignatureToASN1(append(append(make([]byte, 31), 1), append(make([]byte, 31), 2)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ECDSASignatureToRaw(der, elliptic.P256())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 64 || raw[31] != 1 || raw[63] != 2 {
+		t.Errorf("raw = %x", raw)
+	}
+
+	tooBig, _ := ECDSASignatureToASN1(bytes.Repeat([]byte{0xff}, 96))
+	for name, tc := range map[string]struct {
+		sig   []byte
+		curve elliptic.Curve
+		want  error
+	}{
+		"garbage":  {[]byte("not asn.1"), elliptic.P256(), ErrInvalidSignature},
+		"trailing": {append(bytes.Clone(der), 0), elliptic.P256(), ErrInvalidSignature},
+		"tooWide":  {tooBig, elliptic.P256(), ErrInvalidSignature},
+		"nilCurve": {der, nil, ErrUnsupportedKeyType},
+		"p224":     {der, elliptic.P224(), ErrUnsupportedKeyType},
+	} {
+		if _, err := ECDSASignatureToRaw(tc.sig, tc.curve); !errors.Is(err, tc.want) {
+			t.Errorf("ToRaw %s: err = %v, want %v", name, err, tc.want)
+		}
+	}
+	for name, raw := range map[string][]byte{
+		"empty": nil,
+		"odd":   make([]byte, 63),
+		"zero":  make([]byte, 64),
+	} {
+		if _, err := ECDSASignatureToASN1(raw); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("ToASN1 %s: err = %v, want ErrInvalidSignature", name, err)
+		}
+	}
+}
+
+func TestECDSAErrors(t *testing.T) {
+	pubPEM, privPEM := testECDSAKeyPair(t, elliptic.P256())
+	enc, dec := NewEncoder(pubPEM), NewDecoder(privPEM)
+
+	// a raw signature of the wrong width never reaches verification
+	enc.RawECDSASignature = true
+	if err := enc.VerifyECDSA([]byte("x"), make([]byte, 96)); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("wrong raw width err = %v, want ErrInvalidSignature", err)
+	}
+	enc.RawECDSASignature = false
+
+	dec.HashAlg = SHA1
+	if _, err := dec.SignECDSA([]byte("x")); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("SHA1 without AllowLegacyHash err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+	dec.HashAlg = HashAlgorithm(99)
+	if _, err := dec.SignECDSA([]byte("x")); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("unknown hash err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+	dec.HashAlg = SHA256
+
+	readErr := errors.New("disk gone")
+	if _, err := dec.SignECDSAReader(errReader{readErr}); !errors.Is(err, readErr) {
+		t.Errorf("SignECDSAReader read err = %v, want %v", err, readErr)
+	}
+	if err := enc.VerifyECDSAReader(errReader{readErr}, []byte{0x30}); !errors.Is(err, readErr) || errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyECDSAReader read err = %v, want %v", err, readErr)
+	}
+
+	// other key types are refused both ways
+	rsaPub, rsaPriv := testRSAKeyPair(t)
+	if _, err := NewDecoder(rsaPriv).SignECDSA([]byte("x")); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("SignECDSA with RSA err = %v, want ErrUnsupportedKeyType", err)
+	}
+	if err := NewEncoder(rsaPub).VerifyECDSA([]byte("x"), []byte{0x30}); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("VerifyECDSA with RSA err = %v, want ErrUnsupportedKeyType", err)
+	}
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEncoderFromEd25519PublicKey(edPub).VerifyECDSA([]byte("x"), []byte{0x30}); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("VerifyECDSA with Ed25519 err = %v, want ErrUnsupportedKeyType", err)
+	}
+	if _, err := dec.SignRSA([]byte("x")); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("SignRSA with ECDSA err = %v, want ErrUnsupportedKeyType", err)
+	}
+}
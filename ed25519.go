@@ -0,0 +1,194 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+)
+
+// ed25519phOptions selects Ed25519ph (RFC 8032, section 5.1) over a SHA-512
+// digest, with an empty context.
+var ed25519phOptions = &ed25519.Options{Hash: crypto.SHA512}
+
+// GenerateEd25519KeyPair generates an Ed25519 key pair and returns it
+// PEM-encoded as a PKIX "PUBLIC KEY" and an unencrypted PKCS#8 "PRIVATE KEY",
+// the formats "openssl genpkey -algorithm ed25519" writes and [NewEncoder]
+// and [NewDecoder] read.
+func GenerateEd25519KeyPair() (publicKeyPEM, privateKeyPEM string, err error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("error generating key: %w", err)
+	}
+	defer clear(priv)
+
+	dec := NewDecoderFromEd25519Seed(priv.Seed())
+	if dec.Err != nil {
+		return "", "", dec.Err
+	}
+	if privateKeyPEM, err = dec.PrivateKeyPEM(); err != nil {
+		return "", "", err
+	}
+	if publicKeyPEM, err = dec.PublicKeyPEM(); err != nil {
+		return "", "", err
+	}
+	return publicKeyPEM, privateKeyPEM, nil
+}
+
+// NewEncoderFromEd25519PublicKey returns an Encoder for a raw 32-byte Ed25519
+// public key, as published in JWKs, SSH keys or a release pipeline's
+// configuration. PubKeyBlock is set to the key's PKIX "PUBLIC KEY" block.
+func NewEncoderFromEd25519PublicKey(publicKey []byte) *Encoder {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return &Encoder{Err: fmt.Errorf("%w: Ed25519 public key of %d bytes (want %d)", ErrInvalidKeySize, len(publicKey), ed25519.PublicKeySize)}
+	}
+	pub := ed25519.PublicKey(bytes.Clone(publicKey))
+	der, err := marshalPublicKey(pub)
+	if err != nil {
+		return &Encoder{Err: err}
+	}
+
+	block := &pem.Block{Type: pemPublicKey, Bytes: der}
+	return &Encoder{PubKeyBlock: block, pubBlock: block, pub: pub}
+}
+
+// NewDecoderFromEd25519Seed returns a Decoder for the Ed25519 private key
+// derived from a raw 32-byte seed (RFC 8032's private key). PriKeyBlock is
+// set to the key's PKCS#8 "PRIVATE KEY" block.
+func NewDecoderFromEd25519Seed(seed []byte) *Decoder {
+	if len(seed) != ed25519.SeedSize {
+		return &Decoder{Err: fmt.Errorf("%w: Ed25519 seed of %d bytes (want %d)", ErrInvalidKeySize, len(seed), ed25519.SeedSize)}
+	}
+	k, err := NewPrivateKey(ed25519.NewKeyFromSeed(seed))
+	if err != nil {
+		return &Decoder{Err: err}
+	}
+	return k.Decoder()
+}
+
+// Ed25519PublicKey returns the Encoder's raw 32-byte Ed25519 public key.
+func (e *Encoder) Ed25519PublicKey() (ed25519.PublicKey, error) {
+	pub, err := e.ed25519PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(pub), nil
+}
+
+// Ed25519Seed returns the raw 32-byte seed of the Decoder's Ed25519 key, from
+// which [NewDecoderFromEd25519Seed] rebuilds it. It is secret: clear it after
+// use.
+func (d *Decoder) Ed25519Seed() ([]byte, error) {
+	priv, err := d.ed25519PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return priv.Seed(), nil
+}
+
+// SignEd25519 signs text with the Decoder's Ed25519 key and returns the
+// 64-byte signature. Ed25519 signatures are deterministic: the same key and
+// text always give the same signature.
+func (d *Decoder) SignEd25519(text string) ([]byte, error) {
+	return d.SignByteEd25519([]byte(text))
+}
+
+// SignByteEd25519 signs message with the Decoder's Ed25519 key (pure Ed25519,
+// RFC 8032) and returns the 64-byte signature.
+func (d *Decoder) SignByteEd25519(message []byte) ([]byte, error) {
+	priv, err := d.ed25519PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.Sign(priv, message), nil
+}
+
+// SignEd25519phReader signs everything readable from r with Ed25519ph, the
+// prehashed variant of RFC 8032: the input is hashed with SHA-512 as it is
+// read, so a large file never has to fit in memory. An Ed25519ph signature
+// differs from a pure Ed25519 one over the same bytes and only verifies with
+// [Encoder.VerifyEd25519phReader] or another Ed25519ph verifier.
+func (d *Decoder) SignEd25519phReader(r io.Reader) ([]byte, error) {
+	priv, err := d.ed25519PrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := digestReader(crypto.SHA512, r)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := priv.Sign(nil, digest, ed25519phOptions)
+	if err != nil {
+		return nil, fmt.Errorf("error signing data: %w", err)
+	}
+	return signature, nil
+}
+
+// VerifyEd25519 checks signature over text with the Encoder's Ed25519 key. It
+// returns nil only for a valid signature and an error wrapping
+// [ErrInvalidSignature] otherwise.
+func (e *Encoder) VerifyEd25519(text string, signature []byte) error {
+	return e.VerifyByteEd25519([]byte(text), signature)
+}
+
+// VerifyByteEd25519 checks a pure Ed25519 signature over message, as
+// [Encoder.VerifyEd25519] does for text.
+func (e *Encoder) VerifyByteEd25519(message, signature []byte) error {
+	pub, err := e.ed25519PublicKey()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, message, signature) {
+		return fmt.Errorf("%w: Ed25519 verification failed", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// VerifyEd25519phReader checks an Ed25519ph signature over everything
+// readable from r, hashing the input as it is read. A read error is returned
+// as is, not as [ErrInvalidSignature].
+func (e *Encoder) VerifyEd25519phReader(r io.Reader, signature []byte) error {
+	pub, err := e.ed25519PublicKey()
+	if err != nil {
+		return err
+	}
+	digest, err := digestReader(crypto.SHA512, r)
+	if err != nil {
+		return err
+	}
+	if err := ed25519.VerifyWithOptions(pub, digest, signature, ed25519phOptions); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return nil
+}
+
+// ed25519PublicKey returns the Encoder's public key as an Ed25519 key.
+func (e *Encoder) ed25519PublicKey() (ed25519.PublicKey, error) {
+	pubKey, err := e.publicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	edPubKey, ok := pubKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: public key is not an Ed25519 public key", ErrUnsupportedKeyType)
+	}
+	return edPubKey, nil
+}
+
+// ed25519PrivateKey returns the Decoder's private key as an Ed25519 key.
+func (d *Decoder) ed25519PrivateKey() (ed25519.PrivateKey, error) {
+	priKey, err := d.privateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	edPriKey, ok := priKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: private key is not an Ed25519 private key", ErrUnsupportedKeyType)
+	}
+	return edPriKey, nil
+}
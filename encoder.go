@@ -1,12 +1,18 @@
 package crypt
 
 import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
 	"encoding/pem"
 	"fmt"
+	"os"
 )
 
 // HashAlgorithm selects the hash used by RSA-OAEP in
-// [Encoder.EncryptRSA] and [Decoder.DecryptRSA].
+// [Encoder.EncryptRSA] and [Decoder.DecryptRSA], by RSA signatures in
+// [Decoder.SignRSA] and [Encoder.VerifyRSA], and by ECDSA signatures in
+// [Decoder.SignECDSA] and [Encoder.VerifyECDSA].
 type HashAlgorithm int
 
 const (
@@ -14,35 +20,133 @@ const (
 	SHA256 HashAlgorithm = iota
 	// SHA512 selects SHA-512.
 	SHA512
+	// SHA384 selects SHA-384.
+	SHA384
+	// SHA1 selects SHA-1. It exists only to read and write data for legacy
+	// systems and is refused unless AllowLegacyHash is set.
+	SHA1
+	// SHA3_256 selects SHA3-256 (FIPS 202).
+	SHA3_256
+	// SHA3_384 selects SHA3-384.
+	SHA3_384
+	// SHA3_512 selects SHA3-512.
+	SHA3_512
 )
 
-// Encoder holds a PEM-decoded RSA public key and is the entry point for
-// [Encoder.EncryptRSA] and the Base64 encoding helpers.
+// Encoder holds a PEM-decoded RSA, ECDSA or Ed25519 public key and is the
+// entry point for [Encoder.EncryptRSA], [Encoder.VerifyRSA],
+// [Encoder.VerifyECDSA], [Encoder.VerifyEd25519] and the Base64 encoding
+// helpers.
 //
 // Construct one with [NewEncoder] and check Err before use: the constructor
 // reports a bad PEM input on the Err field instead of returning an error.
 type Encoder struct {
 	// PubKeyBlock is the decoded PEM block of the public key.
 	PubKeyBlock *pem.Block
-	// HashAlg is the hash used by EncryptRSA; the zero value is SHA256.
+	// HashAlg is the hash used by EncryptRSA, VerifyRSA and VerifyECDSA;
+	// the zero value is SHA256.
 	HashAlg HashAlgorithm
-	// Err is non-nil when NewEncoder could not decode the public key PEM.
+	// MGF1Hash is the hash used by the OAEP mask generation function, for
+	// a different one than HashAlg as Java's "OAEPWithSHA-256AndMGF1Padding"
+	// (SHA-1 MGF1) and some KMS APIs expect. Nil means HashAlg.
+	MGF1Hash *HashAlgorithm
+	// Label is the OAEP label. It is not encrypted but must match on
+	// decryption; nil and empty are the same label.
+	Label []byte
+	// AllowLegacyHash permits SHA1 as HashAlg or MGF1Hash.
+	AllowLegacyHash bool
+	// SignScheme is the signature scheme VerifyRSA expects; the zero value
+	// is RSAPSS.
+	SignScheme RSASignatureScheme
+	// PSSSaltLength is the RSA-PSS salt length VerifyRSA requires. Zero
+	// accepts any salt length.
+	PSSSaltLength int
+	// RawECDSASignature makes VerifyECDSA expect the fixed-width r||s form
+	// (IEEE P1363, JWS ES256, WebCrypto) instead of ASN.1 DER.
+	RawECDSASignature bool
+	// Err is non-nil when the constructor could not decode or parse the
+	// public key.
 	Err error
+
+	// pub is the key parsed from pubBlock by the constructor. It is used as
+	// long as PubKeyBlock still points to pubBlock, so the key is parsed once
+	// rather than on every call.
+	pubBlock *pem.Block
+	pub      crypto.PublicKey
 }
 
-// NewEncoder decodes a PEM-encoded RSA public key (a "PUBLIC KEY" block) and
-// returns an Encoder for it. It never returns nil; if the input is not a valid
-// public-key PEM block, the returned Encoder has its Err field set, so callers
-// should check Err before calling EncryptRSA.
+// NewEncoder decodes a PEM-encoded RSA, ECDSA or Ed25519 public key and
+// returns an Encoder for it. It accepts a PKIX "PUBLIC KEY" block, a PKCS#1 "RSA PUBLIC KEY" block
+// or a "CERTIFICATE" block, whose public key is used; only the first block
+// is read, so a certificate chain yields its leaf. The key is parsed once,
+// here. It never returns nil; if the input is not one of these, the returned
+// Encoder has its Err field set, so callers should check Err before calling
+// EncryptRSA.
 func NewEncoder(publicKeyPEM string) *Encoder {
-	var err error
-	pubKeyBlock, _ := pem.Decode([]byte(publicKeyPEM))
-	if pubKeyBlock == nil || pubKeyBlock.Type != "PUBLIC KEY" {
-		err = fmt.Errorf("failed to decode public key")
+	pubKeyBlock, err := decodePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return &Encoder{
+			PubKeyBlock: pubKeyBlock,
+			Err:         err,
+		}
+	}
+	return newEncoderFromBlock(pubKeyBlock)
+}
+
+// NewEncoderFromKey returns an Encoder for an RSA public key already in
+// memory. PubKeyBlock is set to the key's PKIX "PUBLIC KEY" block.
+func NewEncoderFromKey(pub *rsa.PublicKey) *Encoder {
+	if pub == nil || pub.N == nil {
+		return &Encoder{Err: fmt.Errorf("%w: nil RSA public key", ErrUnsupportedKeyType)}
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return &Encoder{Err: fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)}
 	}
 
-	return &Encoder{
-		PubKeyBlock: pubKeyBlock,
-		Err:         err,
+	block := &pem.Block{Type: pemPublicKey, Bytes: der}
+	return &Encoder{PubKeyBlock: block, pubBlock: block, pub: pub}
+}
+
+// NewEncoderFromFile reads a PEM-encoded public key or certificate from path
+// and returns [NewEncoder] for it. A read error is reported on Err.
+func NewEncoderFromFile(path string) *Encoder {
+	b, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return &Encoder{Err: fmt.Errorf("error reading public key: %w", err)}
+	}
+	return NewEncoder(string(b))
+}
+
+// NewEncoderFromDER returns an Encoder for a DER-encoded public key: a PKIX
+// SubjectPublicKeyInfo, a PKCS#1 RSAPublicKey or an X.509 certificate.
+func NewEncoderFromDER(der []byte) *Encoder {
+	for _, blockType := range []string{pemPublicKey, pemRSAPublicKey, pemCertificate} {
+		if e := newEncoderFromBlock(&pem.Block{Type: blockType, Bytes: der}); e.Err == nil {
+			return e
+		}
+	}
+	return &Encoder{Err: fmt.Errorf("%w: DER is neither a PKIX or PKCS#1 public key nor a certificate", ErrInvalidPEM)}
+}
+
+// newEncoderFromBlock parses block and returns an Encoder that keeps the
+// parsed key.
+func newEncoderFromBlock(block *pem.Block) *Encoder {
+	pub, err := parsePublicKeyBlock(block)
+	if err != nil {
+		return &Encoder{PubKeyBlock: block, Err: err}
+	}
+	return &Encoder{PubKeyBlock: block, pubBlock: block, pub: pub}
+}
+
+// publicKey returns the Encoder's parsed public key. An Encoder built as a
+// struct literal, or whose PubKeyBlock was replaced, is parsed on each call.
+func (e *Encoder) publicKey() (crypto.PublicKey, error) {
+	if e.PubKeyBlock == nil {
+		return nil, fmt.Errorf("%w: no public key", ErrInvalidPEM)
+	}
+	if e.pub != nil && e.pubBlock == e.PubKeyBlock {
+		return e.pub, nil
 	}
+	return parsePublicKeyBlock(e.PubKeyBlock)
 }
@@ -49,7 +49,7 @@ func (s *Scheme) SealBytes(masterKey, plaintext []byte) ([]byte, error) {
 // must be given the identical aad, so two tokens sealed with different AAD
 // can never be swapped for one another.
 func (s *Scheme) SealBytesAAD(masterKey, plaintext, aad []byte) ([]byte, error) {
-	salt, err := GenerateSalt()
+	salt, err := s.randomBytes(SaltSize)
 	if err != nil {
 		return nil, err
 	}
@@ -65,15 +65,16 @@ func (s *Scheme) SealBytesAAD(masterKey, plaintext, aad []byte) ([]byte, error)
 		return nil, err
 	}
 
-	ciphertext, err := crypt.EncryptByteXChacha20poly1305WithNonceAppendedAAD(subKey, plaintext, authData(header, aad))
+	// the handle writes the same nonce-appended layout as
+	// EncryptByteXChacha20poly1305WithNonceAppendedAAD, but draws the nonce
+	// from the Scheme's randomness source.
+	aead, err := crypt.NewXChacha20poly1305WithRand(subKey, s.random) // nil selects crypto/rand
 	if err != nil {
 		return nil, err
 	}
-
-	blob := make([]byte, 0, len(header)+len(ciphertext))
-	blob = append(blob, header...)
-	blob = append(blob, ciphertext...)
-	return blob, nil
+	blob := make([]byte, len(header), len(header)+aead.Overhead()+len(plaintext))
+	copy(blob, header)
+	return aead.Seal(blob, plaintext, authData(header, aad))
 }
 
 // OpenBytes decrypts an envelope produced by [Scheme.SealBytes] using the master
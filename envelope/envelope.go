@@ -107,6 +107,7 @@ package envelope
 import (
 	"crypto/rand"
 	"errors"
+	"io"
 )
 
 // Sizes, in bytes, used throughout the envelope scheme.
@@ -195,6 +196,15 @@ type Config struct {
 	// created. Unlike the labels it is not frozen: every stream records its
 	// own chunk size, so changing this never orphans sealed data.
 	ChunkSize int
+
+	// Rand is the source of the salts and nonces the Seal family draws. Nil
+	// selects crypto/rand. Set it only to produce reproducible test vectors
+	// (see the cryptotest package): a predictable source makes every item
+	// reuse the same sub-key, which defeats the scheme. It is not used by
+	// the package-level helpers ([GenerateMasterKey], [GenerateSalt],
+	// [RandomHex]), which always read crypto/rand. A shared Scheme reads it
+	// from many goroutines, so it must be safe for concurrent use.
+	Rand io.Reader
 }
 
 // Scheme carries the domain-separation labels used by the label-dependent
@@ -204,6 +214,7 @@ type Scheme struct {
 	kekLabel    string
 	subKeyLabel string
 	chunkSize   int
+	random      io.Reader
 }
 
 // New returns a [Scheme] using the labels in cfg, falling back to
@@ -223,6 +234,7 @@ func New(cfg Config) *Scheme {
 		kekLabel:    cfg.KEKLabel,
 		subKeyLabel: cfg.SubKeyLabel,
 		chunkSize:   cfg.ChunkSize,
+		random:      cfg.Rand,
 	}
 }
 
@@ -234,8 +246,21 @@ func Default() *Scheme {
 
 // randomBytes returns n cryptographically secure random bytes.
 func randomBytes(n int) ([]byte, error) {
+	return readRandom(rand.Reader, n)
+}
+
+// randomBytes returns n bytes from the Scheme's randomness source.
+func (s *Scheme) randomBytes(n int) ([]byte, error) {
+	if s.random == nil {
+		return randomBytes(n)
+	}
+	return readRandom(s.random, n)
+}
+
+// readRandom reads exactly n bytes from r.
+func readRandom(r io.Reader, n int) ([]byte, error) {
 	b := make([]byte, n)
-	if _, err := rand.Read(b); err != nil {
+	if _, err := io.ReadFull(r, b); err != nil {
 		return nil, err
 	}
 	return b, nil
@@ -69,6 +69,42 @@ func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
 	return masterKey, nil
 }
 
+// WrapKeyAES encrypts the master key with the KEK using standard AES-256 Key
+// Wrap (RFC 3394), producing a 40-byte blob that external systems (HSMs, JWE
+// "A256KW", CMS, KMS key-import flows) can unwrap byte-for-byte. It is the
+// interoperable alternative to [WrapKey]; the two formats are not
+// interchangeable, so unwrap with the matching function.
+func WrapKeyAES(kek, masterKey []byte) ([]byte, error) {
+	if len(kek) != KeySize {
+		return nil, ErrInvalidKeySize
+	}
+	if len(masterKey) != KeySize {
+		return nil, ErrInvalidKeySize
+	}
+	return crypt.WrapKeyAES(kek, masterKey)
+}
+
+// UnwrapKeyAES decrypts a master key wrapped with AES Key Wrap by
+// [WrapKeyAES] or by an external RFC 3394 implementation. A non-nil error
+// means the KEK is wrong, the stored value was tampered with, or the wrapped
+// key is not a 32-byte key.
+func UnwrapKeyAES(kek, wrapped []byte) ([]byte, error) {
+	if len(kek) != KeySize {
+		return nil, ErrInvalidKeySize
+	}
+
+	masterKey, err := crypt.UnwrapKeyAES(kek, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	if len(masterKey) != KeySize {
+		// authentic under the KEK but not a master key
+		Zero(masterKey)
+		return nil, ErrInvalidKeySize
+	}
+	return masterKey, nil
+}
+
 // Zero overwrites b with zeros, removing key material from memory. Call it on
 // the KEK, the master key and any explicitly derived sub-key as soon as the
 // value is no longer needed; the Seal/Open functions already wipe the
@@ -136,6 +136,68 @@ func TestWrapUnwrapKey(t *testing.T) {
 	})
 }
 
+func TestWrapUnwrapKeyAES(t *testing.T) {
+	s := Default()
+	kek, _ := s.DeriveKEK(validSecret)
+	masterKey, _ := GenerateMasterKey()
+
+	t.Run("roundTrip", func(t *testing.T) {
+		wrapped, err := WrapKeyAES(kek, masterKey)
+		if err != nil {
+			t.Fatalf("WrapKeyAES error: %v", err)
+		}
+		if len(wrapped) != KeySize+8 {
+			t.Errorf("wrapped len = %d, want %d", len(wrapped), KeySize+8)
+		}
+
+		got, err := UnwrapKeyAES(kek, wrapped)
+		if err != nil {
+			t.Fatalf("UnwrapKeyAES error: %v", err)
+		}
+		if !bytes.Equal(got, masterKey) {
+			t.Error("unwrapped key does not match original master key")
+		}
+	})
+
+	t.Run("wrongKEKFails", func(t *testing.T) {
+		wrapped, _ := WrapKeyAES(kek, masterKey)
+		otherKEK, _ := s.DeriveKEK(validSecret + "different")
+		if _, err := UnwrapKeyAES(otherKEK, wrapped); err == nil {
+			t.Error("UnwrapKeyAES with wrong KEK succeeded, want failure")
+		}
+	})
+
+	t.Run("notInterchangeable", func(t *testing.T) {
+		wrapped, _ := WrapKey(kek, masterKey)
+		if _, err := UnwrapKeyAES(kek, wrapped); err == nil {
+			t.Error("UnwrapKeyAES of a WrapKey blob succeeded, want failure")
+		}
+	})
+
+	t.Run("invalidKeySize", func(t *testing.T) {
+		if _, err := WrapKeyAES([]byte("short"), masterKey); err != ErrInvalidKeySize {
+			t.Errorf("WrapKeyAES err = %v, want ErrInvalidKeySize", err)
+		}
+		if _, err := WrapKeyAES(kek, []byte("short")); err != ErrInvalidKeySize {
+			t.Errorf("WrapKeyAES err = %v, want ErrInvalidKeySize", err)
+		}
+		if _, err := UnwrapKeyAES([]byte("short"), []byte("whatever")); err != ErrInvalidKeySize {
+			t.Errorf("UnwrapKeyAES err = %v, want ErrInvalidKeySize", err)
+		}
+	})
+
+	t.Run("rejectNonMasterKey", func(t *testing.T) {
+		// authentic under the KEK but 16 bytes long
+		wrapped, err := crypt.WrapKeyAES(kek, masterKey[:16])
+		if err != nil {
+			t.Fatalf("wrap error: %v", err)
+		}
+		if _, err := UnwrapKeyAES(kek, wrapped); err != ErrInvalidKeySize {
+			t.Errorf("UnwrapKeyAES err = %v, want ErrInvalidKeySize", err)
+		}
+	})
+}
+
 func TestZero(t *testing.T) {
 	b := []byte{1, 2, 3, 4, 5}
 	Zero(b)
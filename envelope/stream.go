@@ -5,8 +5,8 @@ package envelope
 //
 //	seal
 //	  SealStream[AAD]      drives writer -> ReadFrom -> Close
-//	   -> SealWriterAAD     once per stream: GenerateSalt + randomBytes (salt,
-//	                        nonce prefix), buildStreamHeader, streamAEAD
+//	   -> SealWriterAAD     once per stream: s.randomBytes (salt, nonce
+//	                        prefix), buildStreamHeader, streamAEAD
 //	                        (DeriveSubKey -> XChaCha20), authData, header to dst
 //	   -> ReadFrom          fills buf; a one-byte look-ahead decides whether a
 //	                        full buffer is a non-final chunk
@@ -211,11 +211,11 @@ func (s *Scheme) SealWriterAAD(masterKey []byte, dst io.Writer, aad []byte) (*St
 		return nil, ErrInvalidChunkSize
 	}
 
-	salt, err := GenerateSalt()
+	salt, err := s.randomBytes(SaltSize)
 	if err != nil {
 		return nil, err
 	}
-	prefix, err := randomBytes(streamNoncePrefixSize)
+	prefix, err := s.randomBytes(streamNoncePrefixSize)
 	if err != nil {
 		return nil, err
 	}
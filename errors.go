@@ -0,0 +1,87 @@
+package crypt
+
+import "errors"
+
+// Errors returned by the package. Every function wraps one of these, so
+// callers can branch with [errors.Is] instead of matching strings; where an
+// underlying library error caused the failure it is wrapped as well, so
+// [errors.As] and errors.Is still reach it.
+var (
+	// ErrInvalidKeySize is returned when a key, or the key data given to a
+	// key-wrap function, has a length the algorithm does not accept.
+	ErrInvalidKeySize = errors.New("crypt: invalid key size")
+
+	// ErrInvalidEncoding is returned when a hex or base64 key, read by
+	// [KeyFromHex], [KeyFromBase64] or [ParseKey], is not valid in its
+	// encoding.
+	ErrInvalidEncoding = errors.New("crypt: invalid key encoding")
+
+	// ErrKeyNotFound is returned by [KeyFromEnv] when the environment
+	// variable is unset or blank.
+	ErrKeyNotFound = errors.New("crypt: key not found")
+
+	// ErrInvalidNonce is returned when a nonce passed to a decryption
+	// function has the wrong length.
+	ErrInvalidNonce = errors.New("crypt: invalid nonce length")
+
+	// ErrCiphertextTooShort is returned when a ciphertext cannot even hold
+	// the fixed parts of its format (nonce, tag, header).
+	ErrCiphertextTooShort = errors.New("crypt: ciphertext is too short")
+
+	// ErrCiphertextTooLarge is returned when a ciphertext exceeds what the
+	// cipher can decrypt.
+	ErrCiphertextTooLarge = errors.New("crypt: ciphertext too large")
+
+	// ErrMalformedCiphertext is returned when a ciphertext is long enough but
+	// structurally invalid, for example a bad length field or block count.
+	ErrMalformedCiphertext = errors.New("crypt: malformed ciphertext")
+
+	// ErrPlaintextTooLarge is returned when a message exceeds what the cipher
+	// or the RSA key can encrypt in one call.
+	ErrPlaintextTooLarge = errors.New("crypt: plaintext too large")
+
+	// ErrAssociatedDataTooLarge is returned when the associated data exceeds
+	// what the cipher can authenticate.
+	ErrAssociatedDataTooLarge = errors.New("crypt: associated data too large")
+
+	// ErrKeyUsageLimit is returned by [AesGcmSealer.Seal] when the key has
+	// reached its configured message or byte limit and was not rotated, and
+	// by an HPKE context that has used up its message sequence numbers.
+	ErrKeyUsageLimit = errors.New("crypt: key usage limit reached")
+
+	// ErrAuthenticationFailed is returned when a ciphertext does not
+	// authenticate: a wrong key, password or AAD, or tampered data. It also
+	// covers a failed key commitment, a failed key-unwrap integrity check and
+	// a failed RSA-OAEP decryption.
+	ErrAuthenticationFailed = errors.New("crypt: message authentication failed")
+
+	// ErrInvalidSignature is returned when a signature does not verify: a
+	// wrong key, hash or scheme, or an altered message or signature.
+	ErrInvalidSignature = errors.New("crypt: invalid signature")
+
+	// ErrStreamClosed is returned by [StreamWriter.Write] after the stream
+	// has been closed.
+	ErrStreamClosed = errors.New("crypt: stream writer is closed")
+
+	// ErrInvalidPEM is returned when a PEM or DER key input cannot be
+	// decoded, is of the wrong block type, or does not contain a parsable
+	// key.
+	ErrInvalidPEM = errors.New("crypt: invalid PEM key")
+
+	// ErrUnsupportedKeyType is returned when a parsed key is not of the type
+	// the operation needs, for example a non-RSA key given to RSA-OAEP.
+	ErrUnsupportedKeyType = errors.New("crypt: unsupported key type")
+
+	// ErrUnsupportedAlgorithm is returned for an unknown or mismatched
+	// algorithm identifier, hash algorithm or KDF.
+	ErrUnsupportedAlgorithm = errors.New("crypt: unsupported algorithm")
+
+	// ErrUnsupportedVersion is returned when a self-describing ciphertext
+	// carries a format version this package does not know.
+	ErrUnsupportedVersion = errors.New("crypt: unsupported format version")
+
+	// ErrInvalidParameters is returned when cost or format parameters, given
+	// by the caller or read from a ciphertext, are outside the accepted
+	// bounds.
+	ErrInvalidParameters = errors.New("crypt: invalid parameters")
+)
@@ -0,0 +1,163 @@
+package crypt
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestSentinelErrors checks that each failure class surfaces its sentinel
+// through errors.Is, whichever cipher or format produced it.
+func TestSentinelErrors(t *testing.T) {
+	key := mustBytes(t, 32)
+	other := mustBytes(t, 32)
+	pubPEM, privPEM := testRSAKeyPair(t)
+
+	sealed := func(ct []byte, err error) []byte {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		return ct
+	}
+	gcm := sealed(EncryptByteAesGcmWithNonceAppended(key, []byte("secret")))
+	chacha := sealed(EncryptByteXChacha20poly1305WithNonceAppended(key, []byte("secret")))
+	siv := sealed(EncryptByteAesSiv(mustBytes(t, 64), []byte("secret")))
+	committed := sealed(EncryptByteAesGcmCommitting(key, []byte("secret")))
+	wrapped := sealed(WrapKeyAES(key, other))
+	password := sealed(EncryptByteWithPasswordParams([]byte("pw"), []byte("secret"), fastPasswordParams))
+	badVersion := append([]byte(nil), password...)
+	badVersion[0] = 0xFF
+	rsaCT := sealed(NewEncoder(pubPEM).EncryptByteRSA([]byte("secret")))
+
+	tests := []struct {
+		name string
+		run  func() error
+		want error
+	}{
+		{"aesGcmKeySize", func() error {
+			_, _, err := EncryptByteAesGcm([]byte("short"), []byte("x"))
+			return err
+		}, ErrInvalidKeySize},
+		{"chachaKeySize", func() error {
+			_, err := EncryptByteXChacha20poly1305WithNonceAppended([]byte("short"), []byte("x"))
+			return err
+		}, ErrInvalidKeySize},
+		{"handleKeySize", func() error {
+			_, err := NewAesGcm([]byte("short"))
+			return err
+		}, ErrInvalidKeySize},
+		{"xaesKeySize", func() error {
+			_, err := EncryptByteXAesGcmWithNonceAppended(mustBytes(t, 16), []byte("x"))
+			return err
+		}, ErrInvalidKeySize},
+		{"aesGcmNonce", func() error {
+			_, err := DecryptByteAesGcm(key, make([]byte, 5), gcm)
+			return err
+		}, ErrInvalidNonce},
+		{"chachaNonce", func() error {
+			_, err := DecryptByteXChacha20poly1305(key, make([]byte, 12), chacha)
+			return err
+		}, ErrInvalidNonce},
+		{"aesGcmTooShort", func() error {
+			_, err := DecryptByteAesGcmWithNonceAppended(key, []byte{1, 2, 3})
+			return err
+		}, ErrCiphertextTooShort},
+		{"taggedTooShort", func() error {
+			_, err := DecryptByteAuto(key, nil, nil)
+			return err
+		}, ErrCiphertextTooShort},
+		{"aesGcmWrongKey", func() error {
+			_, err := DecryptByteAesGcmWithNonceAppended(other, gcm)
+			return err
+		}, ErrAuthenticationFailed},
+		{"chachaWrongKey", func() error {
+			_, err := DecryptByteXChacha20poly1305WithNonceAppended(other, chacha)
+			return err
+		}, ErrAuthenticationFailed},
+		{"sivWrongAD", func() error {
+			_, err := DecryptByteAesSiv(mustBytes(t, 64), siv)
+			return err
+		}, ErrAuthenticationFailed},
+		{"commitmentMismatch", func() error {
+			_, err := DecryptByteAesGcmCommitting(other, committed)
+			return err
+		}, ErrAuthenticationFailed},
+		{"unwrapWrongKEK", func() error {
+			_, err := UnwrapKeyAES(other, wrapped)
+			return err
+		}, ErrAuthenticationFailed},
+		{"wrongPassword", func() error {
+			_, err := DecryptByteWithPassword([]byte("nope"), password)
+			return err
+		}, ErrAuthenticationFailed},
+		{"rsaWrongKey", func() error {
+			_, otherPriv := testRSAKeyPair(t)
+			_, err := NewDecoder(otherPriv).DecryptByteRSA(rsaCT)
+			return err
+		}, ErrAuthenticationFailed},
+		{"rsaTooLarge", func() error {
+			_, err := NewEncoder(pubPEM).EncryptByteRSA(make([]byte, 4096))
+			return err
+		}, ErrPlaintextTooLarge},
+		{"rsaSignature", func() error {
+			sig, err := NewDecoder(privPEM).SignRSA([]byte("secret"))
+			if err != nil {
+				return err
+			}
+			return NewEncoder(pubPEM).VerifyRSA([]byte("Secret"), sig)
+		}, ErrInvalidSignature},
+		{"wrapKeyData", func() error {
+			_, err := WrapKeyAES(key, make([]byte, 12))
+			return err
+		}, ErrInvalidKeySize},
+		{"passwordVersion", func() error {
+			_, err := DecryptByteWithPassword([]byte("pw"), badVersion)
+			return err
+		}, ErrUnsupportedVersion},
+		{"passwordParams", func() error {
+			_, err := EncryptByteWithPasswordParams([]byte("pw"), []byte("x"), PasswordParams{})
+			return err
+		}, ErrInvalidParameters},
+		{"unknownAlgorithm", func() error {
+			_, err := NewAEAD(Algorithm(0x7F), key)
+			return err
+		}, ErrUnsupportedAlgorithm},
+		{"rsaShortModulus", func() error {
+			_, err := NewEncoder(weakRSAPublicKeyPEM(t, 512, 65537)).EncryptByteRSA([]byte("x"))
+			return err
+		}, ErrInvalidKeySize},
+		{"rsaEvenExponent", func() error {
+			_, err := NewEncoder(weakRSAPublicKeyPEM(t, 2048, 65536)).EncryptByteRSA([]byte("x"))
+			return err
+		}, ErrUnsupportedKeyType},
+		{"encoderPEM", func() error { return NewEncoder("not a pem block").Err }, ErrInvalidPEM},
+		{"decoderPEM", func() error { return NewDecoder("not a pem block").Err }, ErrInvalidPEM},
+		{"encoderKeyType", func() error { return NewEncoder(privPEM).Err }, ErrInvalidPEM},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.run()
+			if !errors.Is(err, tc.want) {
+				t.Errorf("err = %v, want errors.Is %v", err, tc.want)
+			}
+		})
+	}
+}
+
+// weakRSAPublicKeyPEM returns a PKIX PEM RSA public key with an odd modulus
+// of the given size and exponent e, which crypto/rsa would never generate.
+func weakRSAPublicKeyPEM(t *testing.T, bits, e int) string {
+	t.Helper()
+	n := new(big.Int).Lsh(big.NewInt(1), uint(bits-1)) // #nosec G115
+	n.SetBit(n, 0, 1)
+	der, err := x509.MarshalPKIXPublicKey(&rsa.PublicKey{N: n, E: e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
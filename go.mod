@@ -2,6 +2,7 @@ module github.com/pilinux/crypt
 
 go 1.25.0
 
-require golang.org/x/crypto v0.55.0
-
-require golang.org/x/sys v0.47.0 // indirect
+require (
+	golang.org/x/crypto v0.55.0
+	golang.org/x/sys v0.47.0
+)
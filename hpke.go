@@ -0,0 +1,677 @@
+package crypt
+
+// Hybrid Public Key Encryption, RFC 9180, in all four modes (base, PSK, auth
+// and auth-PSK) for the DHKEM(X25519) and DHKEM(P-256) KEMs, HKDF-SHA256 and
+// HKDF-SHA512, and the AES-128-GCM, AES-256-GCM and ChaCha20-Poly1305 AEADs
+// (plus the export-only AEAD).
+//
+// Keys are passed around in their RFC 9180 serialized form: 32 raw bytes for
+// X25519, a 65-byte uncompressed point and a 32-byte scalar for P-256.
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// HPKEKEM identifies an HPKE key encapsulation mechanism by its IANA code
+// point.
+type HPKEKEM uint16
+
+const (
+	// HPKEDHKEMP256 is DHKEM(P-256, HKDF-SHA256).
+	HPKEDHKEMP256 HPKEKEM = 0x0010
+	// HPKEDHKEMX25519 is DHKEM(X25519, HKDF-SHA256).
+	HPKEDHKEMX25519 HPKEKEM = 0x0020
+)
+
+// HPKEKDF identifies an HPKE key derivation function by its IANA code point.
+type HPKEKDF uint16
+
+const (
+	// HPKEHKDFSHA256 is HKDF-SHA256.
+	HPKEHKDFSHA256 HPKEKDF = 0x0001
+	// HPKEHKDFSHA512 is HKDF-SHA512.
+	HPKEHKDFSHA512 HPKEKDF = 0x0003
+)
+
+// HPKEAEAD identifies an HPKE AEAD by its IANA code point.
+type HPKEAEAD uint16
+
+const (
+	// HPKEAES128GCM is AES-128-GCM.
+	HPKEAES128GCM HPKEAEAD = 0x0001
+	// HPKEAES256GCM is AES-256-GCM.
+	HPKEAES256GCM HPKEAEAD = 0x0002
+	// HPKEChaCha20Poly1305 is ChaCha20-Poly1305.
+	HPKEChaCha20Poly1305 HPKEAEAD = 0x0003
+	// HPKEExportOnly sets up a context that only derives secrets with
+	// Export; Seal and Open fail.
+	HPKEExportOnly HPKEAEAD = 0xFFFF
+)
+
+// HPKE modes, RFC 9180 section 5.
+const (
+	hpkeModeBase    byte = 0x00
+	hpkeModePSK     byte = 0x01
+	hpkeModeAuth    byte = 0x02
+	hpkeModeAuthPSK byte = 0x03
+)
+
+// hpkeMinPSKSize is the shortest pre-shared key accepted; RFC 9180 section
+// 5.1.2 requires 32 bytes of entropy.
+const hpkeMinPSKSize = 32
+
+// HPKESuite is an HPKE ciphersuite. Sender and recipient must use the same
+// suite; a common choice is
+//
+//	crypt.HPKESuite{KEM: crypt.HPKEDHKEMX25519, KDF: crypt.HPKEHKDFSHA256, AEAD: crypt.HPKEAES128GCM}
+type HPKESuite struct {
+	KEM  HPKEKEM
+	KDF  HPKEKDF
+	AEAD HPKEAEAD
+}
+
+// HPKEOptions selects the HPKE mode. A nil or zero HPKEOptions is base mode.
+// Setting PSK and PSKID selects PSK mode; setting the sender's key selects
+// auth mode; setting both selects auth-PSK mode. Both sides must use the
+// same mode and values.
+type HPKEOptions struct {
+	// PSK is a pre-shared key of at least 32 bytes, known to both sides.
+	PSK []byte
+	// PSKID identifies PSK. It must be set exactly when PSK is.
+	PSKID []byte
+	// SenderPrivateKey authenticates the sender (auth modes); it is set on
+	// the sending side only.
+	SenderPrivateKey []byte
+	// SenderPublicKey is the sender's public key (auth modes); it is set on
+	// the receiving side only.
+	SenderPublicKey []byte
+}
+
+// HPKESender is the sending half of an HPKE context, from
+// [HPKESuite.NewSender]. Its messages must be opened in order by the matching
+// [HPKERecipient]. It is not safe for concurrent use.
+type HPKESender struct {
+	ctx hpkeContext
+}
+
+// HPKERecipient is the receiving half of an HPKE context, from
+// [HPKESuite.NewRecipient]. It is not safe for concurrent use.
+type HPKERecipient struct {
+	ctx hpkeContext
+}
+
+// hpkeContext is the key schedule output of RFC 9180 section 5.1.
+type hpkeContext struct {
+	aead           cipher.AEAD // nil for HPKEExportOnly
+	baseNonce      []byte
+	seq            uint64
+	exporterSecret []byte
+	kdf            hpkeKDF
+}
+
+// hpkeKDF is an HKDF instance with its RFC 9180 suite_id.
+type hpkeKDF struct {
+	hash    func() hash.Hash
+	size    int
+	suiteID []byte
+}
+
+// labeledExtract is LabeledExtract of RFC 9180 section 4.
+func (k hpkeKDF) labeledExtract(salt []byte, label string, ikm []byte) ([]byte, error) {
+	labeled := make([]byte, 0, 7+len(k.suiteID)+len(label)+len(ikm))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, k.suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return hkdf.Extract(k.hash, labeled, salt)
+}
+
+// labeledExpand is LabeledExpand of RFC 9180 section 4.
+func (k hpkeKDF) labeledExpand(prk []byte, label string, info []byte, length int) ([]byte, error) {
+	if length > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: HPKE output of %d bytes", ErrInvalidParameters, length)
+	}
+	labeled := make([]byte, 0, 2+7+len(k.suiteID)+len(label)+len(info))
+	labeled = binary.BigEndian.AppendUint16(labeled, uint16(length))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, k.suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	return hkdf.Expand(k.hash, prk, string(labeled), length)
+}
+
+// String returns the RFC 9180 name of the KEM.
+func (k HPKEKEM) String() string {
+	switch k {
+	case HPKEDHKEMP256:
+		return "DHKEM(P-256, HKDF-SHA256)"
+	case HPKEDHKEMX25519:
+		return "DHKEM(X25519, HKDF-SHA256)"
+	default:
+		return fmt.Sprintf("HPKEKEM(0x%04x)", uint16(k))
+	}
+}
+
+// String returns the RFC 9180 name of the KDF.
+func (k HPKEKDF) String() string {
+	switch k {
+	case HPKEHKDFSHA256:
+		return "HKDF-SHA256"
+	case HPKEHKDFSHA512:
+		return "HKDF-SHA512"
+	default:
+		return fmt.Sprintf("HPKEKDF(0x%04x)", uint16(k))
+	}
+}
+
+// String returns the RFC 9180 name of the AEAD.
+func (a HPKEAEAD) String() string {
+	switch a {
+	case HPKEAES128GCM:
+		return "AES-128-GCM"
+	case HPKEAES256GCM:
+		return "AES-256-GCM"
+	case HPKEChaCha20Poly1305:
+		return "ChaCha20Poly1305"
+	case HPKEExportOnly:
+		return "Export-only"
+	default:
+		return fmt.Sprintf("HPKEAEAD(0x%04x)", uint16(a))
+	}
+}
+
+// curve returns the KEM's Diffie-Hellman group.
+func (k HPKEKEM) curve() (ecdh.Curve, error) {
+	switch k {
+	case HPKEDHKEMP256:
+		return ecdh.P256(), nil
+	case HPKEDHKEMX25519:
+		return ecdh.X25519(), nil
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, k)
+	}
+}
+
+// kdf returns the KEM's HKDF-SHA256, labeled with suite_id "KEM" || kem_id.
+func (k HPKEKEM) kdf() hpkeKDF {
+	return hpkeKDF{
+		hash:    sha256.New,
+		size:    sha256.Size,
+		suiteID: binary.BigEndian.AppendUint16([]byte("KEM"), uint16(k)),
+	}
+}
+
+// check rejects a suite with an unknown algorithm.
+func (s HPKESuite) check() error {
+	if _, err := s.KEM.curve(); err != nil {
+		return err
+	}
+	if _, err := s.kdf(); err != nil {
+		return err
+	}
+	switch s.AEAD {
+	case HPKEAES128GCM, HPKEAES256GCM, HPKEChaCha20Poly1305, HPKEExportOnly:
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, s.AEAD)
+}
+
+// kdf returns the suite's key-schedule HKDF, labeled with suite_id "HPKE" ||
+// kem_id || kdf_id || aead_id.
+func (s HPKESuite) kdf() (hpkeKDF, error) {
+	suiteID := []byte("HPKE")
+	suiteID = binary.BigEndian.AppendUint16(suiteID, uint16(s.KEM))
+	suiteID = binary.BigEndian.AppendUint16(suiteID, uint16(s.KDF))
+	suiteID = binary.BigEndian.AppendUint16(suiteID, uint16(s.AEAD))
+	switch s.KDF {
+	case HPKEHKDFSHA256:
+		return hpkeKDF{hash: sha256.New, size: sha256.Size, suiteID: suiteID}, nil
+	case HPKEHKDFSHA512:
+		return hpkeKDF{hash: sha512.New, size: sha512.Size, suiteID: suiteID}, nil
+	default:
+		return hpkeKDF{}, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, s.KDF)
+	}
+}
+
+// GenerateKeyPair generates a key pair for the suite's KEM and returns it
+// serialized.
+func (s HPKESuite) GenerateKeyPair() (publicKey, privateKey []byte, err error) {
+	curve, err := s.KEM.curve()
+	if err != nil {
+		return nil, nil, err
+	}
+	priv, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating key: %w", err)
+	}
+	return priv.PublicKey().Bytes(), priv.Bytes(), nil
+}
+
+// DeriveKeyPair deterministically derives a key pair for the suite's KEM from
+// ikm, which must hold at least as many bytes of entropy as the private key
+// (RFC 9180 section 7.1.3). The same ikm always gives the same pair.
+func (s HPKESuite) DeriveKeyPair(ikm []byte) (publicKey, privateKey []byte, err error) {
+	priv, err := s.KEM.deriveKeyPair(ikm)
+	if err != nil {
+		return nil, nil, err
+	}
+	return priv.PublicKey().Bytes(), priv.Bytes(), nil
+}
+
+// deriveKeyPair is DeriveKeyPair of RFC 9180 section 7.1.3.
+func (k HPKEKEM) deriveKeyPair(ikm []byte) (*ecdh.PrivateKey, error) {
+	curve, err := k.curve()
+	if err != nil {
+		return nil, err
+	}
+	if len(ikm) < 32 {
+		return nil, fmt.Errorf("%w: HPKE key material of %d bytes (want at least 32)", ErrInvalidKeySize, len(ikm))
+	}
+	kdf := k.kdf()
+	prk, err := kdf.labeledExtract(nil, "dkp_prk", ikm)
+	if err != nil {
+		return nil, err
+	}
+
+	if k == HPKEDHKEMX25519 {
+		sk, err := kdf.labeledExpand(prk, "sk", nil, 32)
+		if err != nil {
+			return nil, err
+		}
+		return curve.NewPrivateKey(sk)
+	}
+	// P-256: the bitmask is 0xff, so a candidate only needs to be a valid
+	// non-zero scalar, which NewPrivateKey checks
+	for counter := range 256 {
+		sk, err := kdf.labeledExpand(prk, "candidate", []byte{byte(counter)}, 32)
+		if err != nil {
+			return nil, err
+		}
+		if priv, err := curve.NewPrivateKey(sk); err == nil {
+			return priv, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no valid P-256 key derived", ErrInvalidParameters)
+}
+
+// parsePublicKey deserializes a public key of the KEM.
+func (k HPKEKEM) parsePublicKey(b []byte) (*ecdh.PublicKey, error) {
+	curve, err := k.curve()
+	if err != nil {
+		return nil, err
+	}
+	if want := k.publicKeySize(); len(b) != want {
+		return nil, fmt.Errorf("%w: %v public key of %d bytes (want %d)", ErrInvalidKeySize, k, len(b), want)
+	}
+	pub, err := curve.NewPublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+	}
+	return pub, nil
+}
+
+// parsePrivateKey deserializes a private key of the KEM.
+func (k HPKEKEM) parsePrivateKey(b []byte) (*ecdh.PrivateKey, error) {
+	curve, err := k.curve()
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 32 {
+		return nil, fmt.Errorf("%w: %v private key of %d bytes (want 32)", ErrInvalidKeySize, k, len(b))
+	}
+	priv, err := curve.NewPrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+	}
+	return priv, nil
+}
+
+// publicKeySize is Npk (and Nenc) of the KEM.
+func (k HPKEKEM) publicKeySize() int {
+	if k == HPKEDHKEMP256 {
+		return 65
+	}
+	return 32
+}
+
+// encap is Encap, or AuthEncap when skS is set (RFC 9180 section 4.1),
+// with the ephemeral key skE.
+func (k HPKEKEM) encap(skE *ecdh.PrivateKey, pkR *ecdh.PublicKey, skS *ecdh.PrivateKey) (sharedSecret, enc []byte, err error) {
+	dh, err := skE.ECDH(pkR)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+	}
+	enc = skE.PublicKey().Bytes()
+	kemContext := append(append([]byte{}, enc...), pkR.Bytes()...)
+	if skS != nil {
+		dhS, err := skS.ECDH(pkR)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, skS.PublicKey().Bytes()...)
+	}
+	sharedSecret, err = k.extractAndExpand(dh, kemContext)
+	return sharedSecret, enc, err
+}
+
+// decap is Decap, or AuthDecap when pkS is set.
+func (k HPKEKEM) decap(enc []byte, skR *ecdh.PrivateKey, pkS *ecdh.PublicKey) ([]byte, error) {
+	pkE, err := k.parsePublicKey(enc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid encapsulated key: %w", ErrAuthenticationFailed, err)
+	}
+	dh, err := skR.ECDH(pkE)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
+	}
+	kemContext := append(append([]byte{}, enc...), skR.PublicKey().Bytes()...)
+	if pkS != nil {
+		dhS, err := skR.ECDH(pkS)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkS.Bytes()...)
+	}
+	return k.extractAndExpand(dh, kemContext)
+}
+
+// extractAndExpand is ExtractAndExpand of RFC 9180 section 4.1.
+func (k HPKEKEM) extractAndExpand(dh, kemContext []byte) ([]byte, error) {
+	defer clear(dh)
+	kdf := k.kdf()
+	prk, err := kdf.labeledExtract(nil, "eae_prk", dh)
+	if err != nil {
+		return nil, err
+	}
+	return kdf.labeledExpand(prk, "shared_secret", kemContext, kdf.size)
+}
+
+// mode resolves the HPKE mode from opts and checks its PSK inputs (RFC 9180
+// section 5.1, VerifyPSKInputs). It refuses the sender key field of the other
+// side, so a misplaced key cannot silently select the base or PSK mode.
+func (o *HPKEOptions) mode(sending bool) (mode byte, psk, pskID []byte, err error) {
+	if o == nil {
+		return hpkeModeBase, nil, nil, nil
+	}
+	if sending && o.SenderPublicKey != nil {
+		return 0, nil, nil, fmt.Errorf("%w: HPKE SenderPublicKey is set on the sending side", ErrInvalidParameters)
+	}
+	if !sending && o.SenderPrivateKey != nil {
+		return 0, nil, nil, fmt.Errorf("%w: HPKE SenderPrivateKey is set on the receiving side", ErrInvalidParameters)
+	}
+	auth := o.SenderPublicKey != nil
+	if sending {
+		auth = o.SenderPrivateKey != nil
+	}
+	if (len(o.PSK) == 0) != (len(o.PSKID) == 0) {
+		return 0, nil, nil, fmt.Errorf("%w: HPKE PSK and PSKID must be set together", ErrInvalidParameters)
+	}
+	if len(o.PSK) > 0 && len(o.PSK) < hpkeMinPSKSize {
+		return 0, nil, nil, fmt.Errorf("%w: HPKE PSK of %d bytes (want at least %d)", ErrInvalidKeySize, len(o.PSK), hpkeMinPSKSize)
+	}
+	switch {
+	case auth && len(o.PSK) > 0:
+		mode = hpkeModeAuthPSK
+	case auth:
+		mode = hpkeModeAuth
+	case len(o.PSK) > 0:
+		mode = hpkeModePSK
+	default:
+		mode = hpkeModeBase
+	}
+	return mode, o.PSK, o.PSKID, nil
+}
+
+// NewSender sets up an HPKE context to recipientPublicKey, binding it to info
+// (an application-chosen context string, which may be nil). It returns the
+// encapsulated key, which the recipient needs to set up the matching
+// context, and the sender context; opts selects the mode.
+func (s HPKESuite) NewSender(recipientPublicKey, info []byte, opts *HPKEOptions) (enc []byte, sender *HPKESender, err error) {
+	if err := s.check(); err != nil {
+		return nil, nil, err
+	}
+	curve, _ := s.KEM.curve()
+	skE, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating key: %w", err)
+	}
+	return s.newSender(skE, recipientPublicKey, info, opts)
+}
+
+// newSender is NewSender with the ephemeral key skE.
+func (s HPKESuite) newSender(skE *ecdh.PrivateKey, recipientPublicKey, info []byte, opts *HPKEOptions) ([]byte, *HPKESender, error) {
+	mode, psk, pskID, err := opts.mode(true)
+	if err != nil {
+		return nil, nil, err
+	}
+	pkR, err := s.KEM.parsePublicKey(recipientPublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	var skS *ecdh.PrivateKey
+	if mode == hpkeModeAuth || mode == hpkeModeAuthPSK {
+		if skS, err = s.KEM.parsePrivateKey(opts.SenderPrivateKey); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sharedSecret, enc, err := s.KEM.encap(skE, pkR, skS)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, err := s.keySchedule(mode, sharedSecret, info, psk, pskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, &HPKESender{ctx: ctx}, nil
+}
+
+// NewRecipient sets up the receiving HPKE context for enc, the encapsulated
+// key from [HPKESuite.NewSender], with the recipient's private key. info and
+// opts must match the sender's. A corrupt enc wraps [ErrAuthenticationFailed];
+// a wrong key or info only shows when Open fails.
+func (s HPKESuite) NewRecipient(enc, recipientPrivateKey, info []byte, opts *HPKEOptions) (*HPKERecipient, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	mode, psk, pskID, err := opts.mode(false)
+	if err != nil {
+		return nil, err
+	}
+	skR, err := s.KEM.parsePrivateKey(recipientPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	var pkS *ecdh.PublicKey
+	if mode == hpkeModeAuth || mode == hpkeModeAuthPSK {
+		if pkS, err = s.KEM.parsePublicKey(opts.SenderPublicKey); err != nil {
+			return nil, err
+		}
+	}
+
+	sharedSecret, err := s.KEM.decap(enc, skR, pkS)
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := s.keySchedule(mode, sharedSecret, info, psk, pskID)
+	if err != nil {
+		return nil, err
+	}
+	return &HPKERecipient{ctx: ctx}, nil
+}
+
+// keySchedule is KeySchedule of RFC 9180 section 5.1.
+func (s HPKESuite) keySchedule(mode byte, sharedSecret, info, psk, pskID []byte) (hpkeContext, error) {
+	defer clear(sharedSecret)
+	kdf, err := s.kdf()
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	pskIDHash, err := kdf.labeledExtract(nil, "psk_id_hash", pskID)
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	infoHash, err := kdf.labeledExtract(nil, "info_hash", info)
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	ksContext := append(append([]byte{mode}, pskIDHash...), infoHash...)
+	secret, err := kdf.labeledExtract(sharedSecret, "secret", psk)
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	defer clear(secret)
+
+	ctx := hpkeContext{kdf: kdf}
+	if ctx.exporterSecret, err = kdf.labeledExpand(secret, "exp", ksContext, kdf.size); err != nil {
+		return hpkeContext{}, err
+	}
+	if s.AEAD == HPKEExportOnly {
+		return ctx, nil
+	}
+
+	keySize := 32
+	if s.AEAD == HPKEAES128GCM {
+		keySize = 16
+	}
+	key, err := kdf.labeledExpand(secret, "key", ksContext, keySize)
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	defer clear(key)
+	if s.AEAD == HPKEChaCha20Poly1305 {
+		ctx.aead, err = chacha20poly1305.New(key)
+	} else {
+		ctx.aead, err = aesGCM(key)
+	}
+	if err != nil {
+		return hpkeContext{}, err
+	}
+	if ctx.baseNonce, err = kdf.labeledExpand(secret, "base_nonce", ksContext, ctx.aead.NonceSize()); err != nil {
+		return hpkeContext{}, err
+	}
+	return ctx, nil
+}
+
+// nonce returns the nonce of the current message: base_nonce XOR seq.
+func (c *hpkeContext) nonce() ([]byte, error) {
+	if c.aead == nil {
+		return nil, fmt.Errorf("%w: export-only HPKE context cannot seal or open", ErrUnsupportedAlgorithm)
+	}
+	if c.seq == math.MaxUint64 {
+		return nil, ErrKeyUsageLimit
+	}
+	nonce := make([]byte, len(c.baseNonce))
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], c.seq)
+	for i := range nonce {
+		nonce[i] ^= c.baseNonce[i]
+	}
+	return nonce, nil
+}
+
+// export is Context.Export of RFC 9180 section 5.3.
+func (c *hpkeContext) export(exporterContext []byte, length int) ([]byte, error) {
+	if length < 0 || length > 255*c.kdf.size {
+		return nil, fmt.Errorf("%w: HPKE export of %d bytes (at most %d)", ErrInvalidParameters, length, 255*c.kdf.size)
+	}
+	return c.kdf.labeledExpand(c.exporterSecret, "sec", exporterContext, length)
+}
+
+// Seal encrypts plaintext and authenticates aad as the next message of the
+// context. Messages must be opened in the order they were sealed.
+func (s *HPKESender) Seal(aad, plaintext []byte) ([]byte, error) {
+	nonce, err := s.ctx.nonce()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := s.ctx.aead.Seal(nil, nonce, plaintext, aad)
+	s.ctx.seq++
+	return ciphertext, nil
+}
+
+// Export derives a length-byte secret bound to the context and to
+// exporterContext; the recipient derives the same secret. length may be up to
+// 255 times the KDF's hash size.
+func (s *HPKESender) Export(exporterContext []byte, length int) ([]byte, error) {
+	return s.ctx.export(exporterContext, length)
+}
+
+// Open authenticates and decrypts the next message of the context. A failure
+// wraps [ErrAuthenticationFailed] and does not advance the context, so the
+// right message can still be opened.
+func (r *HPKERecipient) Open(aad, ciphertext []byte) ([]byte, error) {
+	nonce, err := r.ctx.nonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := r.ctx.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, ErrAuthenticationFailed
+	}
+	r.ctx.seq++
+	return plaintext, nil
+}
+
+// Export derives the same secret as [HPKESender.Export].
+func (r *HPKERecipient) Export(exporterContext []byte, length int) ([]byte, error) {
+	return r.ctx.export(exporterContext, length)
+}
+
+// Seal is single-shot HPKE encryption: it sets up a sender context to
+// recipientPublicKey and seals one message. The recipient needs both enc and
+// ciphertext.
+func (s HPKESuite) Seal(recipientPublicKey, info, aad, plaintext []byte, opts *HPKEOptions) (enc, ciphertext []byte, err error) {
+	enc, sender, err := s.NewSender(recipientPublicKey, info, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ciphertext, err = sender.Seal(aad, plaintext); err != nil {
+		return nil, nil, err
+	}
+	return enc, ciphertext, nil
+}
+
+// Open is single-shot HPKE decryption of a message from [HPKESuite.Seal].
+func (s HPKESuite) Open(enc, recipientPrivateKey, info, aad, ciphertext []byte, opts *HPKEOptions) ([]byte, error) {
+	recipient, err := s.NewRecipient(enc, recipientPrivateKey, info, opts)
+	if err != nil {
+		return nil, err
+	}
+	return recipient.Open(aad, ciphertext)
+}
+
+// SendExport is single-shot secret export: it sets up a sender context to
+// recipientPublicKey and exports a length-byte secret, which the recipient
+// derives from enc with [HPKESuite.ReceiveExport].
+func (s HPKESuite) SendExport(recipientPublicKey, info, exporterContext []byte, length int, opts *HPKEOptions) (enc, secret []byte, err error) {
+	enc, sender, err := s.NewSender(recipientPublicKey, info, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret, err = sender.Export(exporterContext, length); err != nil {
+		return nil, nil, err
+	}
+	return enc, secret, nil
+}
+
+// ReceiveExport derives the secret of [HPKESuite.SendExport].
+func (s HPKESuite) ReceiveExport(enc, recipientPrivateKey, info, exporterContext []byte, length int, opts *HPKEOptions) ([]byte, error) {
+	recipient, err := s.NewRecipient(enc, recipientPrivateKey, info, opts)
+	if err != nil {
+		return nil, err
+	}
+	return recipient.Export(exporterContext, length)
+}
@@ -0,0 +1,585 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/sha3"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// hpkeSuites are all the supported suites.
+func hpkeSuites() []HPKESuite {
+	var suites []HPKESuite
+	for _, kem := range []HPKEKEM{HPKEDHKEMX25519, HPKEDHKEMP256} {
+		for _, kdf := range []HPKEKDF{HPKEHKDFSHA256, HPKEHKDFSHA512} {
+			for _, aead := range []HPKEAEAD{HPKEAES128GCM, HPKEAES256GCM, HPKEChaCha20Poly1305, HPKEExportOnly} {
+				suites = append(suites, HPKESuite{kem, kdf, aead})
+			}
+		}
+	}
+	return suites
+}
+
+// drawHPKEInput reads a length byte n and then n bytes from r.
+func drawHPKEInput(t *testing.T, r io.Reader) []byte {
+	t.Helper()
+	var n [1]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, n[0])
+	if _, err := io.ReadFull(r, b); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestHPKERFC9180(t *testing.T) {
+	// testdata/hpke-rfc9180.json holds the base-mode vectors of RFC 9180 as
+	// distributed with the Go crypto/hpke tests. Each one seals 1000 messages
+	// and exports 1000 secrets of lengths 0 to 999, with inputs drawn from an
+	// unkeyed SHAKE128 stream, and compares a SHAKE128 digest of the outputs.
+	b, err := os.ReadFile(filepath.Join("testdata", "hpke-rfc9180.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		Mode                   byte
+		KEMID                  uint16 `json:"kem_id"`
+		KDFID                  uint16 `json:"kdf_id"`
+		AEADID                 uint16 `json:"aead_id"`
+		Info                   string
+		IkmE                   string `json:"ikmE"`
+		IkmR                   string `json:"ikmR"`
+		SkRm                   string `json:"skRm"`
+		PkRm                   string `json:"pkRm"`
+		Enc                    string
+		EncryptionsAccumulated string `json:"encryptions_accumulated"`
+		ExportsAccumulated     string `json:"exports_accumulated"`
+	}
+	if err := json.Unmarshal(b, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	tested := 0
+	for _, v := range vectors {
+		suite := HPKESuite{HPKEKEM(v.KEMID), HPKEKDF(v.KDFID), HPKEAEAD(v.AEADID)}
+		if suite.check() != nil {
+			continue // DHKEM(P-521) is not supported
+		}
+		tested++
+		t.Run(suite.KEM.String()+"/"+suite.KDF.String()+"/"+suite.AEAD.String(), func(t *testing.T) {
+			pkR, skR, err := suite.DeriveKeyPair(mustHex(t, v.IkmR))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(pkR, mustHex(t, v.PkRm)) || !bytes.Equal(skR, mustHex(t, v.SkRm)) {
+				t.Fatalf("DeriveKeyPair = %x, %x; want %s, %s", pkR, skR, v.PkRm, v.SkRm)
+			}
+			skE, err := suite.KEM.deriveKeyPair(mustHex(t, v.IkmE))
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := mustHex(t, v.Info)
+			enc, sender, err := suite.newSender(skE, pkR, info, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(enc, mustHex(t, v.Enc)) {
+				t.Fatalf("enc = %x, want %s", enc, v.Enc)
+			}
+			recipient, err := suite.NewRecipient(enc, skR, info, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if suite.AEAD != HPKEExportOnly {
+				source, sink := sha3.NewSHAKE128(), sha3.NewSHAKE128()
+				for range 1000 {
+					aad, plaintext := drawHPKEInput(t, source), drawHPKEInput(t, source)
+					ciphertext, err := sender.Seal(aad, plaintext)
+					if err != nil {
+						t.Fatal(err)
+					}
+					sink.Write(ciphertext)
+					got, err := recipient.Open(aad, ciphertext)
+					if err != nil || !bytes.Equal(got, plaintext) {
+						t.Fatalf("Open = %x, %v; want %x", got, err, plaintext)
+					}
+				}
+				encryptions := make([]byte, 16)
+				sink.Read(encryptions)
+				if want := mustHex(t, v.EncryptionsAccumulated); !bytes.Equal(encryptions, want) {
+					t.Errorf("accumulated encryptions = %x, want %x", encryptions, want)
+				}
+			}
+
+			source, sink := sha3.NewSHAKE128(), sha3.NewSHAKE128()
+			for l := range 1000 {
+				context := drawHPKEInput(t, source)
+				value, err := sender.Export(context, l)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sink.Write(value)
+				got, err := recipient.Export(context, l)
+				if err != nil || !bytes.Equal(got, value) {
+					t.Fatalf("recipient Export(%d) = %x, %v; want %x", l, got, err, value)
+				}
+			}
+			exports := make([]byte, 16)
+			sink.Read(exports)
+			if want := mustHex(t, v.ExportsAccumulated); !bytes.Equal(exports, want) {
+				t.Errorf("accumulated exports = %x, want %x", exports, want)
+			}
+		})
+	}
+	if tested != 16 {
+		t.Errorf("tested %d vectors, want 16", tested)
+	}
+}
+
+// hpkeModeVectors are the PSK, auth and auth-PSK vectors of RFC 9180
+// appendix A for DHKEM(X25519, HKDF-SHA256) and DHKEM(P-256, HKDF-SHA256),
+// each with HKDF-SHA256 and AES-128-GCM; the Go distribution ships only the
+// base mode. All of them use the psk, psk_id and info of appendix A.1.2 and
+// seal "Beauty is truth, truth beauty" with aad "Count-<seq>".
+//
+// The key pairs come from ikmE, ikmR and ikmS, checked against skRm and skSm;
+// the A.1.2 and A.3.4 entries carry only the RFC's skRm and skSm.
+// ciphertexts are those of the sequence numbers 0, 1, 2, 4, 255 and 256, and
+// exports the 32-byte values for the contexts "", 0x00 and "TestContext".
+var hpkeModeVectors = []struct {
+	section                      string
+	mode                         byte
+	kem                          HPKEKEM
+	ikmE, ikmR, skRm, ikmS, skSm string
+	enc                          string
+	ciphertexts, exports         []string
+}{
+	{
+		section: "A.1.2", mode: hpkeModePSK, kem: HPKEDHKEMX25519,
+		ikmE: "78628c354e46f3e169bd231be7b2ff1c77aa302460a26dbfa15515684c00130b",
+		skRm: "c5eb01eb457fe6c6f57577c5413b931550a162c71a03ac8d196babbd4e5ce0fd",
+		enc:  "0ad0950d9fb9588e59690b74f1237ecdf1d775cd60be2eca57af5a4b0471c91b",
+		ciphertexts: []string{
+			"e52c6fed7f758d0cf7145689f21bc1be6ec9ea097fef4e959440012f4feb73fb611b946199e681f4cfc34db8ea",
+			"49f3b19b28a9ea9f43e8c71204c00d4a490ee7f61387b6719db765e948123b45b61633ef059ba22cd62437c8ba",
+			"257ca6a08473dc851fde45afd598cc83e326ddd0abe1ef23baa3baa4dd8cde99fce2c1e8ce687b0b47ead1adc9",
+			"a71d73a2cd8128fcccbd328b9684d70096e073b59b40b55e6419c9c68ae21069c847e2a70f5d8fb821ce3dfb1c",
+			"55f84b030b7f7197f7d7d552365b6b932df5ec1abacd30241cb4bc4ccea27bd2b518766adfa0fb1b71170e9392",
+			"c5bf246d4a790a12dcc9eed5eae525081e6fb541d5849e9ce8abd92a3bc1551776bea16b4a518f23e237c14b59",
+		},
+		exports: []string{
+			"dff17af354c8b41673567db6259fd6029967b4e1aad13023c2ae5df8f4f43bf6",
+			"6a847261d8207fe596befb52928463881ab493da345b10e1dcc645e3b94e2d95",
+			"8aff52b45a1be3a734bc7a41e20b4e055ad4c4d22104b0c20285a7c4302401cd",
+		},
+	},
+	{
+		section: "A.1.3", mode: hpkeModeAuth, kem: HPKEDHKEMX25519,
+		ikmE: "6e6d8f200ea2fb20c30b003a8b4f433d2f4ed4c2658d5bc8ce2fef718059c9f7",
+		ikmR: "f1d4a30a4cef8d6d4e3b016e6fd3799ea057db4f345472ed302a67ce1c20cdec",
+		skRm: "fdea67cf831f1ca98d8e27b1f6abeb5b7745e9d35348b80fa407ff6958f9137e",
+		ikmS: "94b020ce91d73fca4649006c7e7329a67b40c55e9e93cc907d282bbbff386f58",
+		skSm: "dc4a146313cce60a278a5323d321f051c5707e9c45ba21a3479fecdf76fc69dd",
+		enc:  "23fb952571a14a25e3d678140cd0e5eb47a0961bb18afcf85896e5453c312e76",
+		ciphertexts: []string{
+			"5fd92cc9d46dbf8943e72a07e42f363ed5f721212cd90bcfd072bfd9f44e06b80fd17824947496e21b680c141b",
+			"d3736bb256c19bfa93d79e8f80b7971262cb7c887e35c26370cfed62254369a1b52e3d505b79dd699f002bc8ed",
+			"122175cfd5678e04894e4ff8789e85dd381df48dcaf970d52057df2c9acc3b121313a2bfeaa986050f82d93645",
+			"dae12318660cf963c7bcbef0f39d64de3bf178cf9e585e756654043cc5059873bc8af190b72afc43d1e0135ada",
+			"55d53d85fe4d9e1e97903101eab0b4865ef20cef28765a47f840ff99625b7d69dee927df1defa66a036fc58ff2",
+			"42fa248a0e67ccca688f2b1d13ba4ba84755acf764bd797c8f7ba3b9b1dc3330326f8d172fef6003c79ec72319",
+		},
+		exports: []string{
+			"28c70088017d70c896a8420f04702c5a321d9cbf0279fba899b59e51bac72c85",
+			"25dfc004b0892be1888c3914977aa9c9bbaf2c7471708a49e1195af48a6f29ce",
+			"5a0131813abc9a522cad678eb6bafaabc43389934adb8097d23c5ff68059eb64",
+		},
+	},
+	{
+		section: "A.1.4", mode: hpkeModeAuthPSK, kem: HPKEDHKEMX25519,
+		ikmE: "4303619085a20ebcf18edd22782952b8a7161e1dbae6e46e143a52a96127cf84",
+		ikmR: "4b16221f3b269a88e207270b5e1de28cb01f847841b344b8314d6a622fe5ee90",
+		skRm: "cb29a95649dc5656c2d054c1aa0d3df0493155e9d5da6d7e344ed8b6a64a9423",
+		ikmS: "62f77dcf5df0dd7eac54eac9f654f426d4161ec850cc65c54f8b65d2e0b4e345",
+		skSm: "fc1c87d2f3832adb178b431fce2ac77c7ca2fd680f3406c77b5ecdf818b119f4",
+		enc:  "820818d3c23993492cc5623ab437a48a0a7ca3e9639c140fe1e33811eb844b7c",
+		ciphertexts: []string{
+			"a84c64df1e11d8fd11450039d4fe64ff0c8a99fca0bd72c2d4c3e0400bc14a40f27e45e141a24001697737533e",
+			"4d19303b848f424fc3c3beca249b2c6de0a34083b8e909b6aa4c3688505c05ffe0c8f57a0a4c5ab9da127435d9",
+			"0c085a365fbfa63409943b00a3127abce6e45991bc653f182a80120868fc507e9e4d5e37bcc384fc8f14153b24",
+			"000a3cd3a3523bf7d9796830b1cd987e841a8bae6561ebb6791a3f0e34e89a4fb539faeee3428b8bbc082d2c1a",
+			"576d39dd2d4cc77d1a14a51d5c5f9d5e77586c3d8d2ab33bdec6379e28ce5c502f0b1cbd09047cf9eb9269bb52",
+			"13239bab72e25e9fd5bb09695d23c90a24595158b99127505c8a9ff9f127e0d657f71af59d67d4f4971da028f9",
+		},
+		exports: []string{
+			"08f7e20644bb9b8af54ad66d2067457c5f9fcb2a23d9f6cb4445c0797b330067",
+			"52e51ff7d436557ced5265ff8b94ce69cf7583f49cdb374e6aad801fc063b010",
+			"a30c20370c026bbea4dca51cb63761695132d342bae33a6a11527d3e7679436d",
+		},
+	},
+	{
+		section: "A.3.2", mode: hpkeModePSK, kem: HPKEDHKEMP256,
+		ikmE: "2afa611d8b1a7b321c761b483b6a053579afa4f767450d3ad0f84a39fda587a6",
+		ikmR: "d42ef874c1913d9568c9405407c805baddaffd0898a00f1e84e154fa787b2429",
+		skRm: "438d8bcef33b89e0e9ae5eb0957c353c25a94584b0dd59c991372a75b43cb661",
+		enc:  "04305d35563527bce037773d79a13deabed0e8e7cde61eecee403496959e89e4d0ca701726696d1485137ccb5341b3c1c7aaee90a4a02449725e744b1193b53b5f",
+		ciphertexts: []string{
+			"90c4deb5b75318530194e4bb62f890b019b1397bbf9d0d6eb918890e1fb2be1ac2603193b60a49c2126b75d0eb",
+			"9e223384a3620f4a75b5a52f546b7262d8826dea18db5a365feb8b997180b22d72dc1287f7089a1073a7102c27",
+			"adf9f6000773035023be7d415e13f84c1cb32a24339a32eb81df02be9ddc6abc880dd81cceb7c1d0c7781465b2",
+			"1f4cc9b7013d65511b1f69c050b7bd8bbd5a5c16ece82b238fec4f30ba2400e7ca8ee482ac5253cffb5c3dc577",
+			"cdc541253111ed7a424eea5134dc14fc5e8293ab3b537668b8656789628e45894e5bb873c968e3b7cdcbb654a4",
+			"faf985208858b1253b97b60aecd28bc18737b58d1242370e7703ec33b73a4c31a1afee300e349adef9015bbbfd",
+		},
+		exports: []string{
+			"a115a59bf4dd8dc49332d6a0093af8efca1bcbfd3627d850173f5c4a55d0c185",
+			"4517eaede0669b16aac7c92d5762dd459c301fa10e02237cd5aeb9be969430c4",
+			"164e02144d44b607a7722e58b0f4156e67c0c2874d74cf71da6ca48a4cbdc5e0",
+		},
+	},
+	{
+		section: "A.3.3", mode: hpkeModeAuth, kem: HPKEDHKEMP256,
+		ikmE: "798d82a8d9ea19dbc7f2c6dfa54e8a6706f7cdc119db0813dacf8440ab37c857",
+		ikmR: "7bc93bde8890d1fb55220e7f3b0c107ae7e6eda35ca4040bb6651284bf0747ee",
+		skRm: "d929ab4be2e59f6954d6bedd93e638f02d4046cef21115b00cdda2acb2a4440e",
+		ikmS: "874baa0dcf93595a24a45a7f042e0d22d368747daaa7e19f80a802af19204ba8",
+		skSm: "1120ac99fb1fccc1e8230502d245719d1b217fe20505c7648795139d177f0de9",
+		enc:  "042224f3ea800f7ec55c03f29fc9865f6ee27004f818fcbdc6dc68932c1e52e15b79e264a98f2c535ef06745f3d308624414153b22c7332bc1e691cb4af4d53454",
+		ciphertexts: []string{
+			"82ffc8c44760db691a07c5627e5fc2c08e7a86979ee79b494a17cc3405446ac2bdb8f265db4a099ed3289ffe19",
+			"b0a705a54532c7b4f5907de51c13dffe1e08d55ee9ba59686114b05945494d96725b239468f1229e3966aa1250",
+			"8dc805680e3271a801790833ed74473710157645584f06d1b53ad439078d880b23e25256663178271c80ee8b7c",
+			"04c8f7aae1584b61aa5816382cb0b834a5d744f420e6dffb5ddcec633a21b8b3472820930c1ea9258b035937a2",
+			"4a319462eaedee37248b4d985f64f4f863d31913fe9e30b6e13136053b69fe5d70853c84c60a84bb5495d5a678",
+			"28e874512f8940fafc7d06135e7589f6b4198bc0f3a1c64702e72c9e6abaf9f05cb0d2f11b03a517898815c934",
+		},
+		exports: []string{
+			"837e49c3ff629250c8d80d3c3fb957725ed481e59e2feb57afd9fe9a8c7c4497",
+			"594213f9018d614b82007a7021c3135bda7b380da4acd9ab27165c508640dbda",
+			"14fe634f95ca0d86e15247cca7de7ba9b73c9b9deb6437e1c832daf7291b79d5",
+		},
+	},
+	{
+		section: "A.3.4", mode: hpkeModeAuthPSK, kem: HPKEDHKEMP256,
+		ikmE: "3c1fceb477ec954c8d58ef3249e4bb4c38241b5925b95f7486e4d9f1d0d35fbb",
+		skRm: "bdf4e2e587afdf0930644a0c45053889ebcadeca662d7c755a353d5b4e2a8394",
+		skSm: "b0ed8721db6185435898650f7a677affce925aba7975a582653c4cb13c72d240",
+		enc:  "046a1de3fc26a3d43f4e4ba97dbe24f7e99181136129c48fbe872d4743e2b131357ed4f29a7b317dc22509c7b00991ae990bf65f8b236700c82ab7c11a84511401",
+		ciphertexts: []string{
+			"b9f36d58d9eb101629a3e5a7b63d2ee4af42b3644209ab37e0a272d44365407db8e655c72e4fa46f4ff81b9246",
+			"51788c4e5d56276771032749d015d3eea651af0c7bb8e3da669effffed299ea1f641df621af65579c10fc09736",
+			"3b5a2be002e7b29927f06442947e1cf709b9f8508b03823127387223d712703471c266efc355f1bc2036f3027c",
+			"8ddbf1242fe5c7d61e1675496f3bfdb4d90205b3dfbc1b12aab41395d71a82118e095c484103107cf4face5123",
+			"6de25ceadeaec572fbaa25eda2558b73c383fe55106abaec24d518ef6724a7ce698f83ecdc53e640fe214d2f42",
+			"f380e19d291e12c5e378b51feb5cd50f6d00df6cb2af8393794c4df342126c2e29633fe7e8ce49587531affd4d",
+		},
+		exports: []string{
+			"595ce0eff405d4b3bb1d08308d70a4e77226ce11766e0a94c4fdb5d90025c978",
+			"110472ee0ae328f57ef7332a9886a1992d2c45b9b8d5abc9424ff68630f7d38d",
+			"18ee4d001a9d83a4c67e76f88dd747766576cac438723bad0700a910a4d717e6",
+		},
+	},
+}
+
+func TestHPKEModes(t *testing.T) {
+	psk := mustHex(t, "0247fd33b913760fa1fa51e1892d9f307fbe65eb171e8132c2af18555a738b82")
+	pskID, info := []byte("Ennyn Durin aran Moria"), []byte("Ode on a Grecian Urn")
+	plaintext := []byte("Beauty is truth, truth beauty")
+	seqs := []int{0, 1, 2, 4, 255, 256}
+	exporterContexts := [][]byte{{}, {0}, []byte("TestContext")}
+
+	for _, v := range hpkeModeVectors {
+		t.Run(v.section, func(t *testing.T) {
+			suite := HPKESuite{v.kem, HPKEHKDFSHA256, HPKEAES128GCM}
+			// keyPair checks DeriveKeyPair(ikm) against sk when the RFC
+			// lists both.
+			keyPair := func(ikm, sk string) (pk, skBytes []byte) {
+				if sk == "" {
+					return nil, nil
+				}
+				skBytes = mustHex(t, sk)
+				if ikm != "" {
+					_, derived, err := suite.DeriveKeyPair(mustHex(t, ikm))
+					if err != nil || !bytes.Equal(derived, skBytes) {
+						t.Fatalf("DeriveKeyPair(%s) = %x, %v; want %s", ikm, derived, err, sk)
+					}
+				}
+				priv, err := v.kem.parsePrivateKey(skBytes)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return priv.PublicKey().Bytes(), skBytes
+			}
+			skE, err := v.kem.deriveKeyPair(mustHex(t, v.ikmE))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pkR, skR := keyPair(v.ikmR, v.skRm)
+			pkS, skS := keyPair(v.ikmS, v.skSm)
+
+			sendOpts, recvOpts := &HPKEOptions{}, &HPKEOptions{}
+			if v.mode == hpkeModePSK || v.mode == hpkeModeAuthPSK {
+				sendOpts.PSK, sendOpts.PSKID = psk, pskID
+				recvOpts.PSK, recvOpts.PSKID = psk, pskID
+			}
+			if v.mode == hpkeModeAuth || v.mode == hpkeModeAuthPSK {
+				sendOpts.SenderPrivateKey, recvOpts.SenderPublicKey = skS, pkS
+			}
+
+			enc, sender, err := suite.newSender(skE, pkR, info, sendOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(enc, mustHex(t, v.enc)) {
+				t.Errorf("enc = %x, want %s", enc, v.enc)
+			}
+			recipient, err := suite.NewRecipient(enc, skR, info, recvOpts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next := 0
+			for seq := 0; seq <= seqs[len(seqs)-1]; seq++ {
+				aad := fmt.Appendf(nil, "Count-%d", seq)
+				ct, err := sender.Seal(aad, plaintext)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if seq == seqs[next] {
+					if want := v.ciphertexts[next]; !bytes.Equal(ct, mustHex(t, want)) {
+						t.Errorf("ciphertext %d = %x, want %s", seq, ct, want)
+					}
+					next++
+				}
+				if got, err := recipient.Open(aad, ct); err != nil || !bytes.Equal(got, plaintext) {
+					t.Fatalf("Open ciphertext %d = %q, %v", seq, got, err)
+				}
+			}
+			for i, exporterContext := range exporterContexts {
+				for _, ctx := range []interface {
+					Export([]byte, int) ([]byte, error)
+				}{sender, recipient} {
+					got, err := ctx.Export(exporterContext, 32)
+					if err != nil || !bytes.Equal(got, mustHex(t, v.exports[i])) {
+						t.Errorf("Export(%x) = %x, %v; want %s", exporterContext, got, err, v.exports[i])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHPKERoundTrip(t *testing.T) {
+	psk, pskID := bytes.Repeat([]byte{7}, 32), []byte("psk-2024")
+	info, aad := []byte("app v1"), []byte("header")
+	for _, suite := range hpkeSuites() {
+		pkR, skR, err := suite.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkS, skS, err := suite.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, opts := range map[string][2]*HPKEOptions{
+			"base":    {nil, nil},
+			"psk":     {{PSK: psk, PSKID: pskID}, {PSK: psk, PSKID: pskID}},
+			"auth":    {{SenderPrivateKey: skS}, {SenderPublicKey: pkS}},
+			"authPSK": {{PSK: psk, PSKID: pskID, SenderPrivateKey: skS}, {PSK: psk, PSKID: pskID, SenderPublicKey: pkS}},
+		} {
+			t.Run(suite.KEM.String()+"/"+suite.KDF.String()+"/"+suite.AEAD.String()+"/"+name, func(t *testing.T) {
+				enc, secret, err := suite.SendExport(pkR, info, []byte("label"), 48, opts[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := suite.ReceiveExport(enc, skR, info, []byte("label"), 48, opts[1])
+				if err != nil || !bytes.Equal(got, secret) {
+					t.Errorf("ReceiveExport = %x, %v; want %x", got, err, secret)
+				}
+				other, err := suite.ReceiveExport(enc, skR, []byte("app v2"), []byte("label"), 48, opts[1])
+				if err != nil || bytes.Equal(other, secret) {
+					t.Errorf("ReceiveExport with other info = %x, %v", other, err)
+				}
+
+				msg := []byte("attack at dawn")
+				enc, ct, err := suite.Seal(pkR, info, aad, msg, opts[0])
+				if suite.AEAD == HPKEExportOnly {
+					if !errors.Is(err, ErrUnsupportedAlgorithm) {
+						t.Errorf("Seal with export-only err = %v, want ErrUnsupportedAlgorithm", err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(enc) != suite.KEM.publicKeySize() {
+					t.Errorf("enc is %d bytes, want %d", len(enc), suite.KEM.publicKeySize())
+				}
+				if got, err := suite.Open(enc, skR, info, aad, ct, opts[1]); err != nil || !bytes.Equal(got, msg) {
+					t.Errorf("Open = %q, %v", got, err)
+				}
+				if _, err := suite.Open(enc, skR, []byte("app v2"), aad, ct, opts[1]); !errors.Is(err, ErrAuthenticationFailed) {
+					t.Errorf("Open with other info err = %v, want ErrAuthenticationFailed", err)
+				}
+				if _, err := suite.Open(enc, skR, info, []byte("other"), ct, opts[1]); !errors.Is(err, ErrAuthenticationFailed) {
+					t.Errorf("Open with other aad err = %v, want ErrAuthenticationFailed", err)
+				}
+			})
+		}
+	}
+}
+
+func TestHPKEContext(t *testing.T) {
+	suite := HPKESuite{HPKEDHKEMX25519, HPKEHKDFSHA256, HPKEChaCha20Poly1305}
+	pkR, skR, err := suite.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, sender, err := suite.NewSender(pkR, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := suite.NewRecipient(enc, skR, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cts [][]byte
+	for i := range 3 {
+		ct, err := sender.Seal(nil, fmt.Appendf(nil, "message %d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cts = append(cts, ct)
+	}
+	// out of order fails without advancing the context
+	if _, err := recipient.Open(nil, cts[1]); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("out-of-order Open err = %v, want ErrAuthenticationFailed", err)
+	}
+	for i, ct := range cts {
+		got, err := recipient.Open(nil, ct)
+		if err != nil || string(got) != fmt.Sprintf("message %d", i) {
+			t.Errorf("Open %d = %q, %v", i, got, err)
+		}
+	}
+
+	if _, err := sender.Export(nil, 255*32+1); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("oversized Export err = %v, want ErrInvalidParameters", err)
+	}
+	if _, err := sender.Export(nil, -1); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("negative Export err = %v, want ErrInvalidParameters", err)
+	}
+	sender.ctx.seq = 1<<64 - 1
+	if _, err := sender.Seal(nil, nil); !errors.Is(err, ErrKeyUsageLimit) {
+		t.Errorf("exhausted Seal err = %v, want ErrKeyUsageLimit", err)
+	}
+}
+
+func TestHPKEErrors(t *testing.T) {
+	suite := HPKESuite{HPKEDHKEMX25519, HPKEHKDFSHA256, HPKEAES128GCM}
+	pkR, skR, err := suite.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, ct, err := suite.Seal(pkR, nil, nil, []byte("x"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, s := range map[string]HPKESuite{
+		"kem":  {HPKEKEM(0x12), HPKEHKDFSHA256, HPKEAES128GCM},
+		"kdf":  {HPKEDHKEMX25519, HPKEKDF(2), HPKEAES128GCM},
+		"aead": {HPKEDHKEMX25519, HPKEHKDFSHA256, HPKEAEAD(4)},
+	} {
+		if _, _, err := s.Seal(pkR, nil, nil, nil, nil); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Errorf("unknown %s: Seal err = %v, want ErrUnsupportedAlgorithm", name, err)
+		}
+		if _, err := s.Open(enc, skR, nil, nil, ct, nil); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Errorf("unknown %s: Open err = %v, want ErrUnsupportedAlgorithm", name, err)
+		}
+	}
+	if _, _, err := (HPKESuite{}).GenerateKeyPair(); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("GenerateKeyPair of zero suite err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+	if got := HPKEAEAD(4).String(); got != "HPKEAEAD(0x0004)" {
+		t.Errorf("String = %q", got)
+	}
+
+	// keys
+	if _, _, err := suite.Seal(pkR[:31], nil, nil, nil, nil); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("short public key err = %v, want ErrInvalidKeySize", err)
+	}
+	if _, _, err := suite.Seal(make([]byte, 32), nil, nil, nil, nil); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("low-order public key err = %v, want ErrUnsupportedKeyType", err)
+	}
+	if _, err := suite.Open(enc, skR[:16], nil, nil, ct, nil); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("short private key err = %v, want ErrInvalidKeySize", err)
+	}
+	if _, err := suite.Open(enc[:31], skR, nil, nil, ct, nil); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("short enc err = %v, want ErrAuthenticationFailed", err)
+	}
+	if _, err := suite.Open(make([]byte, 32), skR, nil, nil, ct, nil); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("low-order enc err = %v, want ErrAuthenticationFailed", err)
+	}
+	p256 := HPKESuite{HPKEDHKEMP256, HPKEHKDFSHA256, HPKEAES128GCM}
+	bad := append([]byte{4}, make([]byte, 64)...)
+	if _, _, err := p256.Seal(bad, nil, nil, nil, nil); !errors.Is(err, ErrUnsupportedKeyType) {
+		t.Errorf("off-curve P-256 key err = %v, want ErrUnsupportedKeyType", err)
+	}
+	if _, _, err := suite.DeriveKeyPair(make([]byte, 31)); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("short ikm err = %v, want ErrInvalidKeySize", err)
+	}
+
+	// mode inputs
+	psk := bytes.Repeat([]byte{7}, 32)
+	for name, opts := range map[string]*HPKEOptions{
+		"pskWithoutID": {PSK: psk},
+		"idWithoutPSK": {PSKID: []byte("id")},
+	} {
+		if _, _, err := suite.Seal(pkR, nil, nil, nil, opts); !errors.Is(err, ErrInvalidParameters) {
+			t.Errorf("%s err = %v, want ErrInvalidParameters", name, err)
+		}
+	}
+	if _, _, err := suite.Seal(pkR, nil, nil, nil, &HPKEOptions{PSK: psk[:16], PSKID: []byte("id")}); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("short PSK err = %v, want ErrInvalidKeySize", err)
+	}
+	if _, _, err := suite.Seal(pkR, nil, nil, nil, &HPKEOptions{SenderPublicKey: pkR}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("SenderPublicKey when sending err = %v, want ErrInvalidParameters", err)
+	}
+	if _, err := suite.Open(enc, skR, nil, nil, ct, &HPKEOptions{SenderPrivateKey: skR}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("SenderPrivateKey when receiving err = %v, want ErrInvalidParameters", err)
+	}
+
+	// the recipient must use the sender's mode
+	pkS, skS, err := suite.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, ct, err = suite.Seal(pkR, nil, nil, []byte("x"), &HPKEOptions{SenderPrivateKey: skS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := suite.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, opts := range map[string]*HPKEOptions{
+		"base":        nil,
+		"otherSender": {SenderPublicKey: otherPub},
+		"authPSK":     {SenderPublicKey: pkS, PSK: psk, PSKID: []byte("id")},
+	} {
+		if _, err := suite.Open(enc, skR, nil, nil, ct, opts); !errors.Is(err, ErrAuthenticationFailed) {
+			t.Errorf("auth-mode box opened as %s: err = %v, want ErrAuthenticationFailed", name, err)
+		}
+	}
+	if _, err := suite.Open(enc, skR, nil, nil, ct, &HPKEOptions{SenderPublicKey: make([]byte, 32)}); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("low-order sender key err = %v, want ErrAuthenticationFailed", err)
+	}
+}
@@ -0,0 +1,237 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Key is a secret symmetric key. Its underlying type is []byte, so a Key is
+// accepted directly by every function in this package that takes a key
+// argument, for example EncryptByteXChacha20poly1305(key, input).
+//
+// A Key never prints its contents: String, GoString, Format, MarshalJSON and
+// LogValue all redact it, so passing one to fmt, encoding/json or log/slog
+// by mistake does not leak it. Use [Key.Bytes] when the raw bytes are really
+// needed, and [Key.Destroy] once the key is no longer in use.
+type Key []byte
+
+// Key sizes in bytes.
+const (
+	// KeySizeAES128 is the key length of AES-128.
+	KeySizeAES128 = 16
+	// KeySizeAES192 is the key length of AES-192.
+	KeySizeAES192 = 24
+	// KeySizeAES256 is the key length of AES-256 and XAES-256-GCM.
+	KeySizeAES256 = 32
+	// KeySizeChacha20 is the key length of ChaCha20-Poly1305 and
+	// XChaCha20-Poly1305.
+	KeySizeChacha20 = 32
+)
+
+// keyRedacted replaces the key material wherever a Key is formatted.
+const keyRedacted = "[REDACTED]"
+
+// validKeySize reports whether n is a key length some cipher in this package
+// accepts: 16, 24 or 32 bytes for AES and ChaCha20, and 48 or 64 bytes for
+// AES-SIV.
+func validKeySize(n int) bool {
+	switch n {
+	case 16, 24, 32, 48, 64:
+		return true
+	}
+	return false
+}
+
+// GenerateKey returns a new random key of the given size, read from
+// crypto/rand. The size must be 16, 24, 32, 48 or 64 bytes.
+func GenerateKey(size int) (Key, error) {
+	if !validKeySize(size) {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidKeySize, size)
+	}
+	k := make(Key, size)
+	if _, err := rand.Read(k); err != nil {
+		return nil, fmt.Errorf("error generating key: %w", err)
+	}
+	return k, nil
+}
+
+// GenerateAES128Key returns a new random 16-byte AES-128 key.
+func GenerateAES128Key() (Key, error) {
+	return GenerateKey(KeySizeAES128)
+}
+
+// GenerateAES192Key returns a new random 24-byte AES-192 key.
+func GenerateAES192Key() (Key, error) {
+	return GenerateKey(KeySizeAES192)
+}
+
+// GenerateAES256Key returns a new random 32-byte AES-256 key.
+func GenerateAES256Key() (Key, error) {
+	return GenerateKey(KeySizeAES256)
+}
+
+// GenerateChacha20poly1305Key returns a new random 32-byte key for
+// ChaCha20-Poly1305 and XChaCha20-Poly1305.
+func GenerateChacha20poly1305Key() (Key, error) {
+	return GenerateKey(KeySizeChacha20)
+}
+
+// KeyFromHex decodes a hex-encoded key. Surrounding whitespace is ignored.
+func KeyFromHex(s string) (Key, error) {
+	return keyFromHex([]byte(s))
+}
+
+// KeyFromBase64 decodes a base64-encoded key in the standard or URL-safe
+// alphabet, padded or not. Surrounding whitespace is ignored.
+func KeyFromBase64(s string) (Key, error) {
+	return keyFromBase64([]byte(s))
+}
+
+// ParseKey decodes a hex or base64 key. Hex is tried first: a string of hex
+// digits whose decoded length is a valid key size is taken as hex, anything
+// else as base64.
+func ParseKey(s string) (Key, error) {
+	return parseKey([]byte(s))
+}
+
+// keyFromHex is KeyFromHex over a byte slice, so that a caller holding the
+// encoded key in a buffer can zero it afterwards.
+func keyFromHex(src []byte) (Key, error) {
+	src = bytes.TrimSpace(src)
+	b := make([]byte, hex.DecodedLen(len(src)))
+	if _, err := hex.Decode(b, src); err != nil {
+		clear(b)
+		return nil, fmt.Errorf("%w: invalid hex: %w", ErrInvalidEncoding, err)
+	}
+	return newKey(b)
+}
+
+// keyFromBase64 is KeyFromBase64 over a byte slice; see keyFromHex.
+func keyFromBase64(src []byte) (Key, error) {
+	src = bytes.TrimRight(bytes.TrimSpace(src), "=")
+	enc := base64.RawStdEncoding
+	if bytes.ContainsAny(src, "-_") {
+		enc = base64.RawURLEncoding
+	}
+	b := make([]byte, enc.DecodedLen(len(src)))
+	n, err := enc.Decode(b, src)
+	if err != nil {
+		clear(b)
+		return nil, fmt.Errorf("%w: invalid base64: %w", ErrInvalidEncoding, err)
+	}
+	return newKey(b[:n])
+}
+
+// parseKey is ParseKey over a byte slice; see keyFromHex.
+func parseKey(src []byte) (Key, error) {
+	if k, err := keyFromHex(src); err == nil {
+		return k, nil
+	}
+	return keyFromBase64(src)
+}
+
+// KeyFromEnv reads a hex- or base64-encoded key from the named environment
+// variable, as described for [ParseKey]. A missing or blank variable wraps
+// [ErrKeyNotFound]; a value that does not decode to a valid key wraps
+// [ErrInvalidEncoding] or [ErrInvalidKeySize].
+func KeyFromEnv(name string) (Key, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok || strings.TrimSpace(v) == "" {
+		return nil, fmt.Errorf("%w: environment variable %s is not set", ErrKeyNotFound, name)
+	}
+	return ParseKey(v)
+}
+
+// KeyFromFile reads a hex- or base64-encoded key from a text file, as
+// described for [ParseKey]. A trailing newline is ignored. The file buffer is
+// zeroed before returning.
+func KeyFromFile(path string) (Key, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file: %w", err)
+	}
+	defer clear(b)
+	return parseKey(b)
+}
+
+// newKey checks the size of decoded key material and returns it as a Key.
+func newKey(b []byte) (Key, error) {
+	if !validKeySize(len(b)) {
+		clear(b)
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidKeySize, len(b))
+	}
+	return Key(b), nil
+}
+
+// Bytes returns the raw key material. The returned slice shares memory with
+// k, so [Key.Destroy] zeroes it as well.
+func (k Key) Bytes() []byte {
+	return []byte(k)
+}
+
+// Len returns the key length in bytes.
+func (k Key) Len() int {
+	return len(k)
+}
+
+// ValidFor reports whether k has a valid length for alg, returning an error
+// wrapping [ErrInvalidKeySize] if it does not.
+func (k Key) ValidFor(alg Algorithm) error {
+	switch alg {
+	case AlgAesGcm:
+		if len(k) == 16 || len(k) == 24 || len(k) == 32 {
+			return nil
+		}
+	case AlgChacha20poly1305, AlgXChacha20poly1305:
+		if len(k) == KeySizeChacha20 {
+			return nil
+		}
+	default:
+		return fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, alg)
+	}
+	return fmt.Errorf("%w: %v does not take a %d-byte key", ErrInvalidKeySize, alg, len(k))
+}
+
+// Destroy zeroes the key material and sets k to nil, so any later use fails
+// with [ErrInvalidKeySize] instead of running with an all-zero key.
+func (k *Key) Destroy() {
+	clear(*k)
+	*k = nil
+}
+
+// String returns a redacted placeholder, never the key material.
+func (k Key) String() string {
+	return keyRedacted
+}
+
+// GoString returns a redacted placeholder for the %#v verb.
+func (k Key) GoString() string {
+	return "crypt.Key" + keyRedacted
+}
+
+// Format implements fmt.Formatter so that every verb, including %x and %q,
+// prints the redacted placeholder.
+func (k Key) Format(f fmt.State, verb rune) {
+	if verb == 'v' && f.Flag('#') {
+		_, _ = f.Write([]byte(k.GoString()))
+		return
+	}
+	_, _ = f.Write([]byte(keyRedacted))
+}
+
+// MarshalJSON encodes the key as the redacted placeholder string.
+func (k Key) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + keyRedacted + `"`), nil
+}
+
+// LogValue implements slog.LogValuer so structured logs show the redacted
+// placeholder.
+func (k Key) LogValue() slog.Value {
+	return slog.StringValue(keyRedacted)
+}
@@ -0,0 +1,214 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	gens := []struct {
+		name string
+		gen  func() (Key, error)
+		size int
+	}{
+		{"AES-128", GenerateAES128Key, 16},
+		{"AES-192", GenerateAES192Key, 24},
+		{"AES-256", GenerateAES256Key, 32},
+		{"ChaCha20", GenerateChacha20poly1305Key, 32},
+	}
+	for _, g := range gens {
+		t.Run(g.name, func(t *testing.T) {
+			k, err := g.gen()
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			if k.Len() != g.size {
+				t.Errorf("len = %d, want %d", k.Len(), g.size)
+			}
+			if bytes.Equal(k, make([]byte, g.size)) {
+				t.Error("generated key is all zeros")
+			}
+		})
+	}
+
+	if _, err := GenerateKey(31); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("GenerateKey(31) err = %v, want ErrInvalidKeySize", err)
+	}
+}
+
+func TestKeyAcceptedByRootFunctions(t *testing.T) {
+	k, err := GenerateChacha20poly1305Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := EncryptByteXChacha20poly1305WithNonceAppended(k, []byte("secret"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	pt, err := DecryptByteXChacha20poly1305WithNonceAppended(k, ct)
+	if err != nil || string(pt) != "secret" {
+		t.Fatalf("decrypt = %q, %v", pt, err)
+	}
+
+	k.Destroy()
+	if k != nil {
+		t.Error("Destroy did not reset the key")
+	}
+	if _, err := EncryptByteXChacha20poly1305WithNonceAppended(k, []byte("x")); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("encrypt with destroyed key err = %v, want ErrInvalidKeySize", err)
+	}
+}
+
+func TestKeyDestroyZeroes(t *testing.T) {
+	k, err := GenerateAES256Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := k.Bytes()
+	k.Destroy()
+	if !bytes.Equal(raw, make([]byte, 32)) {
+		t.Error("Destroy did not zero the backing array")
+	}
+}
+
+func TestKeyRedaction(t *testing.T) {
+	k, err := KeyFromHex(strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := []string{"ab", "AB", "171"} // hex, upper hex, first decimal byte
+
+	outputs := map[string]string{
+		"String":   k.String(),
+		"%v":       fmt.Sprintf("%v", k),
+		"%s":       fmt.Sprintf("%s", k),
+		"%x":       fmt.Sprintf("%x", k),
+		"%X":       fmt.Sprintf("%X", k),
+		"%q":       fmt.Sprintf("%q", k),
+		"%#v":      fmt.Sprintf("%#v", k),
+		"%d":       fmt.Sprintf("%d", k),
+		"struct%v": fmt.Sprintf("%+v", struct{ K Key }{k}),
+	}
+	js, err := json.Marshal(struct{ K Key }{k})
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	outputs["json"] = string(js)
+
+	var logBuf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logBuf, nil)).Info("loaded", "key", k)
+	outputs["slog"] = logBuf.String()
+
+	for name, out := range outputs {
+		if !strings.Contains(out, "REDACTED") {
+			t.Errorf("%s: %q is not redacted", name, out)
+		}
+		for _, s := range secrets {
+			if strings.Contains(out, s) {
+				t.Errorf("%s: %q leaks key material", name, out)
+			}
+		}
+	}
+}
+
+func TestKeyConstructors(t *testing.T) {
+	raw := mustBytes(t, 32)
+	hexKey := hex.EncodeToString(raw)
+
+	encodings := map[string]string{
+		"hex":       hexKey,
+		"hexSpaces": "  " + hexKey + "\n",
+		"std":       base64.StdEncoding.EncodeToString(raw),
+		"rawStd":    base64.RawStdEncoding.EncodeToString(raw),
+		"url":       base64.URLEncoding.EncodeToString(raw),
+		"rawURL":    base64.RawURLEncoding.EncodeToString(raw),
+	}
+	for name, s := range encodings {
+		t.Run(name, func(t *testing.T) {
+			k, err := ParseKey(s)
+			if err != nil {
+				t.Fatalf("ParseKey: %v", err)
+			}
+			if !bytes.Equal(k, raw) {
+				t.Error("decoded key mismatch")
+			}
+		})
+	}
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("CRYPT_TEST_KEY", hexKey)
+		k, err := KeyFromEnv("CRYPT_TEST_KEY")
+		if err != nil || !bytes.Equal(k, raw) {
+			t.Fatalf("KeyFromEnv = %v, %v", k, err)
+		}
+		if _, err := KeyFromEnv("CRYPT_TEST_KEY_UNSET"); !errors.Is(err, ErrKeyNotFound) || errors.Is(err, ErrInvalidKeySize) {
+			t.Errorf("unset variable err = %v, want ErrKeyNotFound", err)
+		}
+		t.Setenv("CRYPT_TEST_KEY_BLANK", " \n")
+		if _, err := KeyFromEnv("CRYPT_TEST_KEY_BLANK"); !errors.Is(err, ErrKeyNotFound) {
+			t.Errorf("blank variable err = %v, want ErrKeyNotFound", err)
+		}
+		t.Setenv("CRYPT_TEST_KEY_SHORT", hexKey[:30])
+		if _, err := KeyFromEnv("CRYPT_TEST_KEY_SHORT"); !errors.Is(err, ErrInvalidKeySize) {
+			t.Errorf("short key err = %v, want ErrInvalidKeySize", err)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "key")
+		if err := os.WriteFile(path, []byte(encodings["std"]+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		k, err := KeyFromFile(path)
+		if err != nil || !bytes.Equal(k, raw) {
+			t.Fatalf("KeyFromFile = %v, %v", k, err)
+		}
+		if _, err := KeyFromFile(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Error("missing file succeeded, want error")
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, s := range []string{"", "abcd", hexKey[:62], strings.Repeat("A", 42)} {
+			if _, err := ParseKey(s); !errors.Is(err, ErrInvalidKeySize) {
+				t.Errorf("ParseKey(%q) err = %v, want ErrInvalidKeySize", s, err)
+			}
+		}
+		for _, s := range []string{"not base64!", hexKey[:62] + "%%"} {
+			if _, err := ParseKey(s); !errors.Is(err, ErrInvalidEncoding) || errors.Is(err, ErrInvalidKeySize) {
+				t.Errorf("ParseKey(%q) err = %v, want ErrInvalidEncoding", s, err)
+			}
+		}
+		if _, err := KeyFromHex("zz" + hexKey[2:]); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("KeyFromHex(bad digit) err = %v, want ErrInvalidEncoding", err)
+		}
+		if _, err := KeyFromBase64(hexKey + "!"); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("KeyFromBase64(bad character) err = %v, want ErrInvalidEncoding", err)
+		}
+	})
+}
+
+func TestKeyValidFor(t *testing.T) {
+	aes128 := make(Key, 16)
+	if err := aes128.ValidFor(AlgAesGcm); err != nil {
+		t.Errorf("AES-128 key for AES-GCM: %v", err)
+	}
+	if err := aes128.ValidFor(AlgXChacha20poly1305); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("AES-128 key for XChaCha20 err = %v, want ErrInvalidKeySize", err)
+	}
+	if err := make(Key, 32).ValidFor(AlgChacha20poly1305); err != nil {
+		t.Errorf("32-byte key for ChaCha20: %v", err)
+	}
+	if err := aes128.ValidFor(Algorithm(0x7F)); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("unknown algorithm err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+}
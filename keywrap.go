@@ -0,0 +1,226 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+)
+
+// Constants of AES Key Wrap (RFC 3394) and AES Key Wrap with Padding
+// (RFC 5649).
+const (
+	// keyWrapSemiblock is the 64-bit unit both algorithms operate on.
+	keyWrapSemiblock = 8
+
+	// keyWrapMaxSize caps the key data accepted by the padded variant, whose
+	// message length indicator is 32 bits wide.
+	keyWrapMaxSize = 1<<32 - 1
+)
+
+var (
+	// keyWrapIV is the default initial value of RFC 3394 section 2.2.3.1.
+	keyWrapIV = [keyWrapSemiblock]byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+	// keyWrapPadIV is the constant half of the RFC 5649 alternative initial
+	// value; the other half is the 32-bit length of the key data.
+	keyWrapPadIV = [4]byte{0xA6, 0x59, 0x59, 0xA6}
+)
+
+// keyWrapCipher builds the AES block cipher for a 128, 192 or 256-bit KEK.
+func keyWrapCipher(kek []byte) (cipher.Block, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
+	}
+	return block, nil
+}
+
+// wrap runs the wrapping process W of RFC 3394 section 2.2.1 (index-based
+// form) with initial value iv over plaintext, which must be at least two
+// semiblocks long and a multiple of the semiblock size.
+func wrap(block cipher.Block, iv [keyWrapSemiblock]byte, plaintext []byte) []byte {
+	n := len(plaintext) / keyWrapSemiblock
+	out := make([]byte, keyWrapSemiblock+len(plaintext))
+	copy(out[keyWrapSemiblock:], plaintext)
+
+	var b [aes.BlockSize]byte
+	copy(b[:keyWrapSemiblock], iv[:])
+	for j := range 6 {
+		for i := 1; i <= n; i++ {
+			r := out[i*keyWrapSemiblock : (i+1)*keyWrapSemiblock]
+			copy(b[keyWrapSemiblock:], r)
+			block.Encrypt(b[:], b[:])
+
+			// A = MSB(64, B) ^ t where t = n*j + i
+			a := binary.BigEndian.Uint64(b[:keyWrapSemiblock]) ^ uint64(n*j+i) // #nosec G115 -- n*j+i > 0
+			binary.BigEndian.PutUint64(b[:keyWrapSemiblock], a)
+			copy(r, b[keyWrapSemiblock:])
+		}
+	}
+	copy(out[:keyWrapSemiblock], b[:keyWrapSemiblock])
+	clear(b[:])
+	return out
+}
+
+// unwrap runs the unwrapping process W^-1 of RFC 3394 section 2.2.2 over
+// ciphertext (at least three semiblocks, a multiple of the semiblock size)
+// and returns the recovered initial value and key data. The caller checks
+// the initial value.
+func unwrap(block cipher.Block, ciphertext []byte) (iv [keyWrapSemiblock]byte, plaintext []byte) {
+	n := len(ciphertext)/keyWrapSemiblock - 1
+	plaintext = make([]byte, n*keyWrapSemiblock)
+	copy(plaintext, ciphertext[keyWrapSemiblock:])
+
+	var b [aes.BlockSize]byte
+	copy(b[:keyWrapSemiblock], ciphertext[:keyWrapSemiblock])
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			r := plaintext[(i-1)*keyWrapSemiblock : i*keyWrapSemiblock]
+			a := binary.BigEndian.Uint64(b[:keyWrapSemiblock]) ^ uint64(n*j+i) // #nosec G115 -- n*j+i > 0
+			binary.BigEndian.PutUint64(b[:keyWrapSemiblock], a)
+			copy(b[keyWrapSemiblock:], r)
+			block.Decrypt(b[:], b[:])
+			copy(r, b[keyWrapSemiblock:])
+		}
+	}
+	copy(iv[:], b[:keyWrapSemiblock])
+	clear(b[:])
+	return iv, plaintext
+}
+
+// WrapKeyAES wraps key data with AES Key Wrap (RFC 3394, NIST SP 800-38F
+// "KW") under the given 128, 192 or 256-bit key-encryption key. The key data
+// must be at least 16 bytes and a multiple of 8; use WrapKeyAESWithPadding for
+// any other length. The output is 8 bytes longer than the input and
+// byte-for-byte compatible with other implementations, such as JWE "A256KW",
+// CMS and HSM or cloud KMS key-import flows.
+//
+// Key wrapping is deterministic and intended for high-entropy key material
+// only; use an AEAD for anything else.
+func WrapKeyAES(kek, key []byte) (wrapped []byte, err error) {
+	if len(key) < 2*keyWrapSemiblock || len(key)%keyWrapSemiblock != 0 {
+		err = fmt.Errorf("%w: AES key wrap takes a multiple of 8 bytes, at least 16", ErrInvalidKeySize)
+		return
+	}
+
+	block, err := keyWrapCipher(kek)
+	if err != nil {
+		return
+	}
+
+	wrapped = wrap(block, keyWrapIV, key)
+	return
+}
+
+// UnwrapKeyAES unwraps key data produced by WrapKeyAES (or any RFC 3394
+// implementation) under the given 128, 192 or 256-bit key-encryption key. A
+// non-nil error means the KEK is wrong or the wrapped key was tampered with;
+// no key data is returned in that case.
+func UnwrapKeyAES(kek, wrapped []byte) (key []byte, err error) {
+	if len(wrapped) < 3*keyWrapSemiblock || len(wrapped)%keyWrapSemiblock != 0 {
+		err = ErrMalformedCiphertext
+		return
+	}
+
+	block, err := keyWrapCipher(kek)
+	if err != nil {
+		return
+	}
+
+	iv, key := unwrap(block, wrapped)
+	if subtle.ConstantTimeCompare(iv[:], keyWrapIV[:]) != 1 {
+		clear(key)
+		key = nil
+		err = ErrAuthenticationFailed
+		return
+	}
+
+	return
+}
+
+// WrapKeyAESWithPadding wraps key data of any length from 1 byte to 4 GiB with
+// AES Key Wrap with Padding (RFC 5649, NIST SP 800-38F "KWP") under the given
+// 128, 192 or 256-bit key-encryption key. The output is the key data rounded up
+// to a multiple of 8 bytes, plus 8.
+func WrapKeyAESWithPadding(kek, key []byte) (wrapped []byte, err error) {
+	if len(key) == 0 || uint64(len(key)) > keyWrapMaxSize {
+		err = fmt.Errorf("%w: AES key wrap with padding takes 1 byte to 4 GiB", ErrInvalidKeySize)
+		return
+	}
+
+	block, err := keyWrapCipher(kek)
+	if err != nil {
+		return
+	}
+
+	// alternative initial value: 0xA65959A6 || 32-bit message length
+	var iv [keyWrapSemiblock]byte
+	copy(iv[:4], keyWrapPadIV[:])
+	binary.BigEndian.PutUint32(iv[4:], uint32(len(key))) // #nosec G115 -- bounded above
+
+	padded := make([]byte, (len(key)+keyWrapSemiblock-1)/keyWrapSemiblock*keyWrapSemiblock)
+	copy(padded, key)
+	defer clear(padded)
+
+	// a single padded semiblock is encrypted as one AES block instead
+	if len(padded) == keyWrapSemiblock {
+		wrapped = make([]byte, aes.BlockSize)
+		copy(wrapped, iv[:])
+		copy(wrapped[keyWrapSemiblock:], padded)
+		block.Encrypt(wrapped, wrapped)
+		return
+	}
+
+	wrapped = wrap(block, iv, padded)
+	return
+}
+
+// UnwrapKeyAESWithPadding unwraps key data produced by WrapKeyAESWithPadding
+// (or any RFC 5649 implementation) under the given 128, 192 or 256-bit
+// key-encryption key. A non-nil error means the KEK is wrong or the wrapped
+// key was tampered with; no key data is returned in that case.
+func UnwrapKeyAESWithPadding(kek, wrapped []byte) (key []byte, err error) {
+	if len(wrapped) < 2*keyWrapSemiblock || len(wrapped)%keyWrapSemiblock != 0 {
+		err = ErrMalformedCiphertext
+		return
+	}
+
+	block, err := keyWrapCipher(kek)
+	if err != nil {
+		return
+	}
+
+	var iv [keyWrapSemiblock]byte
+	var padded []byte
+	if len(wrapped) == aes.BlockSize {
+		b := make([]byte, aes.BlockSize)
+		block.Decrypt(b, wrapped)
+		copy(iv[:], b[:keyWrapSemiblock])
+		padded = b[keyWrapSemiblock:]
+	} else {
+		iv, padded = unwrap(block, wrapped)
+	}
+
+	// the length must fall in the last semiblock and every padding byte
+	// must be zero
+	mli := uint64(binary.BigEndian.Uint32(iv[4:]))
+	ok := subtle.ConstantTimeCompare(iv[:4], keyWrapPadIV[:]) == 1 &&
+		mli > uint64(len(padded)-keyWrapSemiblock) && mli <= uint64(len(padded))
+	if ok {
+		var pad byte
+		for _, c := range padded[mli:] {
+			pad |= c
+		}
+		ok = pad == 0
+	}
+	if !ok {
+		clear(padded)
+		err = ErrAuthenticationFailed
+		return
+	}
+
+	key = padded[:mli:mli]
+	return
+}
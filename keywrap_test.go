@@ -0,0 +1,216 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrapKeyAESKnownAnswer(t *testing.T) {
+	// RFC 3394 section 4
+	vectors := []struct {
+		name, kek, key, wrapped string
+	}{
+		{
+			name:    "128-bit key, 128-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f",
+			key:     "00112233445566778899aabbccddeeff",
+			wrapped: "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5",
+		},
+		{
+			name:    "128-bit key, 192-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f1011121314151617",
+			key:     "00112233445566778899aabbccddeeff",
+			wrapped: "96778b25ae6ca435f92b5b97c050aed2468ab8a17ad84e5d",
+		},
+		{
+			name:    "128-bit key, 256-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			key:     "00112233445566778899aabbccddeeff",
+			wrapped: "64e8c3f9ce0f5ba263e9777905818a2a93c8191e7d6e8ae7",
+		},
+		{
+			name:    "256-bit key, 256-bit KEK",
+			kek:     "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			key:     "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f",
+			wrapped: "28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21",
+		},
+	}
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			kek, key, want := mustHex(t, v.kek), mustHex(t, v.key), mustHex(t, v.wrapped)
+
+			got, err := WrapKeyAES(kek, key)
+			if err != nil {
+				t.Fatalf("WrapKeyAES: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("WrapKeyAES = %x, want %x", got, want)
+			}
+
+			unwrapped, err := UnwrapKeyAES(kek, want)
+			if err != nil {
+				t.Fatalf("UnwrapKeyAES: %v", err)
+			}
+			if !bytes.Equal(unwrapped, key) {
+				t.Errorf("UnwrapKeyAES = %x, want %x", unwrapped, key)
+			}
+		})
+	}
+}
+
+func TestWrapKeyAESWithPaddingKnownAnswer(t *testing.T) {
+	// RFC 5649 section 6
+	kek := mustHex(t, "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	vectors := []struct {
+		name, key, wrapped string
+	}{
+		{
+			name:    "20 bytes",
+			key:     "c37b7e6492584340bed12207808941155068f738",
+			wrapped: "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		},
+		{
+			name:    "7 bytes",
+			key:     "466f7250617369",
+			wrapped: "afbeb0f07dfbf5419200f2ccb50bb24f",
+		},
+	}
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			key, want := mustHex(t, v.key), mustHex(t, v.wrapped)
+
+			got, err := WrapKeyAESWithPadding(kek, key)
+			if err != nil {
+				t.Fatalf("WrapKeyAESWithPadding: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("WrapKeyAESWithPadding = %x, want %x", got, want)
+			}
+
+			unwrapped, err := UnwrapKeyAESWithPadding(kek, want)
+			if err != nil {
+				t.Fatalf("UnwrapKeyAESWithPadding: %v", err)
+			}
+			if !bytes.Equal(unwrapped, key) {
+				t.Errorf("UnwrapKeyAESWithPadding = %x, want %x", unwrapped, key)
+			}
+		})
+	}
+}
+
+func TestWrapKeyAESWithPaddingRoundTrip(t *testing.T) {
+	kek := mustBytes(t, 32)
+
+	for _, n := range []int{1, 8, 9, 16, 31, 32, 33, 64} {
+		key := mustBytes(t, n)
+		wrapped, err := WrapKeyAESWithPadding(kek, key)
+		if err != nil {
+			t.Fatalf("WrapKeyAESWithPadding(%d bytes): %v", n, err)
+		}
+		if want := (n+7)/8*8 + 8; len(wrapped) != want {
+			t.Errorf("%d bytes: wrapped len = %d, want %d", n, len(wrapped), want)
+		}
+		got, err := UnwrapKeyAESWithPadding(kek, wrapped)
+		if err != nil {
+			t.Fatalf("UnwrapKeyAESWithPadding(%d bytes): %v", n, err)
+		}
+		if !bytes.Equal(got, key) {
+			t.Errorf("%d bytes: round-trip mismatch", n)
+		}
+	}
+}
+
+func TestWrapKeyAESErrors(t *testing.T) {
+	kek := mustBytes(t, 32)
+	key := mustBytes(t, 32)
+
+	t.Run("invalidKEK", func(t *testing.T) {
+		if _, err := WrapKeyAES([]byte("too-short"), key); err == nil {
+			t.Error("expected error for invalid KEK size, got nil")
+		}
+		if _, err := WrapKeyAESWithPadding([]byte("too-short"), key); err == nil {
+			t.Error("expected error for invalid KEK size, got nil")
+		}
+	})
+
+	t.Run("invalidKeyData", func(t *testing.T) {
+		// KW needs at least two semiblocks of whole-semiblock data
+		for _, n := range []int{0, 8, 15, 17} {
+			if _, err := WrapKeyAES(kek, make([]byte, n)); err == nil {
+				t.Errorf("WrapKeyAES(%d bytes) succeeded, want error", n)
+			}
+		}
+		if _, err := WrapKeyAESWithPadding(kek, nil); err == nil {
+			t.Error("WrapKeyAESWithPadding(empty) succeeded, want error")
+		}
+	})
+
+	t.Run("wrongKEK", func(t *testing.T) {
+		wrapped, err := WrapKeyAES(kek, key)
+		if err != nil {
+			t.Fatalf("wrap: %v", err)
+		}
+		if _, err := UnwrapKeyAES(mustBytes(t, 32), wrapped); err == nil {
+			t.Error("unwrap with wrong KEK succeeded, want failure")
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		for _, fn := range []struct {
+			name   string
+			wrap   func(kek, key []byte) ([]byte, error)
+			unwrap func(kek, wrapped []byte) ([]byte, error)
+			key    []byte
+		}{
+			{"KW", WrapKeyAES, UnwrapKeyAES, key},
+			{"KWP", WrapKeyAESWithPadding, UnwrapKeyAESWithPadding, key[:20]},
+			{"KWPSingleBlock", WrapKeyAESWithPadding, UnwrapKeyAESWithPadding, key[:5]},
+		} {
+			wrapped, err := fn.wrap(kek, fn.key)
+			if err != nil {
+				t.Fatalf("%s wrap: %v", fn.name, err)
+			}
+			for i := range wrapped {
+				bad := bytes.Clone(wrapped)
+				bad[i] ^= 0x01
+				if _, err := fn.unwrap(kek, bad); err == nil {
+					t.Fatalf("%s: unwrap with byte %d flipped succeeded, want failure", fn.name, i)
+				}
+			}
+		}
+	})
+
+	t.Run("crossVariant", func(t *testing.T) {
+		// the two variants use different initial values and never accept
+		// each other's output
+		wrapped, err := WrapKeyAES(kek, key)
+		if err != nil {
+			t.Fatalf("wrap: %v", err)
+		}
+		if _, err := UnwrapKeyAESWithPadding(kek, wrapped); err == nil {
+			t.Error("KWP unwrap of a KW blob succeeded, want failure")
+		}
+		wrapped, err = WrapKeyAESWithPadding(kek, key)
+		if err != nil {
+			t.Fatalf("wrap: %v", err)
+		}
+		if _, err := UnwrapKeyAES(kek, wrapped); err == nil {
+			t.Error("KW unwrap of a KWP blob succeeded, want failure")
+		}
+	})
+
+	t.Run("invalidLength", func(t *testing.T) {
+		for _, n := range []int{0, 8, 16, 25} {
+			if _, err := UnwrapKeyAES(kek, make([]byte, n)); err == nil {
+				t.Errorf("UnwrapKeyAES(%d bytes) succeeded, want error", n)
+			}
+		}
+		for _, n := range []int{0, 8, 17} {
+			if _, err := UnwrapKeyAESWithPadding(kek, make([]byte, n)); err == nil {
+				t.Errorf("UnwrapKeyAESWithPadding(%d bytes) succeeded, want error", n)
+			}
+		}
+	})
+}
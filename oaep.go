@@ -0,0 +1,80 @@
+//go:build !go1.26
+
+package crypt
+
+// EME-OAEP encoding (RFC 8017, Section 7.1.1) with an MGF1 hash that differs
+// from the label hash. crypto/rsa decrypts such ciphertexts through
+// rsa.OAEPOptions, but before Go 1.26 it cannot produce them, and that is the
+// combination Java's default OAEP provider and several cloud KMS products
+// use (SHA-256 with SHA-1 MGF1). Everything else goes through
+// rsa.EncryptOAEP, and on Go 1.26 and later this case does too (see
+// oaep_go126.go).
+//
+//	DB = Hash(label) || 00...00 || 01 || message
+//	maskedDB   = DB   XOR MGF1(seed, len(DB))
+//	maskedSeed = seed XOR MGF1(maskedDB, hLen)
+//	EM = 00 || maskedSeed || maskedDB
+//	ciphertext = EM^e mod n
+//
+// Only the public-key operation runs here, on a key that passed the same
+// checks crypto/rsa applies (checkRSAPublicKey in rsa.go). It uses math/big,
+// which is not constant time in the message; build with Go 1.26 or later to
+// avoid that. Decryption, which handles the private key, stays in crypto/rsa.
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"math/big"
+)
+
+// encryptOAEPMGF1 encrypts msg for pub with RSA-OAEP, hashing the label with
+// opts.Hash and masking with MGF1 over opts.MGFHash.
+func encryptOAEPMGF1(random io.Reader, pub *rsa.PublicKey, opts *rsa.OAEPOptions, msg []byte) ([]byte, error) {
+	if err := checkRSAPublicKey(pub); err != nil {
+		return nil, err
+	}
+	k := pub.Size()
+	hLen := opts.Hash.Size()
+	if len(msg) > k-2*hLen-2 {
+		return nil, rsa.ErrMessageTooLong
+	}
+
+	em := make([]byte, k)
+	seed := em[1 : 1+hLen]
+	db := em[1+hLen:]
+
+	h := opts.Hash.New()
+	h.Write(opts.Label)
+	h.Sum(db[:0])
+	db[len(db)-len(msg)-1] = 0x01
+	copy(db[len(db)-len(msg):], msg)
+
+	if _, err := io.ReadFull(random, seed); err != nil {
+		return nil, err
+	}
+	mgf1XOR(db, opts.MGFHash, seed)
+	mgf1XOR(seed, opts.MGFHash, db)
+
+	m := new(big.Int).SetBytes(em)
+	c := m.Exp(m, big.NewInt(int64(pub.E)), pub.N)
+	return c.FillBytes(em), nil
+}
+
+// mgf1XOR XORs out with MGF1(seed, len(out)) over hash.
+func mgf1XOR(out []byte, hash crypto.Hash, seed []byte) {
+	h := hash.New()
+	var counter [4]byte
+	var digest []byte
+	for done := 0; done < len(out); {
+		h.Reset()
+		h.Write(seed)
+		h.Write(counter[:])
+		digest = h.Sum(digest[:0])
+
+		done += subtle.XORBytes(out[done:], out[done:], digest)
+		binary.BigEndian.PutUint32(counter[:], binary.BigEndian.Uint32(counter[:])+1)
+	}
+}
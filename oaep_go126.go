@@ -0,0 +1,15 @@
+//go:build go1.26
+
+package crypt
+
+import (
+	"crypto/rsa"
+	"io"
+)
+
+// encryptOAEPMGF1 encrypts msg for pub with RSA-OAEP, hashing the label with
+// opts.Hash and masking with MGF1 over opts.MGFHash. Since Go 1.26 crypto/rsa
+// produces such ciphertexts itself; see oaep.go for older toolchains.
+func encryptOAEPMGF1(random io.Reader, pub *rsa.PublicKey, opts *rsa.OAEPOptions, msg []byte) ([]byte, error) {
+	return rsa.EncryptOAEPWithOptions(random, pub, msg, opts)
+}
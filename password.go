@@ -0,0 +1,326 @@
+package crypt
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Password-based encryption.
+//
+// The blob is self-describing, so the KDF parameters can be raised over time
+// without breaking data sealed under the old ones:
+//
+//	┌─────────┬─────┬────────────┬──────────┬─────────┬─────────┬──────┬──────────────────────────────┐
+//	│ version │ kdf │ memory KiB │ time     │ threads │ saltLen │ salt │ commitment||nonce||AEAD      │
+//	│ 1 byte  │ 1   │ 4 (BE)     │ 4 (BE)   │ 1       │ 1       │ 16   │ 32 + 24 + ciphertext + 16    │
+//	└─────────┴─────┴────────────┴──────────┴─────────┴─────────┴──────┴──────────────────────────────┘
+//
+// The key is Argon2id(password, salt, params) and the payload is the
+// key-committing XChaCha20-Poly1305 layout of
+// [EncryptByteXChacha20poly1305CommittingAAD], with the whole header as AAD.
+// Key commitment matters here: a password is exactly the kind of key an
+// attacker can make the decrypting side try many of.
+
+const (
+	// passwordVersion tags the password blob format.
+	passwordVersion byte = 0x01
+
+	// passwordKDFArgon2id identifies Argon2id (RFC 9106) as the KDF.
+	passwordKDFArgon2id byte = 0x01
+
+	// passwordSaltSize is the length of the random per-blob salt.
+	passwordSaltSize = 16
+
+	// passwordKeySize is the derived XChaCha20-Poly1305 key length.
+	passwordKeySize = 32
+
+	// passwordHeaderSize is version(1) || kdf(1) || memory(4) || time(4) ||
+	// threads(1) || saltLen(1) || salt.
+	passwordHeaderSize = 12 + passwordSaltSize
+)
+
+// Bounds on the Argon2id parameters. They are enforced when sealing and,
+// more importantly, when opening: the parameters are read from the blob, so
+// without a ceiling an attacker-supplied blob could demand any amount of
+// memory or time. [PasswordLimits] tightens them further when opening.
+const (
+	// MaxPasswordMemory is the largest accepted Argon2id memory cost, in KiB
+	// (1 GiB).
+	MaxPasswordMemory = 1 << 20
+
+	// MaxPasswordTime is the largest accepted Argon2id time cost (passes).
+	MaxPasswordTime = 10
+
+	// MaxPasswordThreads is the largest accepted Argon2id parallelism.
+	MaxPasswordThreads = 16
+
+	// MinPasswordMemory is the smallest accepted Argon2id memory cost, in KiB
+	// (19 MiB, the OWASP minimum for Argon2id).
+	MinPasswordMemory = 19 << 10
+)
+
+// PasswordLimits caps the Argon2id parameters a blob may demand when it is
+// opened with [DecryptByteWithPasswordLimits]. A zero field means the
+// package maximum ([MaxPasswordMemory], [MaxPasswordTime] or
+// [MaxPasswordThreads]); a larger value is clamped to it, so limits can only
+// tighten the bounds. Set them to what your own blobs use, so that a forged
+// blob cannot make a server spend more than a legitimate login does.
+type PasswordLimits struct {
+	// MaxMemory is the largest accepted memory cost in KiB.
+	MaxMemory uint32
+	// MaxTime is the largest accepted number of passes.
+	MaxTime uint32
+	// MaxThreads is the largest accepted degree of parallelism.
+	MaxThreads uint8
+}
+
+// check reports whether p stays within l.
+func (l PasswordLimits) check(p PasswordParams) error {
+	maxMemory, maxTime, maxThreads := uint32(MaxPasswordMemory), uint32(MaxPasswordTime), uint8(MaxPasswordThreads)
+	if l.MaxMemory != 0 {
+		maxMemory = min(l.MaxMemory, maxMemory)
+	}
+	if l.MaxTime != 0 {
+		maxTime = min(l.MaxTime, maxTime)
+	}
+	if l.MaxThreads != 0 {
+		maxThreads = min(l.MaxThreads, maxThreads)
+	}
+	if p.Memory > maxMemory {
+		return fmt.Errorf("%w: argon2id memory %d KiB above the limit of %d KiB", ErrInvalidParameters, p.Memory, maxMemory)
+	}
+	if p.Time > maxTime {
+		return fmt.Errorf("%w: argon2id time %d above the limit of %d", ErrInvalidParameters, p.Time, maxTime)
+	}
+	if p.Threads > maxThreads {
+		return fmt.Errorf("%w: argon2id threads %d above the limit of %d", ErrInvalidParameters, p.Threads, maxThreads)
+	}
+	return nil
+}
+
+// PasswordParams are the Argon2id cost parameters of a password blob.
+type PasswordParams struct {
+	// Memory is the memory cost in KiB.
+	Memory uint32
+	// Time is the number of passes over the memory.
+	Time uint32
+	// Threads is the degree of parallelism.
+	Threads uint8
+}
+
+// DefaultPasswordParams returns the parameters used by
+// [EncryptByteWithPassword]: the second recommended option of RFC 9106
+// (64 MiB, 3 passes, 4 lanes), which takes a fraction of a second on current
+// hardware.
+func DefaultPasswordParams() PasswordParams {
+	return PasswordParams{Memory: 64 << 10, Time: 3, Threads: 4}
+}
+
+// validate reports whether p is within the accepted bounds.
+func (p PasswordParams) validate() error {
+	if p.Memory < MinPasswordMemory || p.Memory > MaxPasswordMemory {
+		return fmt.Errorf("%w: argon2id memory %d KiB out of range", ErrInvalidParameters, p.Memory)
+	}
+	if p.Time < 1 || p.Time > MaxPasswordTime {
+		return fmt.Errorf("%w: argon2id time %d out of range", ErrInvalidParameters, p.Time)
+	}
+	if p.Threads < 1 || p.Threads > MaxPasswordThreads {
+		return fmt.Errorf("%w: argon2id threads %d out of range", ErrInvalidParameters, p.Threads)
+	}
+	return nil
+}
+
+// AtLeast reports whether p is at least as costly as q in every parameter.
+func (p PasswordParams) AtLeast(q PasswordParams) bool {
+	return p.Memory >= q.Memory && p.Time >= q.Time && p.Threads >= q.Threads
+}
+
+// buildPasswordHeader assembles the cleartext header of a password blob.
+func buildPasswordHeader(params PasswordParams, salt []byte) []byte {
+	header := make([]byte, passwordHeaderSize)
+	header[0] = passwordVersion
+	header[1] = passwordKDFArgon2id
+	binary.BigEndian.PutUint32(header[2:], params.Memory)
+	binary.BigEndian.PutUint32(header[6:], params.Time)
+	header[10] = params.Threads
+	header[11] = passwordSaltSize
+	copy(header[12:], salt)
+	return header
+}
+
+// parsePasswordHeader splits a password blob into its header, parameters,
+// salt and payload, rejecting unknown versions or KDFs and out-of-range
+// parameters before any key derivation runs.
+func parsePasswordHeader(blob []byte) (header []byte, params PasswordParams, salt, payload []byte, err error) {
+	if len(blob) < passwordHeaderSize {
+		err = ErrCiphertextTooShort
+		return
+	}
+	if blob[0] != passwordVersion {
+		err = fmt.Errorf("%w: password blob version %d", ErrUnsupportedVersion, blob[0])
+		return
+	}
+	if blob[1] != passwordKDFArgon2id {
+		err = fmt.Errorf("%w: password KDF %d", ErrUnsupportedAlgorithm, blob[1])
+		return
+	}
+	if blob[11] != passwordSaltSize {
+		err = ErrMalformedCiphertext
+		return
+	}
+
+	params = PasswordParams{
+		Memory:  binary.BigEndian.Uint32(blob[2:]),
+		Time:    binary.BigEndian.Uint32(blob[6:]),
+		Threads: blob[10],
+	}
+	if err = params.validate(); err != nil {
+		return
+	}
+
+	header = blob[:passwordHeaderSize]
+	salt = header[12:]
+	payload = blob[passwordHeaderSize:]
+	return
+}
+
+// argon2IDFunc has the signature of argon2.IDKey.
+type argon2IDFunc func(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte
+
+// passwordKey derives the encryption key from the password with idKey,
+// which is argon2.IDKey outside of tests.
+func passwordKey(idKey argon2IDFunc, password, salt []byte, params PasswordParams) []byte {
+	return idKey(password, salt, params.Time, params.Memory, params.Threads, passwordKeySize)
+}
+
+// EncryptByteWithPasswordParams encrypts and authenticates the given message
+// (bytes) under a key derived from password with Argon2id using params, and
+// returns a self-describing blob that records the KDF, its parameters and the
+// salt, so [DecryptByteWithPassword] needs nothing but the password.
+func EncryptByteWithPasswordParams(password, input []byte, params PasswordParams) (ciphertext []byte, err error) {
+	if err = params.validate(); err != nil {
+		return
+	}
+
+	salt := make([]byte, passwordSaltSize)
+	_, err = rand.Read(salt)
+	if err != nil {
+		err = fmt.Errorf("error generating salt: %w", err)
+		return
+	}
+
+	header := buildPasswordHeader(params, salt)
+	key := passwordKey(argon2.IDKey, password, salt, params)
+	defer clear(key)
+
+	payload, err := encryptCommitting(AlgXChacha20poly1305, key, input, header)
+	if err != nil {
+		return
+	}
+
+	ciphertext = append(header, payload...)
+	return
+}
+
+// EncryptByteWithPassword encrypts and authenticates the given message (bytes)
+// under a key derived from password with Argon2id using
+// [DefaultPasswordParams].
+func EncryptByteWithPassword(password, input []byte) (ciphertext []byte, err error) {
+	return EncryptByteWithPasswordParams(password, input, DefaultPasswordParams())
+}
+
+// EncryptWithPassword encrypts and authenticates the given message (string)
+// under a key derived from password with Argon2id using
+// [DefaultPasswordParams].
+func EncryptWithPassword(password []byte, text string) (ciphertext []byte, err error) {
+	return EncryptByteWithPassword(password, []byte(text))
+}
+
+// DecryptByteWithPassword decrypts and authenticates a blob produced by
+// [EncryptByteWithPassword] or [EncryptByteWithPasswordParams], re-deriving
+// the key with the parameters stored in the blob. Parameters outside
+// [MinPasswordMemory]..[MaxPasswordMemory], or above [MaxPasswordTime] or
+// [MaxPasswordThreads], are rejected before any work is done. A wrong
+// password fails authentication.
+func DecryptByteWithPassword(password, ciphertext []byte) (plaintext []byte, err error) {
+	return DecryptByteWithPasswordLimits(password, ciphertext, PasswordLimits{})
+}
+
+// DecryptByteWithPasswordLimits is [DecryptByteWithPassword] with tighter
+// bounds on the parameters a blob may demand. A blob above limits is rejected
+// before any key derivation runs.
+func DecryptByteWithPasswordLimits(password, ciphertext []byte, limits PasswordLimits) (plaintext []byte, err error) {
+	return decryptWithPasswordLimits(argon2.IDKey, password, ciphertext, limits)
+}
+
+// decryptWithPasswordLimits is [DecryptByteWithPasswordLimits] deriving the
+// key with idKey.
+func decryptWithPasswordLimits(idKey argon2IDFunc, password, ciphertext []byte, limits PasswordLimits) (plaintext []byte, err error) {
+	header, params, salt, payload, err := parsePasswordHeader(ciphertext)
+	if err != nil {
+		return
+	}
+	if err = limits.check(params); err != nil {
+		return
+	}
+
+	key := passwordKey(idKey, password, salt, params)
+	defer clear(key)
+
+	return decryptCommitting(AlgXChacha20poly1305, key, payload, header)
+}
+
+// DecryptWithPassword decrypts and authenticates a blob produced by
+// [EncryptWithPassword]; see [DecryptByteWithPassword].
+func DecryptWithPassword(password, ciphertext []byte) (text string, err error) {
+	plaintext, err := DecryptByteWithPassword(password, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// PasswordBlobParams reports the Argon2id parameters a password blob was
+// sealed with, without deriving any key. Nothing is authenticated until the
+// blob is decrypted.
+func PasswordBlobParams(ciphertext []byte) (params PasswordParams, err error) {
+	_, params, _, _, err = parsePasswordHeader(ciphertext)
+	return
+}
+
+// ResealWithPassword upgrades a password blob to params. If the blob was
+// already sealed with parameters at least as strong in every dimension, it is
+// returned unchanged with resealed == false. Otherwise it is decrypted and
+// sealed again under a fresh salt with params. Call it after a successful
+// login to migrate stored data as the defaults grow.
+func ResealWithPassword(password, ciphertext []byte, params PasswordParams) (out []byte, resealed bool, err error) {
+	if err = params.validate(); err != nil {
+		return
+	}
+
+	current, err := PasswordBlobParams(ciphertext)
+	if err != nil {
+		return
+	}
+
+	// always authenticate, so a wrong password never looks like success
+	plaintext, err := DecryptByteWithPassword(password, ciphertext)
+	if err != nil {
+		return
+	}
+	defer clear(plaintext)
+
+	if current.AtLeast(params) {
+		out = ciphertext
+		return
+	}
+
+	out, err = EncryptByteWithPasswordParams(password, plaintext, params)
+	resealed = err == nil
+	return
+}
@@ -0,0 +1,213 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// fastPasswordParams are the cheapest accepted parameters, so the tests do
+// not spend seconds in Argon2id.
+var fastPasswordParams = PasswordParams{Memory: MinPasswordMemory, Time: 1, Threads: 1}
+
+func TestPasswordRoundTrip(t *testing.T) {
+	password := []byte("correct horse battery staple")
+	const text = "the quick brown fox jumps over the lazy dog"
+
+	t.Run("defaults", func(t *testing.T) {
+		ciphertext, err := EncryptWithPassword(password, text)
+		if err != nil {
+			t.Fatalf("EncryptWithPassword: %v", err)
+		}
+		params, err := PasswordBlobParams(ciphertext)
+		if err != nil {
+			t.Fatalf("PasswordBlobParams: %v", err)
+		}
+		if params != DefaultPasswordParams() {
+			t.Errorf("params = %+v, want defaults %+v", params, DefaultPasswordParams())
+		}
+		got, err := DecryptWithPassword(password, ciphertext)
+		if err != nil {
+			t.Fatalf("DecryptWithPassword: %v", err)
+		}
+		if got != text {
+			t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+		}
+	})
+
+	t.Run("customParams", func(t *testing.T) {
+		ciphertext, err := EncryptByteWithPasswordParams(password, []byte(text), fastPasswordParams)
+		if err != nil {
+			t.Fatalf("EncryptByteWithPasswordParams: %v", err)
+		}
+		if want := passwordHeaderSize + commitmentSize + 24 + len(text) + 16; len(ciphertext) != want {
+			t.Errorf("ciphertext len = %d, want %d", len(ciphertext), want)
+		}
+		got, err := DecryptByteWithPassword(password, ciphertext)
+		if err != nil || string(got) != text {
+			t.Errorf("DecryptByteWithPassword = %q, %v", got, err)
+		}
+	})
+
+	t.Run("uniqueSalt", func(t *testing.T) {
+		a, _ := EncryptByteWithPasswordParams(password, []byte(text), fastPasswordParams)
+		b, _ := EncryptByteWithPasswordParams(password, []byte(text), fastPasswordParams)
+		if bytes.Equal(a[:passwordHeaderSize], b[:passwordHeaderSize]) {
+			t.Error("two blobs share a salt")
+		}
+	})
+}
+
+func TestPasswordRejects(t *testing.T) {
+	password := []byte("correct horse battery staple")
+	ciphertext, err := EncryptByteWithPasswordParams(password, []byte("secret"), fastPasswordParams)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	t.Run("wrongPassword", func(t *testing.T) {
+		if _, err := DecryptByteWithPassword([]byte("Tr0ub4dor&3"), ciphertext); err == nil {
+			t.Error("decryption with wrong password succeeded, want failure")
+		}
+	})
+
+	t.Run("tamperedHeader", func(t *testing.T) {
+		// the header is authenticated, so even a parameter change that
+		// stays in range must fail
+		bad := bytes.Clone(ciphertext)
+		binary.BigEndian.PutUint32(bad[6:], fastPasswordParams.Time+1)
+		if _, err := DecryptByteWithPassword(password, bad); err == nil {
+			t.Error("decryption with altered time cost succeeded, want failure")
+		}
+	})
+
+	t.Run("attackerParams", func(t *testing.T) {
+		// out-of-range parameters are refused before Argon2id runs
+		cases := map[string]func(b []byte){
+			"hugeMemory":  func(b []byte) { binary.BigEndian.PutUint32(b[2:], MaxPasswordMemory+1) },
+			"tinyMemory":  func(b []byte) { binary.BigEndian.PutUint32(b[2:], 8) },
+			"hugeTime":    func(b []byte) { binary.BigEndian.PutUint32(b[6:], MaxPasswordTime+1) },
+			"zeroTime":    func(b []byte) { binary.BigEndian.PutUint32(b[6:], 0) },
+			"noThreads":   func(b []byte) { b[10] = 0 },
+			"manyThreads": func(b []byte) { b[10] = MaxPasswordThreads + 1 },
+			"version":     func(b []byte) { b[0] = 0x7F },
+			"kdf":         func(b []byte) { b[1] = 0x7F },
+			"saltLen":     func(b []byte) { b[11] = 8 },
+		}
+		for name, mutate := range cases {
+			bad := bytes.Clone(ciphertext)
+			mutate(bad)
+			if _, err := PasswordBlobParams(bad); err == nil {
+				t.Errorf("%s: PasswordBlobParams succeeded, want failure", name)
+			}
+			if _, err := DecryptByteWithPassword(password, bad); err == nil {
+				t.Errorf("%s: decryption succeeded, want failure", name)
+			}
+		}
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		// a blob within the package bounds but above the caller's limits is
+		// refused before Argon2id runs
+		derived := 0
+		countingIDKey := func(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+			derived++
+			return make([]byte, keyLen)
+		}
+
+		costly := bytes.Clone(ciphertext)
+		binary.BigEndian.PutUint32(costly[2:], MaxPasswordMemory)
+		binary.BigEndian.PutUint32(costly[6:], MaxPasswordTime)
+		costly[10] = MaxPasswordThreads
+		for name, limits := range map[string]PasswordLimits{
+			"memory":  {MaxMemory: fastPasswordParams.Memory},
+			"time":    {MaxTime: fastPasswordParams.Time},
+			"threads": {MaxThreads: fastPasswordParams.Threads},
+		} {
+			if _, err := decryptWithPasswordLimits(countingIDKey, password, costly, limits); !errors.Is(err, ErrInvalidParameters) {
+				t.Errorf("%s: err = %v, want ErrInvalidParameters", name, err)
+			}
+		}
+		for name, mutate := range map[string]func(b []byte){
+			"memory":  func(b []byte) { binary.BigEndian.PutUint32(b[2:], MaxPasswordMemory+1) },
+			"time":    func(b []byte) { binary.BigEndian.PutUint32(b[6:], MaxPasswordTime+1) },
+			"threads": func(b []byte) { b[10] = MaxPasswordThreads + 1 },
+		} {
+			bad := bytes.Clone(ciphertext)
+			mutate(bad)
+			// limits above the package maximum do not loosen it
+			huge := PasswordLimits{MaxMemory: 1 << 31, MaxTime: 1 << 31, MaxThreads: 255}
+			if _, err := decryptWithPasswordLimits(countingIDKey, password, bad, huge); !errors.Is(err, ErrInvalidParameters) {
+				t.Errorf("over-ceiling %s: err = %v, want ErrInvalidParameters", name, err)
+			}
+		}
+		if derived != 0 {
+			t.Errorf("Argon2id ran %d times for rejected blobs", derived)
+		}
+		if _, err := decryptWithPasswordLimits(countingIDKey, password, ciphertext, PasswordLimits{}); !errors.Is(err, ErrAuthenticationFailed) || derived != 1 {
+			t.Errorf("accepted blob: err = %v after %d derivations, want one derivation", err, derived)
+		}
+
+		// limits the blob meets still decrypt
+		limits := PasswordLimits{MaxMemory: fastPasswordParams.Memory, MaxTime: 1, MaxThreads: 1}
+		if got, err := DecryptByteWithPasswordLimits(password, ciphertext, limits); err != nil || string(got) != "secret" {
+			t.Errorf("within limits: %q, %v", got, err)
+		}
+	})
+
+	t.Run("invalidParams", func(t *testing.T) {
+		if _, err := EncryptByteWithPasswordParams(password, nil, PasswordParams{}); err == nil {
+			t.Error("encrypt with zero params succeeded, want failure")
+		}
+	})
+
+	t.Run("tooShort", func(t *testing.T) {
+		if _, err := DecryptByteWithPassword(password, ciphertext[:passwordHeaderSize-1]); err == nil {
+			t.Error("expected error for too-short ciphertext, got nil")
+		}
+	})
+}
+
+func TestResealWithPassword(t *testing.T) {
+	password := []byte("correct horse battery staple")
+	stronger := fastPasswordParams
+	stronger.Time = 2
+
+	ciphertext, err := EncryptByteWithPasswordParams(password, []byte("secret"), fastPasswordParams)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	t.Run("upgrade", func(t *testing.T) {
+		out, resealed, err := ResealWithPassword(password, ciphertext, stronger)
+		if err != nil {
+			t.Fatalf("ResealWithPassword: %v", err)
+		}
+		if !resealed {
+			t.Error("resealed = false, want true for weaker blob")
+		}
+		if params, _ := PasswordBlobParams(out); params != stronger {
+			t.Errorf("params = %+v, want %+v", params, stronger)
+		}
+		if got, err := DecryptByteWithPassword(password, out); err != nil || string(got) != "secret" {
+			t.Errorf("decrypt resealed = %q, %v", got, err)
+		}
+	})
+
+	t.Run("alreadyStrongEnough", func(t *testing.T) {
+		out, resealed, err := ResealWithPassword(password, ciphertext, fastPasswordParams)
+		if err != nil {
+			t.Fatalf("ResealWithPassword: %v", err)
+		}
+		if resealed || !bytes.Equal(out, ciphertext) {
+			t.Error("blob already at target strength was resealed")
+		}
+	})
+
+	t.Run("wrongPassword", func(t *testing.T) {
+		if _, _, err := ResealWithPassword([]byte("wrong"), ciphertext, fastPasswordParams); err == nil {
+			t.Error("reseal with wrong password succeeded, want failure")
+		}
+	})
+}
@@ -0,0 +1,157 @@
+package crypt
+
+// Decryption of PKCS#8 "ENCRYPTED PRIVATE KEY" blocks protected with PBES2
+// (RFC 8018): PBKDF2 over HMAC-SHA-1/224/256/384/512 and AES-CBC, which is
+// what OpenSSL 1.1 and later, Java keytool and most KMS exports produce.
+//
+//	EncryptedPrivateKeyInfo ::= SEQUENCE {
+//	  encryptionAlgorithm  AlgorithmIdentifier {pbes2, PBES2-params},
+//	  encryptedData        OCTET STRING }
+//
+//	PBES2-params ::= SEQUENCE {
+//	  keyDerivationFunc  AlgorithmIdentifier {pbkdf2, PBKDF2-params},
+//	  encryptionScheme   AlgorithmIdentifier {aesXXX-CBC, iv OCTET STRING} }
+//
+//	PBKDF2-params ::= SEQUENCE {
+//	  salt            OCTET STRING,
+//	  iterationCount  INTEGER,
+//	  keyLength       INTEGER OPTIONAL,
+//	  prf             AlgorithmIdentifier DEFAULT hmacWithSHA1 }
+//
+// PBES1, scrypt and non-AES ciphers are refused with ErrUnsupportedAlgorithm.
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	_ "crypto/sha1" // link SHA-1, the PBKDF2 default PRF
+	"crypto/subtle"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+)
+
+// maxPBKDF2Iterations bounds the work a hostile key file can demand.
+const maxPBKDF2Iterations = 10_000_000
+
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	// pbkdf2PRFs maps the hmacWithSHA* identifiers to their hash.
+	pbkdf2PRFs = map[string]crypto.Hash{
+		"1.2.840.113549.2.7":  crypto.SHA1,
+		"1.2.840.113549.2.8":  crypto.SHA224,
+		"1.2.840.113549.2.9":  crypto.SHA256,
+		"1.2.840.113549.2.10": crypto.SHA384,
+		"1.2.840.113549.2.11": crypto.SHA512,
+	}
+
+	// pbes2Ciphers maps the aesXXX-CBC identifiers to their key size.
+	pbes2Ciphers = map[string]int{
+		"2.16.840.1.101.3.4.1.2":  16,
+		"2.16.840.1.101.3.4.1.22": 24,
+		"2.16.840.1.101.3.4.1.42": 32,
+	}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8 decrypts the DER of an "ENCRYPTED PRIVATE KEY" block and
+// returns the DER of the PKCS#8 PrivateKeyInfo inside. A wrong passphrase
+// wraps ErrAuthenticationFailed.
+func decryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: malformed encrypted private key", ErrInvalidPEM)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("%w: key encryption %v (only PBES2 is supported)", ErrUnsupportedAlgorithm, info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("%w: malformed PBES2 parameters", ErrInvalidPEM)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("%w: key derivation %v (only PBKDF2 is supported)", ErrUnsupportedAlgorithm, params.KeyDerivationFunc.Algorithm)
+	}
+	keySize, ok := pbes2Ciphers[params.EncryptionScheme.Algorithm.String()]
+	if !ok {
+		return nil, fmt.Errorf("%w: key cipher %v (only AES-CBC is supported)", ErrUnsupportedAlgorithm, params.EncryptionScheme.Algorithm)
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("%w: malformed PBKDF2 parameters", ErrInvalidPEM)
+	}
+	prf := crypto.SHA1
+	if len(kdf.PRF.Algorithm) != 0 {
+		if prf, ok = pbkdf2PRFs[kdf.PRF.Algorithm.String()]; !ok {
+			return nil, fmt.Errorf("%w: PBKDF2 PRF %v", ErrUnsupportedAlgorithm, kdf.PRF.Algorithm)
+		}
+	}
+	if kdf.IterationCount < 1 || kdf.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("%w: PBKDF2 iteration count %d", ErrInvalidParameters, kdf.IterationCount)
+	}
+	if kdf.KeyLength != 0 && kdf.KeyLength != keySize {
+		return nil, fmt.Errorf("%w: PBKDF2 key length %d for a %d-byte cipher key", ErrInvalidParameters, kdf.KeyLength, keySize)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("%w: malformed AES-CBC IV", ErrInvalidPEM)
+	}
+	ciphertext := info.EncryptedData
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: encrypted key is not a whole number of blocks", ErrInvalidPEM)
+	}
+
+	key, err := pbkdf2.Key(prf.New, string(passphrase), kdf.Salt, kdf.IterationCount, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key: %w", err)
+	}
+	defer clear(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+	plaintext, ok = unpadPKCS7(plaintext)
+	if !ok {
+		return nil, fmt.Errorf("%w: wrong passphrase or corrupt key", ErrAuthenticationFailed)
+	}
+	return plaintext, nil
+}
+
+// unpadPKCS7 strips the PKCS#7 padding of a CBC plaintext.
+func unpadPKCS7(b []byte) ([]byte, bool) {
+	n := int(b[len(b)-1])
+	if n == 0 || n > aes.BlockSize {
+		return nil, false
+	}
+	pad := b[len(b)-n:]
+	if subtle.ConstantTimeCompare(pad, bytes.Repeat([]byte{byte(n)}, n)) != 1 {
+		return nil, false
+	}
+	return b[:len(b)-n], true
+}
@@ -0,0 +1,120 @@
+package crypt
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// PEM block types accepted by NewEncoder and NewDecoder.
+const (
+	pemPublicKey           = "PUBLIC KEY"            // PKIX SubjectPublicKeyInfo
+	pemRSAPublicKey        = "RSA PUBLIC KEY"        // PKCS#1 RSAPublicKey
+	pemCertificate         = "CERTIFICATE"           // X.509 certificate
+	pemPrivateKey          = "PRIVATE KEY"           // PKCS#8 PrivateKeyInfo
+	pemRSAPrivateKey       = "RSA PRIVATE KEY"       // PKCS#1 RSAPrivateKey
+	pemECPrivateKey        = "EC PRIVATE KEY"        // SEC 1 ECPrivateKey
+	pemECParameters        = "EC PARAMETERS"         // skipped before an EC key
+	pemEncryptedPrivateKey = "ENCRYPTED PRIVATE KEY" // PKCS#8 EncryptedPrivateKeyInfo
+)
+
+// decodePublicKeyPEM decodes the first PEM block of s, which must carry a
+// public key or a certificate. Text before the block and any later blocks,
+// such as the rest of a certificate chain, are ignored.
+func decodePublicKeyPEM(s string) (*pem.Block, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, fmt.Errorf("%w: failed to decode public key", ErrInvalidPEM)
+	}
+	switch block.Type {
+	case pemPublicKey, pemRSAPublicKey, pemCertificate:
+		return block, nil
+	}
+	return block, fmt.Errorf("%w: failed to decode public key: unexpected %q block", ErrInvalidPEM, block.Type)
+}
+
+// decodePrivateKeyPEM decodes the first PEM block of s, which must carry a
+// private key, encrypted or not. An "EC PARAMETERS" block, which "openssl
+// ecparam -genkey" writes before the key, is skipped.
+func decodePrivateKeyPEM(s string) (*pem.Block, error) {
+	block, rest := pem.Decode([]byte(s))
+	if block != nil && block.Type == pemECParameters {
+		block, _ = pem.Decode(rest)
+	}
+	if block == nil {
+		return nil, fmt.Errorf("%w: failed to decode private key", ErrInvalidPEM)
+	}
+	switch block.Type {
+	case pemPrivateKey, pemEncryptedPrivateKey:
+		return block, nil
+	case pemRSAPrivateKey, pemECPrivateKey:
+		// RFC 1421 "Proc-Type: 4,ENCRYPTED" encryption derives its key with
+		// a single MD5 round and is not supported; PKCS#8 replaces it.
+		if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+			return block, fmt.Errorf("%w: legacy PEM encryption is not supported; re-encrypt the key as PKCS#8", ErrUnsupportedAlgorithm)
+		}
+		return block, nil
+	}
+	return block, fmt.Errorf("%w: failed to decode private key: unexpected %q block", ErrInvalidPEM, block.Type)
+}
+
+// decryptPrivateKeyBlock decrypts an "ENCRYPTED PRIVATE KEY" block into a
+// plain "PRIVATE KEY" block. A wrong passphrase wraps
+// ErrAuthenticationFailed.
+func decryptPrivateKeyBlock(block *pem.Block, passphrase []byte) (*pem.Block, error) {
+	der, err := decryptPKCS8(block.Bytes, passphrase)
+	if err != nil {
+		return block, err
+	}
+	// CBC is not authenticated: a wrong passphrase passes the padding check
+	// about once in 256 tries and only shows up as garbage DER.
+	if _, err := x509.ParsePKCS8PrivateKey(der); err != nil {
+		clear(der)
+		return block, fmt.Errorf("%w: wrong passphrase or corrupt key", ErrAuthenticationFailed)
+	}
+	return &pem.Block{Type: pemPrivateKey, Bytes: der}, nil
+}
+
+// parsePublicKeyBlock parses a block accepted by decodePublicKeyPEM.
+func parsePublicKeyBlock(block *pem.Block) (crypto.PublicKey, error) {
+	var pubKey crypto.PublicKey
+	var err error
+	switch block.Type {
+	case pemRSAPublicKey:
+		pubKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case pemCertificate:
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			pubKey = cert.PublicKey
+		}
+	default:
+		pubKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: error parsing public key: %w", ErrInvalidPEM, err)
+	}
+	return pubKey, nil
+}
+
+// parsePrivateKeyBlock parses a block accepted by decodePrivateKeyPEM once
+// it is decrypted.
+func parsePrivateKeyBlock(block *pem.Block) (crypto.PrivateKey, error) {
+	var priKey crypto.PrivateKey
+	var err error
+	switch block.Type {
+	case pemRSAPrivateKey:
+		priKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case pemECPrivateKey:
+		priKey, err = x509.ParseECPrivateKey(block.Bytes)
+	case pemEncryptedPrivateKey:
+		return nil, fmt.Errorf("%w: private key is still encrypted", ErrInvalidPEM)
+	default:
+		priKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: error parsing private key: %w", ErrInvalidPEM, err)
+	}
+	return priKey, nil
+}
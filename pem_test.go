@@ -0,0 +1,192 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPassphrase protects the encrypted keys in testdata, which were written
+// by OpenSSL 3.0 from testdata/rsa-pkcs8.pem:
+//
+//	openssl pkcs8 -topk8 -v2 aes-256-cbc -v2prf hmacWithSHA256
+//	openssl pkcs8 -topk8 -v2 aes-128-cbc -v2prf hmacWithSHA1
+//	openssl pkcs8 -topk8 -v2 aes-192-cbc -v2prf hmacWithSHA512 -iter 5000
+//	openssl pkcs8 -topk8 -v2 des3
+//	openssl pkcs8 -topk8 -scrypt
+const testPassphrase = "correct horse"
+
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// checkKeyPair encrypts and signs with enc and reverses both with dec.
+func checkKeyPair(t *testing.T, enc *Encoder, dec *Decoder) {
+	t.Helper()
+	if enc.Err != nil || dec.Err != nil {
+		t.Fatalf("constructor errors: %v, %v", enc.Err, dec.Err)
+	}
+	ct, err := enc.EncryptRSA("pem")
+	if err != nil {
+		t.Fatalf("EncryptRSA: %v", err)
+	}
+	if got, err := dec.DecryptRSA(ct); err != nil || got != "pem" {
+		t.Fatalf("DecryptRSA = %q, %v", got, err)
+	}
+	sig, err := dec.SignRSA([]byte("pem"))
+	if err != nil {
+		t.Fatalf("SignRSA: %v", err)
+	}
+	if err := enc.VerifyRSA([]byte("pem"), sig); err != nil {
+		t.Fatalf("VerifyRSA: %v", err)
+	}
+}
+
+// testCertificatePEM returns a self-signed certificate for key.
+func testCertificatePEM(t *testing.T, key *rsa.PrivateKey, name string) []byte {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestPKCS1AndCertificatePEM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs1Priv := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	pkcs1Pub := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)}))
+	cert := testCertificatePEM(t, key, "leaf")
+
+	t.Run("pkcs1", func(t *testing.T) {
+		checkKeyPair(t, NewEncoder(pkcs1Pub), NewDecoder(pkcs1Priv))
+	})
+
+	t.Run("certificate", func(t *testing.T) {
+		checkKeyPair(t, NewEncoder(string(cert)), NewDecoder(pkcs1Priv))
+	})
+
+	t.Run("chainUsesLeaf", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain := append([]byte("subject=CN = leaf\n"), cert...)
+		chain = append(chain, testCertificatePEM(t, other, "issuer")...)
+		checkKeyPair(t, NewEncoder(string(chain)), NewDecoder(pkcs1Priv))
+	})
+
+	t.Run("legacyEncryptedPKCS1", func(t *testing.T) {
+		block := &pem.Block{
+			Type:    "RSA PRIVATE KEY",
+			Headers: map[string]string{"Proc-Type": "4,ENCRYPTED", "DEK-Info": "AES-256-CBC,00"},
+			Bytes:   []byte("opaque"),
+		}
+		d := NewDecoderWithPassphrase(string(pem.EncodeToMemory(block)), []byte(testPassphrase))
+		if !errors.Is(d.Err, ErrUnsupportedAlgorithm) {
+			t.Errorf("Err = %v, want ErrUnsupportedAlgorithm", d.Err)
+		}
+	})
+
+	t.Run("wrongBlockTypes", func(t *testing.T) {
+		if e := NewEncoder(pkcs1Priv); !errors.Is(e.Err, ErrInvalidPEM) {
+			t.Errorf("NewEncoder(RSA PRIVATE KEY) Err = %v, want ErrInvalidPEM", e.Err)
+		}
+		if d := NewDecoder(string(cert)); !errors.Is(d.Err, ErrInvalidPEM) {
+			t.Errorf("NewDecoder(CERTIFICATE) Err = %v, want ErrInvalidPEM", d.Err)
+		}
+		if d := NewDecoder(pkcs1Pub); !errors.Is(d.Err, ErrInvalidPEM) {
+			t.Errorf("NewDecoder(RSA PUBLIC KEY) Err = %v, want ErrInvalidPEM", d.Err)
+		}
+	})
+}
+
+func TestEncryptedPKCS8(t *testing.T) {
+	plain := NewDecoder(readTestdata(t, "rsa-pkcs8.pem"))
+	if plain.Err != nil {
+		t.Fatal(plain.Err)
+	}
+	priv, err := plain.rsaPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := NewEncoder(string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})))
+
+	for _, name := range []string{
+		"rsa-pkcs8-aes256-sha256.pem",
+		"rsa-pkcs8-aes128-sha1.pem",
+		"rsa-pkcs8-aes192-sha512.pem",
+	} {
+		t.Run(name, func(t *testing.T) {
+			encrypted := readTestdata(t, name)
+
+			dec := NewDecoderWithPassphrase(encrypted, []byte(testPassphrase))
+			if dec.Err != nil {
+				t.Fatalf("NewDecoderWithPassphrase: %v", dec.Err)
+			}
+			if dec.PriKeyBlock.Type != "PRIVATE KEY" || !bytes.Equal(dec.PriKeyBlock.Bytes, plain.PriKeyBlock.Bytes) {
+				t.Error("decrypted key differs from testdata/rsa-pkcs8.pem")
+			}
+			checkKeyPair(t, enc, dec)
+
+			if d := NewDecoderWithPassphrase(encrypted, []byte("wrong horse")); !errors.Is(d.Err, ErrAuthenticationFailed) {
+				t.Errorf("wrong passphrase Err = %v, want ErrAuthenticationFailed", d.Err)
+			}
+			d := NewDecoder(encrypted)
+			if !errors.Is(d.Err, ErrInvalidPEM) {
+				t.Errorf("NewDecoder Err = %v, want ErrInvalidPEM", d.Err)
+			}
+			if _, err := d.DecryptRSA([]byte("x")); !errors.Is(err, ErrInvalidPEM) {
+				t.Errorf("DecryptRSA with an encrypted key err = %v, want ErrInvalidPEM", err)
+			}
+		})
+	}
+
+	t.Run("unsupported", func(t *testing.T) {
+		for _, name := range []string{"rsa-pkcs8-des3.pem", "rsa-pkcs8-scrypt.pem"} {
+			d := NewDecoderWithPassphrase(readTestdata(t, name), []byte(testPassphrase))
+			if !errors.Is(d.Err, ErrUnsupportedAlgorithm) {
+				t.Errorf("%s: Err = %v, want ErrUnsupportedAlgorithm", name, d.Err)
+			}
+		}
+	})
+
+	t.Run("unencrypted", func(t *testing.T) {
+		d := NewDecoderWithPassphrase(readTestdata(t, "rsa-pkcs8.pem"), []byte(testPassphrase))
+		checkKeyPair(t, enc, d)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		garbage := pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("garbage")})
+		if d := NewDecoderWithPassphrase(string(garbage), []byte(testPassphrase)); !errors.Is(d.Err, ErrInvalidPEM) {
+			t.Errorf("Err = %v, want ErrInvalidPEM", d.Err)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package crypt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+)
+
+var (
+	_ crypto.Signer    = (*Decoder)(nil)
+	_ crypto.Decrypter = (*Decoder)(nil)
+	_ crypto.Signer    = (*PrivateKey)(nil)
+	_ crypto.Decrypter = (*PrivateKey)(nil)
+)
+
+// PrivateKey holds a parsed RSA, ECDSA or Ed25519 private key. It implements
+// [crypto.Signer] for all three and [crypto.Decrypter] for RSA, so one loaded
+// key serves crypto/tls, [x509.CreateCertificate], SSH signers and the RSA
+// API of this package (through [PrivateKey.Decoder]).
+//
+// A PrivateKey is immutable and safe for concurrent use.
+type PrivateKey struct {
+	key crypto.Signer
+}
+
+// NewPrivateKey wraps a private key already in memory: an *rsa.PrivateKey,
+// an *ecdsa.PrivateKey or an ed25519.PrivateKey. Other types wrap
+// [ErrUnsupportedKeyType].
+func NewPrivateKey(key crypto.PrivateKey) (*PrivateKey, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k == nil || k.N == nil {
+			return nil, fmt.Errorf("%w: nil RSA private key", ErrUnsupportedKeyType)
+		}
+		if err := k.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+		}
+		return &PrivateKey{key: k}, nil
+	case *ecdsa.PrivateKey:
+		if k == nil || k.Curve == nil || k.D == nil {
+			return nil, fmt.Errorf("%w: nil ECDSA private key", ErrUnsupportedKeyType)
+		}
+		return &PrivateKey{key: k}, nil
+	case ed25519.PrivateKey:
+		if len(k) != ed25519.PrivateKeySize {
+			return nil, fmt.Errorf("%w: Ed25519 private key of %d bytes", ErrUnsupportedKeyType, len(k))
+		}
+		return &PrivateKey{key: k}, nil
+	}
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedKeyType, key)
+}
+
+// ParsePrivateKey parses a PEM-encoded private key of any supported type, in
+// any format [NewDecoder] accepts.
+func ParsePrivateKey(privateKeyPEM string) (*PrivateKey, error) {
+	return NewDecoder(privateKeyPEM).PrivateKey()
+}
+
+// ParsePrivateKeyWithPassphrase is like [ParsePrivateKey] but also decrypts
+// an "ENCRYPTED PRIVATE KEY" block; see [NewDecoderWithPassphrase].
+func ParsePrivateKeyWithPassphrase(privateKeyPEM string, passphrase []byte) (*PrivateKey, error) {
+	return NewDecoderWithPassphrase(privateKeyPEM, passphrase).PrivateKey()
+}
+
+// ParsePrivateKeyDER parses a DER-encoded PKCS#8, PKCS#1 or SEC 1 private
+// key.
+func ParsePrivateKeyDER(der []byte) (*PrivateKey, error) {
+	return NewDecoderFromDER(der).PrivateKey()
+}
+
+// Public returns the public half of the key: an *rsa.PublicKey, an
+// *ecdsa.PublicKey or an ed25519.PublicKey.
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return k.key.Public()
+}
+
+// Sign signs digest with the key, as [crypto.Signer] specifies: opts selects
+// the hash, and an *rsa.PSSOptions selects RSA-PSS over PKCS#1 v1.5. For
+// Ed25519, digest is the whole message and opts.HashFunc() must be zero
+// (or an *ed25519.Options). A nil rand uses crypto/rand.
+func (k *PrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return signWith(k.key, rand, digest, opts)
+}
+
+// Decrypt decrypts msg with an RSA key, as [crypto.Decrypter] specifies: an
+// *rsa.OAEPOptions selects OAEP, and nil or *rsa.PKCS1v15DecryptOptions
+// selects PKCS#1 v1.5, which only legacy protocols such as TLS RSA key
+// exchange should use. Other key types wrap [ErrUnsupportedKeyType].
+func (k *PrivateKey) Decrypt(rand io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	return decryptWith(k.key, rand, msg, opts)
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the public key; see
+// [Encoder.Fingerprint].
+func (k *PrivateKey) Fingerprint() ([]byte, error) {
+	return k.Decoder().Fingerprint()
+}
+
+// Decoder returns a Decoder for the key, for PEM export and, with an RSA
+// key, the package's RSA decryption and signing API.
+func (k *PrivateKey) Decoder() *Decoder {
+	der, err := x509.MarshalPKCS8PrivateKey(k.key)
+	if err != nil {
+		return &Decoder{Err: fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)}
+	}
+	block := &pem.Block{Type: pemPrivateKey, Bytes: der}
+	return &Decoder{PriKeyBlock: block, privBlock: block, priv: k.key}
+}
+
+// PrivateKey returns the Decoder's key as a [PrivateKey], or the
+// constructor's error.
+func (d *Decoder) PrivateKey() (*PrivateKey, error) {
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	priv, err := d.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(priv)
+}
+
+// Public returns the public half of the Decoder's key, or nil when it holds
+// no usable key (check Err). It implements [crypto.Signer].
+func (d *Decoder) Public() crypto.PublicKey {
+	pub, err := d.publicKey()
+	if err != nil {
+		return nil
+	}
+	return pub
+}
+
+// Sign implements [crypto.Signer] with the Decoder's key; see
+// [PrivateKey.Sign]. It ignores HashAlg and SignScheme, which configure
+// [Decoder.SignRSA]: here opts decides.
+func (d *Decoder) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	priv, err := d.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	return signWith(priv, rand, digest, opts)
+}
+
+// Decrypt implements [crypto.Decrypter] with the Decoder's RSA key; see
+// [PrivateKey.Decrypt]. It ignores the Decoder's OAEP settings, which
+// configure [Decoder.DecryptRSA]: here opts decides.
+func (d *Decoder) Decrypt(rand io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	priv, err := d.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	return decryptWith(priv, rand, msg, opts)
+}
+
+// signWith signs digest with priv as a crypto.Signer.
+func signWith(priv crypto.PrivateKey, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T cannot sign", ErrUnsupportedKeyType, priv)
+	}
+	signature, err := signer.Sign(randomSource(rand), digest, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error signing data: %w", err)
+	}
+	return signature, nil
+}
+
+// decryptWith decrypts msg with priv, which must be an RSA key.
+func decryptWith(priv crypto.PrivateKey, rand io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	rsaPriv, ok := priv.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T cannot decrypt", ErrUnsupportedKeyType, priv)
+	}
+	plaintext, err := rsaPriv.Decrypt(randomSource(rand), msg, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,253 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// testSigners returns a Decoder-backed signer for each supported key type.
+func testSigners(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	_, rsaPEM := testRSAKeyPair(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the layout "openssl ecparam -genkey" writes
+	ecPEM := "-----BEGIN EC PARAMETERS-----\nBggqhkjOPQMBBw==\n-----END EC PARAMETERS-----\n" +
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}))
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER}))
+
+	signers := map[string]crypto.Signer{}
+	for name, in := range map[string]string{"rsa": rsaPEM, "ecdsa": ecPEM, "ed25519": edPEM} {
+		dec := NewDecoder(in)
+		if dec.Err != nil {
+			t.Fatalf("%s: NewDecoder: %v", name, dec.Err)
+		}
+		signers[name] = dec
+	}
+	return signers
+}
+
+// selfSigned issues a self-signed certificate through signer.
+func selfSigned(t *testing.T, signer crypto.Signer) *x509.Certificate {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, signer.Public(), signer)
+	if err != nil {
+		t.Fatalf("CreateCertificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestDecoderAsSigner(t *testing.T) {
+	for name, signer := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			cert := selfSigned(t, signer)
+			if err := cert.CheckSignatureFrom(cert); err != nil {
+				t.Errorf("certificate signature: %v", err)
+			}
+
+			// a TLS 1.3 handshake signs the transcript with the key
+			pool := x509.NewCertPool()
+			pool.AddCert(cert)
+			serverConf := &tls.Config{
+				Certificates: []tls.Certificate{{
+					Certificate: [][]byte{cert.Raw},
+					PrivateKey:  signer,
+				}},
+				SessionTicketsDisabled: true,
+			}
+			clientConf := &tls.Config{RootCAs: pool, ServerName: "localhost"}
+
+			c, s := net.Pipe()
+			done := make(chan error, 1)
+			go func() {
+				done <- tls.Server(s, serverConf).Handshake()
+				_ = s.Close()
+			}()
+			if err := tls.Client(c, clientConf).Handshake(); err != nil {
+				t.Errorf("client handshake: %v", err)
+			}
+			_ = c.Close()
+			if err := <-done; err != nil {
+				t.Errorf("server handshake: %v", err)
+			}
+		})
+	}
+}
+
+func TestPrivateKey(t *testing.T) {
+	digest := sha256.Sum256([]byte("payload"))
+
+	for name, signer := range testSigners(t) {
+		t.Run(name, func(t *testing.T) {
+			dec := signer.(*Decoder)
+			k, err := dec.PrivateKey()
+			if err != nil {
+				t.Fatalf("PrivateKey: %v", err)
+			}
+			if !k.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(dec.Public()) {
+				t.Error("PrivateKey.Public differs from Decoder.Public")
+			}
+
+			var sig []byte
+			switch pub := k.Public().(type) {
+			case *rsa.PublicKey:
+				sig, err = k.Sign(nil, digest[:], &rsa.PSSOptions{Hash: crypto.SHA256})
+				if err == nil {
+					err = rsa.VerifyPSS(pub, crypto.SHA256, digest[:], sig, nil)
+				}
+			case *ecdsa.PublicKey:
+				sig, err = k.Sign(nil, digest[:], crypto.SHA256)
+				if err == nil && !ecdsa.VerifyASN1(pub, digest[:], sig) {
+					err = errors.New("ECDSA signature does not verify")
+				}
+			case ed25519.PublicKey:
+				sig, err = k.Sign(nil, []byte("payload"), crypto.Hash(0))
+				if err == nil && !ed25519.Verify(pub, []byte("payload"), sig) {
+					err = errors.New("Ed25519 signature does not verify")
+				}
+			}
+			if err != nil {
+				t.Errorf("sign and verify: %v", err)
+			}
+
+			fp, err := k.Fingerprint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			der, _ := x509.MarshalPKIXPublicKey(k.Public())
+			if want := sha256.Sum256(der); !bytes.Equal(fp, want[:]) {
+				t.Errorf("Fingerprint = %x, want %x", fp, want)
+			}
+
+			exported, err := k.Decoder().PrivateKeyPEM()
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := ParsePrivateKey(exported)
+			if err != nil {
+				t.Fatalf("ParsePrivateKey of the export: %v", err)
+			}
+			if fp2, _ := again.Fingerprint(); !bytes.Equal(fp, fp2) {
+				t.Error("exported key has a different fingerprint")
+			}
+
+			if name != "rsa" {
+				if _, err := k.Decrypt(nil, []byte("x"), nil); !errors.Is(err, ErrUnsupportedKeyType) {
+					t.Errorf("Decrypt err = %v, want ErrUnsupportedKeyType", err)
+				}
+				if _, err := dec.DecryptRSA([]byte("x")); !errors.Is(err, ErrUnsupportedKeyType) {
+					t.Errorf("DecryptRSA err = %v, want ErrUnsupportedKeyType", err)
+				}
+			}
+		})
+	}
+}
+
+func TestDecoderAsDecrypter(t *testing.T) {
+	_, privPEM := testRSAKeyPair(t)
+	dec := NewDecoder(privPEM)
+	pub := dec.Public().(*rsa.PublicKey)
+	msg := []byte("premaster")
+
+	oaep, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, msg, []byte("l"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := dec.Decrypt(nil, oaep, &rsa.OAEPOptions{Hash: crypto.SHA256, Label: []byte("l")})
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("OAEP Decrypt = %q, %v", got, err)
+	}
+	if _, err := dec.Decrypt(nil, oaep, &rsa.OAEPOptions{Hash: crypto.SHA256}); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("wrong label err = %v, want ErrAuthenticationFailed", err)
+	}
+
+	v15, err := rsa.EncryptPKCS1v15(rand.Reader, pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = dec.Decrypt(rand.Reader, v15, nil)
+	if err != nil || !bytes.Equal(got, msg) {
+		t.Errorf("PKCS#1 v1.5 Decrypt = %q, %v", got, err)
+	}
+
+	var d crypto.Decrypter = dec
+	if _, ok := d.Public().(*rsa.PublicKey); !ok {
+		t.Errorf("Public() = %T, want *rsa.PublicKey", d.Public())
+	}
+}
+
+func TestNewPrivateKeyErrors(t *testing.T) {
+	x, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, key := range map[string]crypto.PrivateKey{
+		"nil":        nil,
+		"nilRSA":     (*rsa.PrivateKey)(nil),
+		"nilECDSA":   (*ecdsa.PrivateKey)(nil),
+		"shortEd":    ed25519.PrivateKey(make([]byte, 10)),
+		"x25519":     x,
+		"unexpected": "key",
+	} {
+		if _, err := NewPrivateKey(key); !errors.Is(err, ErrUnsupportedKeyType) {
+			t.Errorf("%s: err = %v, want ErrUnsupportedKeyType", name, err)
+		}
+	}
+
+	if _, err := ParsePrivateKey("not a pem block"); !errors.Is(err, ErrInvalidPEM) {
+		t.Errorf("ParsePrivateKey err = %v, want ErrInvalidPEM", err)
+	}
+	bad := NewDecoder("not a pem block")
+	if bad.Public() != nil {
+		t.Error("Public of a failed Decoder is not nil")
+	}
+	if _, err := bad.Sign(nil, make([]byte, 32), crypto.SHA256); !errors.Is(err, ErrInvalidPEM) {
+		t.Errorf("Sign err = %v, want ErrInvalidPEM", err)
+	}
+}
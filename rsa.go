@@ -4,9 +4,11 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	_ "crypto/sha1"   // link SHA-1 so crypto.SHA1.New() never panics
 	_ "crypto/sha256" // link SHA-256 so crypto.SHA256.New() never panics
-	_ "crypto/sha512" // link SHA-512 so crypto.SHA512.New() never panics
-	"crypto/x509"
+	_ "crypto/sha3"   // link SHA3-256/384/512 so New() never panics
+	_ "crypto/sha512" // link SHA-512 and SHA-384 so New() never panics
+	"errors"
 	"fmt"
 )
 
@@ -20,92 +22,212 @@ func (h HashAlgorithm) hash() (crypto.Hash, error) {
 		alg = crypto.SHA256
 	case SHA512:
 		alg = crypto.SHA512
+	case SHA384:
+		alg = crypto.SHA384
+	case SHA1:
+		alg = crypto.SHA1
+	case SHA3_256:
+		alg = crypto.SHA3_256
+	case SHA3_384:
+		alg = crypto.SHA3_384
+	case SHA3_512:
+		alg = crypto.SHA3_512
 	default:
-		return 0, fmt.Errorf("unsupported hash algorithm: %d", int(h))
+		return 0, fmt.Errorf("%w: hash algorithm %d", ErrUnsupportedAlgorithm, int(h))
 	}
 	if !alg.Available() {
-		return 0, fmt.Errorf("hash algorithm %v is not linked into the binary", alg)
+		return 0, fmt.Errorf("%w: hash algorithm %v is not linked into the binary", ErrUnsupportedAlgorithm, alg)
 	}
 	return alg, nil
 }
 
-// EncryptByteRSA encrypts the given message (bytes) with RSA-OAEP and using SHA-256 (default) or SHA-512.
-func (e *Encoder) EncryptByteRSA(input []byte) (ciphertext []byte, err error) {
-	pubKey, err := x509.ParsePKIXPublicKey(e.PubKeyBlock.Bytes)
+// checkedHash is hash, refusing SHA1 unless allowLegacy is set.
+func (h HashAlgorithm) checkedHash(allowLegacy bool) (crypto.Hash, error) {
+	alg, err := h.hash()
 	if err != nil {
-		err = fmt.Errorf("error parsing public key: %v", err)
-		return
+		return 0, err
+	}
+	if alg == crypto.SHA1 && !allowLegacy {
+		return 0, fmt.Errorf("%w: %v is a legacy hash; set AllowLegacyHash to use it", ErrUnsupportedAlgorithm, alg)
+	}
+	return alg, nil
+}
+
+// newOAEPOptions resolves the OAEP settings shared by Encoder and Decoder.
+func newOAEPOptions(hashAlg HashAlgorithm, mgf1Hash *HashAlgorithm, allowLegacy bool, label []byte) (*rsa.OAEPOptions, error) {
+	h, err := hashAlg.checkedHash(allowLegacy)
+	if err != nil {
+		return nil, err
+	}
+	opts := &rsa.OAEPOptions{Hash: h, MGFHash: h, Label: label}
+	if mgf1Hash != nil {
+		if opts.MGFHash, err = mgf1Hash.checkedHash(allowLegacy); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// oaepOptions returns the OAEP settings of the Encoder.
+func (e *Encoder) oaepOptions() (*rsa.OAEPOptions, error) {
+	return newOAEPOptions(e.HashAlg, e.MGF1Hash, e.AllowLegacyHash, e.Label)
+}
+
+// oaepOptions returns the OAEP settings of the Decoder.
+func (d *Decoder) oaepOptions() (*rsa.OAEPOptions, error) {
+	return newOAEPOptions(d.HashAlg, d.MGF1Hash, d.AllowLegacyHash, d.Label)
+}
+
+// rsaPublicKey returns the Encoder's public key as an RSA key.
+func (e *Encoder) rsaPublicKey() (*rsa.PublicKey, error) {
+	pubKey, err := e.publicKey()
+	if err != nil {
+		return nil, err
 	}
 
 	rsaPubKey, ok := pubKey.(*rsa.PublicKey)
 	if !ok {
-		err = fmt.Errorf("failed to cast public key to RSA public key")
-		return
+		return nil, fmt.Errorf("%w: public key is not an RSA public key", ErrUnsupportedKeyType)
 	}
+	return rsaPubKey, nil
+}
 
-	hashAlg, err := e.HashAlg.hash()
+// rsaPrivateKey returns the Decoder's private key as an RSA key.
+func (d *Decoder) rsaPrivateKey() (*rsa.PrivateKey, error) {
+	priKey, err := d.privateKey()
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	rsaPriKey, ok := priKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: private key is not an RSA private key", ErrUnsupportedKeyType)
+	}
+	return rsaPriKey, nil
+}
+
+// checkRSAPublicKey mirrors the public key checks of crypto/rsa, so that
+// every encryption path reports them through the package's sentinels: a
+// modulus of at least 1024 bits ([ErrInvalidKeySize]) that is odd, and an odd
+// exponent in [3, 2^31-1] ([ErrUnsupportedKeyType]).
+func checkRSAPublicKey(pub *rsa.PublicKey) error {
+	switch {
+	case pub.N == nil:
+		return fmt.Errorf("%w: missing RSA public modulus", ErrUnsupportedKeyType)
+	case pub.N.BitLen() < 1024:
+		return fmt.Errorf("%w: %d-bit RSA keys are insecure", ErrInvalidKeySize, pub.N.BitLen())
+	case pub.N.Bit(0) == 0:
+		return fmt.Errorf("%w: RSA public modulus is even", ErrUnsupportedKeyType)
+	case pub.E < 2:
+		return fmt.Errorf("%w: RSA public exponent too small or negative", ErrUnsupportedKeyType)
+	case pub.E&1 == 0:
+		return fmt.Errorf("%w: RSA public exponent is even", ErrUnsupportedKeyType)
+	case pub.E > 1<<31-1:
+		return fmt.Errorf("%w: RSA public exponent too large", ErrUnsupportedKeyType)
+	}
+	return nil
+}
+
+// encryptOAEP encrypts input for pub with RSA-OAEP under opts.
+func encryptOAEP(pub *rsa.PublicKey, opts *rsa.OAEPOptions, input []byte) ([]byte, error) {
+	if err := checkRSAPublicKey(pub); err != nil {
+		return nil, err
 	}
+	var ciphertext []byte
+	var err error
+	if opts.MGFHash == opts.Hash {
+		// encrypt the data using RSA-OAEP
+		ciphertext, err = rsa.EncryptOAEP(opts.Hash.New(), rand.Reader, pub, input, opts.Label)
+	} else {
+		// rsa.EncryptOAEP cannot separate the MGF1 hash; see oaep.go
+		ciphertext, err = encryptOAEPMGF1(rand.Reader, pub, opts, input)
+	}
+	if errors.Is(err, rsa.ErrMessageTooLong) {
+		return nil, fmt.Errorf("%w: %w", ErrPlaintextTooLarge, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting data: %w", err)
+	}
+	return ciphertext, nil
+}
 
-	// encrypt the data using RSA-OAEP
-	ciphertext, err = rsa.EncryptOAEP(
-		hashAlg.New(),
-		rand.Reader,
-		rsaPubKey,
-		input,
-		nil,
-	)
+// decryptOAEP decrypts an RSA-OAEP ciphertext with priv under opts.
+func decryptOAEP(priv *rsa.PrivateKey, opts *rsa.OAEPOptions, ciphertext []byte) ([]byte, error) {
+	// Decrypt ignores the random argument for OAEP (it is legacy), so nil
+	// documents that intent.
+	plaintext, err := priv.Decrypt(nil, ciphertext, opts)
 	if err != nil {
-		err = fmt.Errorf("error encrypting data: %v", err)
-		return
+		return nil, fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
 	}
+	return plaintext, nil
+}
 
-	return
+// MaxOAEPPlaintextSize reports the largest message RSA-OAEP can encrypt in
+// one operation for pub and hashAlg: the modulus length minus twice the hash
+// length minus 2, for example 190 bytes for RSA-2048 with SHA-256. Anything
+// larger needs [Encoder.SealRSA].
+func MaxOAEPPlaintextSize(pub *rsa.PublicKey, hashAlg HashAlgorithm) (int, error) {
+	h, err := hashAlg.hash()
+	if err != nil {
+		return 0, err
+	}
+	n := pub.Size() - 2*h.Size() - 2
+	if n < 0 {
+		return 0, fmt.Errorf("%w: %d-bit key is too small for OAEP with %v", ErrInvalidKeySize, pub.N.BitLen(), h)
+	}
+	return n, nil
 }
 
-// EncryptRSA encrypts the given message (string) with RSA-OAEP and using SHA-256 (default) or SHA-512.
-func (e *Encoder) EncryptRSA(text string) (ciphertext []byte, err error) {
-	return e.EncryptByteRSA([]byte(text))
+// MaxRSAPlaintextSize reports the largest input [Encoder.EncryptByteRSA]
+// accepts for the Encoder's key and HashAlg; see [MaxOAEPPlaintextSize]. The
+// MGF1 hash and the label do not change the limit.
+func (e *Encoder) MaxRSAPlaintextSize() (int, error) {
+	pub, err := e.rsaPublicKey()
+	if err != nil {
+		return 0, err
+	}
+	return MaxOAEPPlaintextSize(pub, e.HashAlg)
 }
 
-// DecryptByteRSA decrypts the given message with RSA-OAEP and using SHA-256 (default) or SHA-512.
-func (d *Decoder) DecryptByteRSA(ciphertext []byte) (plaintext []byte, err error) {
-	priKey, err := x509.ParsePKCS8PrivateKey(d.PriKeyBlock.Bytes)
+// EncryptByteRSA encrypts the given message (bytes) with RSA-OAEP, using the
+// Encoder's HashAlg (SHA-256 by default), MGF1 hash and Label.
+// The message may be at most [Encoder.MaxRSAPlaintextSize] bytes; use
+// [Encoder.SealRSA] for larger payloads.
+func (e *Encoder) EncryptByteRSA(input []byte) (ciphertext []byte, err error) {
+	pub, err := e.rsaPublicKey()
 	if err != nil {
-		err = fmt.Errorf("error parsing private key: %v", err)
 		return
 	}
-
-	rsaPriKey, ok := priKey.(*rsa.PrivateKey)
-	if !ok {
-		err = fmt.Errorf("failed to cast private key to RSA private key")
+	opts, err := e.oaepOptions()
+	if err != nil {
 		return
 	}
+	return encryptOAEP(pub, opts, input)
+}
 
-	hashAlg, err := d.HashAlg.hash()
+// EncryptRSA encrypts the given message (string) with RSA-OAEP; see
+// [Encoder.EncryptByteRSA].
+func (e *Encoder) EncryptRSA(text string) (ciphertext []byte, err error) {
+	return e.EncryptByteRSA([]byte(text))
+}
+
+// DecryptByteRSA decrypts the given message with RSA-OAEP, using the
+// Decoder's HashAlg (SHA-256 by default), MGF1 hash and Label. They must match
+// the settings the message was encrypted with.
+func (d *Decoder) DecryptByteRSA(ciphertext []byte) (plaintext []byte, err error) {
+	priv, err := d.rsaPrivateKey()
 	if err != nil {
 		return
 	}
-
-	// decrypt the data using RSA-OAEP; DecryptOAEP ignores the random
-	// argument (it is legacy), so nil documents that intent.
-	plaintext, err = rsa.DecryptOAEP(
-		hashAlg.New(),
-		nil,
-		rsaPriKey,
-		ciphertext,
-		nil,
-	)
+	opts, err := d.oaepOptions()
 	if err != nil {
-		err = fmt.Errorf("error decrypting data: %v", err)
 		return
 	}
-
-	return
+	return decryptOAEP(priv, opts, ciphertext)
 }
 
-// DecryptRSA decrypts the given message with RSA-OAEP and using SHA-256 (default) or SHA-512.
+// DecryptRSA decrypts the given message with RSA-OAEP; see
+// [Decoder.DecryptByteRSA].
 func (d *Decoder) DecryptRSA(ciphertext []byte) (text string, err error) {
 	plaintext, err := d.DecryptByteRSA(ciphertext)
 	if err != nil {
@@ -0,0 +1,231 @@
+package crypt
+
+// Hybrid RSA encryption. Raw RSA-OAEP can only encrypt a few hundred bytes,
+// so SealRSA wraps a fresh 32-byte data key with OAEP and encrypts the
+// payload under it with AES-256-GCM.
+//
+// message = header || nonce(12) || ciphertext || tag(16)
+// stream  = header || root stream (see stream.go) under the data key
+//
+// header = version(1) || alg(1) || wrappedLen(2, big-endian) || wrappedKey
+//
+// version is rsaHybridVersion for a message and rsaHybridStreamVersion for a
+// stream, so neither reader accepts the other's data. alg is the Algorithm
+// the data key is used with. wrappedKey is the OAEP encryption of the data
+// key, as long as the RSA modulus. The header and the caller's AAD are
+// authenticated by the AEAD, so no byte of it can be changed unnoticed.
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// rsaHybridVersion tags a hybrid message.
+	rsaHybridVersion byte = 0x01
+
+	// rsaHybridStreamVersion tags a hybrid stream.
+	rsaHybridStreamVersion byte = 0x02
+
+	// rsaHybridFixedSize is version(1) || alg(1) || wrappedLen(2).
+	rsaHybridFixedSize = 4
+
+	// rsaHybridAlg is the AEAD the data key is used with.
+	rsaHybridAlg = AlgAesGcm
+
+	// rsaDataKeySize is the length of the per-message data key.
+	rsaDataKeySize = 32
+)
+
+// rsaHybridHeader draws a fresh data key, wraps it for pub and returns the
+// header that carries it.
+func rsaHybridHeader(version byte, pub *rsa.PublicKey, opts *rsa.OAEPOptions) (header, dataKey []byte, err error) {
+	dataKey = make([]byte, rsaDataKeySize)
+	if _, err = rand.Read(dataKey); err != nil {
+		return nil, nil, fmt.Errorf("error generating key: %w", err)
+	}
+
+	wrapped, err := encryptOAEP(pub, opts, dataKey)
+	if err != nil {
+		clear(dataKey)
+		return nil, nil, err
+	}
+
+	header = make([]byte, rsaHybridFixedSize, rsaHybridFixedSize+len(wrapped))
+	header[0] = version
+	header[1] = byte(rsaHybridAlg)
+	// an OAEP ciphertext is as long as the modulus; RSA keys stay far
+	// below 64 KiB (524288 bits), so the length fits the 16-bit field.
+	binary.BigEndian.PutUint16(header[2:], uint16(len(wrapped))) // #nosec G115
+	header = append(header, wrapped...)
+	return header, dataKey, nil
+}
+
+// parseRSAHybridHeader checks the fixed header fields against the expected
+// version and priv, and returns the length of the whole header.
+func parseRSAHybridHeader(version byte, priv *rsa.PrivateKey, fixed []byte) (int, error) {
+	if len(fixed) < rsaHybridFixedSize {
+		return 0, ErrCiphertextTooShort
+	}
+	if fixed[0] != version {
+		return 0, fmt.Errorf("%w: hybrid RSA version %d", ErrUnsupportedVersion, fixed[0])
+	}
+	if alg := Algorithm(fixed[1]); alg != rsaHybridAlg {
+		return 0, fmt.Errorf("%w: %v", ErrUnsupportedAlgorithm, alg)
+	}
+	if n := int(binary.BigEndian.Uint16(fixed[2:])); n != priv.Size() {
+		return 0, fmt.Errorf("%w: wrapped key is %d bytes, want %d", ErrMalformedCiphertext, n, priv.Size())
+	}
+	return rsaHybridFixedSize + priv.Size(), nil
+}
+
+// SealRSA encrypts a plaintext of any size for the Encoder's public key. It
+// wraps a fresh 256-bit data key with RSA-OAEP (with the Encoder's OAEP
+// settings) and encrypts plaintext under it with AES-256-GCM, binding
+// additionalData (which may be nil) without storing it. The output is readable by [Decoder.OpenRSA] and
+// grows by the RSA modulus length plus 32 bytes.
+func (e *Encoder) SealRSA(plaintext, additionalData []byte) ([]byte, error) {
+	pub, err := e.rsaPublicKey()
+	if err != nil {
+		return nil, err
+	}
+	opts, err := e.oaepOptions()
+	if err != nil {
+		return nil, err
+	}
+	header, dataKey, err := rsaHybridHeader(rsaHybridVersion, pub, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+
+	aead, err := NewAEAD(rsaHybridAlg, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(header), len(header)+aead.Overhead()+len(plaintext))
+	copy(out, header)
+	return aead.Seal(out, plaintext, headerAAD(header, additionalData))
+}
+
+// OpenRSA decrypts a message produced by [Encoder.SealRSA] with the
+// Decoder's private key. additionalData must match the value given at
+// encryption.
+func (d *Decoder) OpenRSA(ciphertext, additionalData []byte) ([]byte, error) {
+	priv, err := d.rsaPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	n, err := parseRSAHybridHeader(rsaHybridVersion, priv, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < n {
+		return nil, ErrCiphertextTooShort
+	}
+	header, payload := ciphertext[:n], ciphertext[n:]
+
+	opts, err := d.oaepOptions()
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := decryptOAEP(priv, opts, header[rsaHybridFixedSize:])
+	if err != nil {
+		return nil, err
+	}
+	defer clear(dataKey)
+	if len(dataKey) != rsaDataKeySize {
+		return nil, ErrAuthenticationFailed
+	}
+
+	aead, err := NewAEAD(rsaHybridAlg, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, payload, headerAAD(header, additionalData))
+}
+
+// SealRSAStream encrypts everything readable from src to dst for the
+// Encoder's public key and returns the number of plaintext bytes encrypted.
+// It wraps a fresh data key with RSA-OAEP, as [Encoder.SealRSA] does, and
+// encrypts the data as a chunked AES-GCM stream (see [NewStreamWriter]), so
+// memory use stays constant whatever the input size. On error the stream is
+// left without a final chunk and cannot be opened.
+func (e *Encoder) SealRSAStream(dst io.Writer, src io.Reader, additionalData []byte) (int64, error) {
+	pub, err := e.rsaPublicKey()
+	if err != nil {
+		return 0, err
+	}
+	opts, err := e.oaepOptions()
+	if err != nil {
+		return 0, err
+	}
+	header, dataKey, err := rsaHybridHeader(rsaHybridStreamVersion, pub, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer clear(dataKey)
+
+	if _, err := dst.Write(header); err != nil {
+		return 0, err
+	}
+	w, err := NewStreamWriter(rsaHybridAlg, dataKey, dst, headerAAD(header, additionalData))
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.ReadFrom(src)
+	if err != nil {
+		w.abort()
+		return n, err
+	}
+	return n, w.Close()
+}
+
+// OpenRSAStream decrypts a stream produced by [Encoder.SealRSAStream] from
+// src into dst and returns the number of plaintext bytes written. Chunks are
+// written out as they authenticate, so treat dst as unusable unless the call
+// returns without error.
+func (d *Decoder) OpenRSAStream(dst io.Writer, src io.Reader, additionalData []byte) (int64, error) {
+	priv, err := d.rsaPrivateKey()
+	if err != nil {
+		return 0, err
+	}
+
+	header := make([]byte, rsaHybridFixedSize, rsaHybridFixedSize+priv.Size())
+	if _, err := io.ReadFull(src, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, ErrCiphertextTooShort
+		}
+		return 0, err
+	}
+	n, err := parseRSAHybridHeader(rsaHybridStreamVersion, priv, header)
+	if err != nil {
+		return 0, err
+	}
+	header = header[:n]
+	if _, err := io.ReadFull(src, header[rsaHybridFixedSize:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return 0, ErrCiphertextTooShort
+		}
+		return 0, err
+	}
+
+	opts, err := d.oaepOptions()
+	if err != nil {
+		return 0, err
+	}
+	dataKey, err := decryptOAEP(priv, opts, header[rsaHybridFixedSize:])
+	if err != nil {
+		return 0, err
+	}
+	defer clear(dataKey)
+	if len(dataKey) != rsaDataKeySize {
+		return 0, ErrAuthenticationFailed
+	}
+
+	return decryptStream(rsaHybridAlg, dataKey, dst, src, headerAAD(header, additionalData))
+}
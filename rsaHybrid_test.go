@@ -0,0 +1,158 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSealOpenRSA(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	enc, dec := NewEncoder(pubPEM), NewDecoder(privPEM)
+	aad := []byte("invoice:9")
+
+	for _, n := range []int{0, 1, 190, 191, 100 << 10} {
+		plaintext := mustBytes(t, n)
+		sealed, err := enc.SealRSA(plaintext, aad)
+		if err != nil {
+			t.Fatalf("n=%d: SealRSA: %v", n, err)
+		}
+		if want := n + 4 + 256 + 12 + 16; len(sealed) != want {
+			t.Errorf("n=%d: len = %d, want %d", n, len(sealed), want)
+		}
+		got, err := dec.OpenRSA(sealed, aad)
+		if err != nil {
+			t.Fatalf("n=%d: OpenRSA: %v", n, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("n=%d: round-trip mismatch", n)
+		}
+	}
+}
+
+func TestSealOpenRSAErrors(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	_, otherPriv := testRSAKeyPair(t)
+	enc, dec := NewEncoder(pubPEM), NewDecoder(privPEM)
+
+	sealed, err := enc.SealRSA([]byte("payload"), []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mangle := func(f func(b []byte) []byte) []byte {
+		return f(append([]byte(nil), sealed...))
+	}
+
+	tests := []struct {
+		name string
+		dec  *Decoder
+		ct   []byte
+		aad  []byte
+		want error
+	}{
+		{"wrongAAD", dec, sealed, []byte("other"), ErrAuthenticationFailed},
+		{"wrongKey", NewDecoder(otherPriv), sealed, []byte("ad"), ErrAuthenticationFailed},
+		{"flipPayload", dec, mangle(func(b []byte) []byte { b[len(b)-1] ^= 1; return b }), []byte("ad"), ErrAuthenticationFailed},
+		{"flipWrappedKey", dec, mangle(func(b []byte) []byte { b[10] ^= 1; return b }), []byte("ad"), ErrAuthenticationFailed},
+		{"version", dec, mangle(func(b []byte) []byte { b[0] = rsaHybridStreamVersion; return b }), []byte("ad"), ErrUnsupportedVersion},
+		{"algorithm", dec, mangle(func(b []byte) []byte { b[1] = byte(AlgXChacha20poly1305); return b }), []byte("ad"), ErrUnsupportedAlgorithm},
+		{"wrappedLen", dec, mangle(func(b []byte) []byte { b[3]--; return b }), []byte("ad"), ErrMalformedCiphertext},
+		{"truncatedHeader", dec, sealed[:100], []byte("ad"), ErrCiphertextTooShort},
+		{"empty", dec, nil, nil, ErrCiphertextTooShort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.dec.OpenRSA(tt.ct, tt.aad); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealOpenRSAStream(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	enc, dec := NewEncoder(pubPEM), NewDecoder(privPEM)
+	aad := []byte("backup:2024")
+
+	for _, n := range []int{0, 10, DefaultStreamChunkSize, 3*DefaultStreamChunkSize + 1} {
+		plaintext := mustBytes(t, n)
+		var sealed bytes.Buffer
+		written, err := enc.SealRSAStream(&sealed, bytes.NewReader(plaintext), aad)
+		if err != nil || written != int64(n) {
+			t.Fatalf("n=%d: SealRSAStream = %d, %v", n, written, err)
+		}
+
+		var opened bytes.Buffer
+		read, err := dec.OpenRSAStream(&opened, bytes.NewReader(sealed.Bytes()), aad)
+		if err != nil || read != int64(n) {
+			t.Fatalf("n=%d: OpenRSAStream = %d, %v", n, read, err)
+		}
+		if !bytes.Equal(opened.Bytes(), plaintext) {
+			t.Errorf("n=%d: round-trip mismatch", n)
+		}
+
+		if _, err := dec.OpenRSAStream(io.Discard, bytes.NewReader(sealed.Bytes()), nil); !errors.Is(err, ErrAuthenticationFailed) {
+			t.Errorf("n=%d: wrong AAD err = %v, want ErrAuthenticationFailed", n, err)
+		}
+	}
+
+	// a message and a stream are not interchangeable
+	msg, err := enc.SealRSA([]byte("x"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.OpenRSAStream(io.Discard, bytes.NewReader(msg), nil); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("message as stream err = %v, want ErrUnsupportedVersion", err)
+	}
+	var stream bytes.Buffer
+	if _, err := enc.SealRSAStream(&stream, bytes.NewReader([]byte("x")), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.OpenRSA(stream.Bytes(), nil); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("stream as message err = %v, want ErrUnsupportedVersion", err)
+	}
+
+	// cutting the stream inside its header is reported, not mistaken for EOF
+	if _, err := dec.OpenRSAStream(io.Discard, bytes.NewReader(stream.Bytes()[:50]), nil); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("truncated header err = %v, want ErrCiphertextTooShort", err)
+	}
+}
+
+func TestMaxOAEPPlaintextSize(t *testing.T) {
+	pubPEM, _ := testRSAKeyPair(t)
+	enc := NewEncoder(pubPEM)
+	block, _ := x509.ParsePKIXPublicKey(enc.PubKeyBlock.Bytes)
+	pub := block.(*rsa.PublicKey)
+
+	for _, tt := range []struct {
+		hash HashAlgorithm
+		want int
+	}{
+		{SHA256, 256 - 2*32 - 2},
+		{SHA512, 256 - 2*64 - 2},
+	} {
+		got, err := MaxOAEPPlaintextSize(pub, tt.hash)
+		if err != nil || got != tt.want {
+			t.Errorf("MaxOAEPPlaintextSize(%d) = %d, %v, want %d", tt.hash, got, err, tt.want)
+		}
+
+		enc.HashAlg = tt.hash
+		if got, err := enc.MaxRSAPlaintextSize(); err != nil || got != tt.want {
+			t.Errorf("MaxRSAPlaintextSize = %d, %v, want %d", got, err, tt.want)
+		}
+		// the bound is exact: max succeeds, max+1 fails
+		if _, err := enc.EncryptByteRSA(make([]byte, tt.want)); err != nil {
+			t.Errorf("encrypting %d bytes: %v", tt.want, err)
+		}
+		if _, err := enc.EncryptByteRSA(make([]byte, tt.want+1)); !errors.Is(err, ErrPlaintextTooLarge) {
+			t.Errorf("encrypting %d bytes err = %v, want ErrPlaintextTooLarge", tt.want+1, err)
+		}
+	}
+
+	if _, err := MaxOAEPPlaintextSize(pub, HashAlgorithm(99)); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("unknown hash err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+}
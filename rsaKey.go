@@ -0,0 +1,167 @@
+package crypt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+const (
+	// minRSAKeyBits is the smallest modulus GenerateRSAKeyPair creates.
+	minRSAKeyBits = 2048
+
+	// maxRSAKeyBits bounds GenerateRSAKeyPair; larger keys take minutes to
+	// generate and gain nothing over switching algorithm.
+	maxRSAKeyBits = 16384
+)
+
+// GenerateRSAKeyPair generates an RSA key pair with a modulus of bits bits
+// (2048 to 16384; 3072 matches 128-bit security) and returns it PEM-encoded
+// in the formats [NewEncoder] and [NewDecoder] read: a PKIX "PUBLIC KEY" and
+// an unencrypted PKCS#8 "PRIVATE KEY". Store the private key accordingly.
+func GenerateRSAKeyPair(bits int) (publicKeyPEM, privateKeyPEM string, err error) {
+	if bits < minRSAKeyBits || bits > maxRSAKeyBits {
+		return "", "", fmt.Errorf("%w: %d-bit RSA key (want %d to %d)", ErrInvalidKeySize, bits, minRSAKeyBits, maxRSAKeyBits)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", "", fmt.Errorf("error generating key: %w", err)
+	}
+
+	dec := NewDecoderFromKey(key)
+	if dec.Err != nil {
+		return "", "", dec.Err
+	}
+	if privateKeyPEM, err = dec.PrivateKeyPEM(); err != nil {
+		return "", "", err
+	}
+	if publicKeyPEM, err = dec.PublicKeyPEM(); err != nil {
+		return "", "", err
+	}
+	return publicKeyPEM, privateKeyPEM, nil
+}
+
+// RSAPublicKey returns the Encoder's parsed RSA public key. The key is
+// shared with the Encoder and must not be modified.
+func (e *Encoder) RSAPublicKey() (*rsa.PublicKey, error) {
+	return e.rsaPublicKey()
+}
+
+// PublicKeyDER returns the Encoder's public key as a DER-encoded PKIX
+// SubjectPublicKeyInfo, whatever format it was read from.
+func (e *Encoder) PublicKeyDER() ([]byte, error) {
+	pub, err := e.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	return marshalPublicKey(pub)
+}
+
+// PublicKeyPEM returns the Encoder's public key as a PKIX "PUBLIC KEY" PEM
+// block, converting a PKCS#1 key or a certificate.
+func (e *Encoder) PublicKeyPEM() (string, error) {
+	der, err := e.PublicKeyDER()
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: pemPublicKey, Bytes: der})), nil
+}
+
+// Fingerprint returns the SHA-256 digest of the Encoder's DER-encoded PKIX
+// SubjectPublicKeyInfo, the value "openssl pkey -pubin -outform DER |
+// sha256sum" prints and HTTP public-key pins encode. It identifies the key
+// whatever format it was stored in.
+func (e *Encoder) Fingerprint() ([]byte, error) {
+	der, err := e.PublicKeyDER()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+	return sum[:], nil
+}
+
+// RSAPrivateKey returns the Decoder's parsed RSA private key. The key is
+// shared with the Decoder and must not be modified.
+func (d *Decoder) RSAPrivateKey() (*rsa.PrivateKey, error) {
+	return d.rsaPrivateKey()
+}
+
+// PrivateKeyDER returns the Decoder's private key as an unencrypted
+// DER-encoded PKCS#8 PrivateKeyInfo, whatever format it was read from.
+func (d *Decoder) PrivateKeyDER() ([]byte, error) {
+	priv, err := d.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+	}
+	return der, nil
+}
+
+// PrivateKeyPEM returns the Decoder's private key as an unencrypted PKCS#8
+// "PRIVATE KEY" PEM block, converting a PKCS#1 or decrypted key.
+func (d *Decoder) PrivateKeyPEM() (string, error) {
+	der, err := d.PrivateKeyDER()
+	if err != nil {
+		return "", err
+	}
+	defer clear(der)
+	return string(pem.EncodeToMemory(&pem.Block{Type: pemPrivateKey, Bytes: der})), nil
+}
+
+// PublicKeyPEM returns the public half of the Decoder's key as a PKIX
+// "PUBLIC KEY" PEM block, ready for [NewEncoder].
+func (d *Decoder) PublicKeyPEM() (string, error) {
+	pub, err := d.publicKey()
+	if err != nil {
+		return "", err
+	}
+	der, err := marshalPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: pemPublicKey, Bytes: der})), nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the public half of the
+// Decoder's key, equal to [Encoder.Fingerprint] for the matching public key.
+func (d *Decoder) Fingerprint() ([]byte, error) {
+	pub, err := d.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	der, err := marshalPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+	return sum[:], nil
+}
+
+// publicKey returns the public half of the Decoder's private key.
+func (d *Decoder) publicKey() (crypto.PublicKey, error) {
+	priv, err := d.privateKey()
+	if err != nil {
+		return nil, err
+	}
+	k, ok := priv.(interface{ Public() crypto.PublicKey })
+	if !ok {
+		return nil, fmt.Errorf("%w: %T has no public key", ErrUnsupportedKeyType, priv)
+	}
+	return k.Public(), nil
+}
+
+// marshalPublicKey encodes pub as a PKIX SubjectPublicKeyInfo.
+func marshalPublicKey(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedKeyType, err)
+	}
+	return der, nil
+}
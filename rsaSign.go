@@ -0,0 +1,132 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"io"
+)
+
+// RSASignatureScheme selects the padding of RSA signatures made by
+// [Decoder.SignRSA] and checked by [Encoder.VerifyRSA].
+type RSASignatureScheme int
+
+const (
+	// RSAPSS selects RSASSA-PSS (RFC 8017) with MGF1 over the signing hash.
+	// It is the default (the zero value).
+	RSAPSS RSASignatureScheme = iota
+	// RSAPKCS1v15 selects RSASSA-PKCS1-v1_5, for peers that cannot verify
+	// PSS (JWT RS256, older X.509 tooling).
+	RSAPKCS1v15
+)
+
+// rsaSignOptions resolves the hash and the PSS options for a signature; pss
+// is nil for PKCS#1 v1.5. A zero salt length means the hash length when
+// signing and any length when verifying.
+func rsaSignOptions(hashAlg HashAlgorithm, scheme RSASignatureScheme, saltLength int, allowLegacy, signing bool) (h crypto.Hash, pss *rsa.PSSOptions, err error) {
+	if h, err = hashAlg.checkedHash(allowLegacy); err != nil {
+		return 0, nil, err
+	}
+
+	switch scheme {
+	case RSAPSS:
+	case RSAPKCS1v15:
+		return h, nil, nil
+	default:
+		return 0, nil, fmt.Errorf("%w: signature scheme %d", ErrUnsupportedAlgorithm, int(scheme))
+	}
+
+	pss = &rsa.PSSOptions{Hash: h, SaltLength: saltLength}
+	switch {
+	case saltLength < 0:
+		return 0, nil, fmt.Errorf("%w: negative PSS salt length %d", ErrInvalidParameters, saltLength)
+	case saltLength == 0 && signing:
+		pss.SaltLength = rsa.PSSSaltLengthEqualsHash
+	case saltLength == 0:
+		pss.SaltLength = rsa.PSSSaltLengthAuto
+	}
+	return h, pss, nil
+}
+
+// digestReader hashes everything readable from r.
+func digestReader(h crypto.Hash, r io.Reader) ([]byte, error) {
+	d := h.New()
+	if _, err := io.Copy(d, r); err != nil {
+		return nil, err
+	}
+	return d.Sum(nil), nil
+}
+
+// SignRSA signs message with the Decoder's private key, using HashAlg and
+// SignScheme (RSA-PSS with a hash-length salt by default). The signature is
+// as long as the RSA modulus.
+func (d *Decoder) SignRSA(message []byte) ([]byte, error) {
+	return d.SignRSAReader(bytes.NewReader(message))
+}
+
+// SignRSAReader signs everything readable from r, as [Decoder.SignRSA] does
+// for a message. The input is hashed as it is read, so a large file never
+// has to fit in memory; the signature is the same as SignRSA over the same
+// bytes.
+func (d *Decoder) SignRSAReader(r io.Reader) ([]byte, error) {
+	priv, err := d.rsaPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	h, pss, err := rsaSignOptions(d.HashAlg, d.SignScheme, d.PSSSaltLength, d.AllowLegacyHash, true)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := digestReader(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var signature []byte
+	if pss != nil {
+		signature, err = rsa.SignPSS(rand.Reader, priv, h, digest, pss)
+	} else {
+		signature, err = rsa.SignPKCS1v15(nil, priv, h, digest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error signing data: %w", err)
+	}
+	return signature, nil
+}
+
+// VerifyRSA checks signature over message with the Encoder's public key,
+// using HashAlg, SignScheme and PSSSaltLength. It returns nil only for a
+// valid signature and an error wrapping [ErrInvalidSignature] otherwise.
+func (e *Encoder) VerifyRSA(message, signature []byte) error {
+	return e.VerifyRSAReader(bytes.NewReader(message), signature)
+}
+
+// VerifyRSAReader checks signature over everything readable from r, as
+// [Encoder.VerifyRSA] does for a message, hashing the input as it is read. A
+// read error is returned as is, not as [ErrInvalidSignature].
+func (e *Encoder) VerifyRSAReader(r io.Reader, signature []byte) error {
+	pub, err := e.rsaPublicKey()
+	if err != nil {
+		return err
+	}
+	h, pss, err := rsaSignOptions(e.HashAlg, e.SignScheme, e.PSSSaltLength, e.AllowLegacyHash, false)
+	if err != nil {
+		return err
+	}
+	digest, err := digestReader(h, r)
+	if err != nil {
+		return err
+	}
+
+	if pss != nil {
+		err = rsa.VerifyPSS(pub, h, digest, signature, pss)
+	} else {
+		err = rsa.VerifyPKCS1v15(pub, h, digest, signature)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"testing"
+)
+
+func TestSignVerifyRSA(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	msg := []byte("release v1.2.3")
+
+	for _, scheme := range []RSASignatureScheme{RSAPSS, RSAPKCS1v15} {
+		for _, hashAlg := range []HashAlgorithm{SHA256, SHA384, SHA512, SHA1, SHA3_256} {
+			dec := NewDecoder(privPEM)
+			dec.HashAlg, dec.SignScheme, dec.AllowLegacyHash = hashAlg, scheme, true
+			enc := NewEncoder(pubPEM)
+			enc.HashAlg, enc.SignScheme, enc.AllowLegacyHash = hashAlg, scheme, true
+
+			sig, err := dec.SignRSA(msg)
+			if err != nil {
+				t.Fatalf("scheme %d hash %d: SignRSA: %v", scheme, hashAlg, err)
+			}
+			if len(sig) != 256 {
+				t.Errorf("scheme %d hash %d: signature is %d bytes, want 256", scheme, hashAlg, len(sig))
+			}
+			if err := enc.VerifyRSA(msg, sig); err != nil {
+				t.Errorf("scheme %d hash %d: VerifyRSA: %v", scheme, hashAlg, err)
+			}
+			if err := enc.VerifyRSA([]byte("release v1.2.4"), sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("scheme %d hash %d: altered message err = %v, want ErrInvalidSignature", scheme, hashAlg, err)
+			}
+			sig[0] ^= 1
+			if err := enc.VerifyRSA(msg, sig); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("scheme %d hash %d: altered signature err = %v, want ErrInvalidSignature", scheme, hashAlg, err)
+			}
+		}
+	}
+}
+
+func TestSignRSAInterop(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	enc, dec := NewEncoder(pubPEM), NewDecoder(privPEM)
+	parsed, err := x509.ParsePKIXPublicKey(enc.PubKeyBlock.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := parsed.(*rsa.PublicKey)
+	msg := []byte("hello")
+	digest := sha256.Sum256(msg)
+
+	// the default is PSS with a salt as long as the hash
+	sig, err := dec.SignRSA(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPSS(pub, crypto.SHA256, digest[:], sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+		t.Errorf("rsa.VerifyPSS: %v", err)
+	}
+
+	dec.SignScheme = RSAPKCS1v15
+	sig, err = dec.SignRSA(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("rsa.VerifyPKCS1v15: %v", err)
+	}
+	if err := enc.VerifyRSA(msg, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("PKCS#1 v1.5 signature verified as PSS: err = %v", err)
+	}
+}
+
+func TestSignRSAReader(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	enc, dec := NewEncoder(pubPEM), NewDecoder(privPEM)
+	data := mustBytes(t, 1<<20)
+
+	sig, err := dec.SignRSAReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("SignRSAReader: %v", err)
+	}
+	if err := enc.VerifyRSA(data, sig); err != nil {
+		t.Errorf("VerifyRSA of a streamed signature: %v", err)
+	}
+	if err := enc.VerifyRSAReader(bytes.NewReader(data), sig); err != nil {
+		t.Errorf("VerifyRSAReader: %v", err)
+	}
+	if err := enc.VerifyRSAReader(bytes.NewReader(data[1:]), sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("truncated input err = %v, want ErrInvalidSignature", err)
+	}
+
+	// PKCS#1 v1.5 is deterministic, so both paths give the same bytes
+	dec.SignScheme = RSAPKCS1v15
+	a, err := dec.SignRSA(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := dec.SignRSAReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("SignRSA and SignRSAReader differ over the same input")
+	}
+
+	boom := errors.New("disk gone")
+	if _, err := dec.SignRSAReader(errReader{boom}); !errors.Is(err, boom) {
+		t.Errorf("SignRSAReader read error = %v, want %v", err, boom)
+	}
+	if err := enc.VerifyRSAReader(errReader{boom}, a); !errors.Is(err, boom) || errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyRSAReader read error = %v, want %v only", err, boom)
+	}
+}
+
+func TestRSAPSSSaltLength(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	enc, dec := NewEncoder(pubPEM), NewDecoder(privPEM)
+	msg := []byte("salted")
+
+	dec.PSSSaltLength = 20
+	sig, err := dec.SignRSA(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the zero value accepts any salt length; a set length must match
+	if err := enc.VerifyRSA(msg, sig); err != nil {
+		t.Errorf("auto salt length: %v", err)
+	}
+	enc.PSSSaltLength = 20
+	if err := enc.VerifyRSA(msg, sig); err != nil {
+		t.Errorf("matching salt length: %v", err)
+	}
+	enc.PSSSaltLength = 32
+	if err := enc.VerifyRSA(msg, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("mismatched salt length err = %v, want ErrInvalidSignature", err)
+	}
+
+	dec.PSSSaltLength = -1
+	if _, err := dec.SignRSA(msg); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("negative salt length err = %v, want ErrInvalidParameters", err)
+	}
+	dec.PSSSaltLength = 256
+	if _, err := dec.SignRSA(msg); err == nil {
+		t.Error("salt longer than the key allows succeeded, want failure")
+	}
+}
+
+func TestSignRSAErrors(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	_, otherPriv := testRSAKeyPair(t)
+	msg := []byte("x")
+
+	sig, err := NewDecoder(otherPriv).SignRSA(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEncoder(pubPEM).VerifyRSA(msg, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("wrong key err = %v, want ErrInvalidSignature", err)
+	}
+
+	dec := NewDecoder(privPEM)
+	dec.HashAlg = SHA1
+	if _, err := dec.SignRSA(msg); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("SHA-1 without AllowLegacyHash err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+	dec = NewDecoder(privPEM)
+	dec.SignScheme = RSASignatureScheme(9)
+	if _, err := dec.SignRSA(msg); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("unknown scheme err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+
+	enc := NewEncoder(pubPEM)
+	enc.HashAlg = SHA512
+	sig, err = NewDecoder(privPEM).SignRSA(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.VerifyRSA(msg, sig); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("hash mismatch err = %v, want ErrInvalidSignature", err)
+	}
+}
+
+// errReader fails every Read with err.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -2,11 +2,14 @@ package crypt
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
+	"math/big"
 	"testing"
 )
 
@@ -234,3 +237,149 @@ func TestRSAMalformedKeyBytes(t *testing.T) {
 		}
 	})
 }
+
+func TestRSAOAEPOptions(t *testing.T) {
+	pubPEM, privPEM := testRSAKeyPair(t)
+	block, _ := pem.Decode([]byte(privPEM))
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv := parsed.(*rsa.PrivateKey)
+	hashes := map[HashAlgorithm]crypto.Hash{
+		SHA1: crypto.SHA1, SHA256: crypto.SHA256, SHA384: crypto.SHA384, SHA512: crypto.SHA512,
+	}
+	msg := []byte("Hello world")
+
+	for hashAlg, h := range hashes {
+		for mgfAlg, mgf := range hashes {
+			enc := NewEncoder(pubPEM)
+			enc.HashAlg, enc.MGF1Hash = hashAlg, &mgfAlg
+			enc.Label, enc.AllowLegacyHash = []byte("orders"), true
+			dec := NewDecoder(privPEM)
+			dec.HashAlg, dec.MGF1Hash = hashAlg, &mgfAlg
+			dec.Label, dec.AllowLegacyHash = []byte("orders"), true
+
+			ciphertext, err := enc.EncryptByteRSA(msg)
+			if err != nil {
+				t.Fatalf("%v/%v: EncryptByteRSA: %v", h, mgf, err)
+			}
+			if got, err := dec.DecryptByteRSA(ciphertext); err != nil || !bytes.Equal(got, msg) {
+				t.Errorf("%v/%v: DecryptByteRSA = %q, %v", h, mgf, got, err)
+			}
+
+			// crypto/rsa is an independent decoder of the same padding
+			opts := &rsa.OAEPOptions{Hash: h, MGFHash: mgf, Label: []byte("orders")}
+			if got, err := priv.Decrypt(nil, ciphertext, opts); err != nil || !bytes.Equal(got, msg) {
+				t.Errorf("%v/%v: rsa.PrivateKey.Decrypt = %q, %v", h, mgf, got, err)
+			}
+
+			dec.Label = []byte("invoices")
+			if _, err := dec.DecryptByteRSA(ciphertext); !errors.Is(err, ErrAuthenticationFailed) {
+				t.Errorf("%v/%v: wrong label err = %v, want ErrAuthenticationFailed", h, mgf, err)
+			}
+			if mgfAlg != hashAlg {
+				dec.Label, dec.MGF1Hash = []byte("orders"), nil
+				if _, err := dec.DecryptByteRSA(ciphertext); !errors.Is(err, ErrAuthenticationFailed) {
+					t.Errorf("%v/%v: MGF1 mismatch err = %v, want ErrAuthenticationFailed", h, mgf, err)
+				}
+			}
+		}
+	}
+
+	t.Run("mgf1HashUnset", func(t *testing.T) {
+		// a nil MGF1Hash follows HashAlg
+		enc := NewEncoder(pubPEM)
+		enc.HashAlg = SHA512
+		ciphertext, err := enc.EncryptByteRSA(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rsa.DecryptOAEP(crypto.SHA512.New(), nil, priv, ciphertext, nil); err != nil {
+			t.Errorf("SHA-512 with SHA-512 MGF1: %v", err)
+		}
+	})
+
+	t.Run("legacyRefused", func(t *testing.T) {
+		enc := NewEncoder(pubPEM)
+		enc.HashAlg = SHA1
+		if _, err := enc.EncryptByteRSA(msg); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Errorf("SHA-1 OAEP err = %v, want ErrUnsupportedAlgorithm", err)
+		}
+
+		dec := NewDecoder(privPEM)
+		sha1 := SHA1
+		dec.MGF1Hash = &sha1
+		if _, err := dec.DecryptByteRSA(make([]byte, 256)); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Errorf("SHA-1 MGF1 err = %v, want ErrUnsupportedAlgorithm", err)
+		}
+		sealed, err := NewEncoder(pubPEM).SealRSA(msg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dec.OpenRSA(sealed, nil); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Errorf("OpenRSA with SHA-1 MGF1 err = %v, want ErrUnsupportedAlgorithm", err)
+		}
+	})
+
+	t.Run("plaintextTooLong", func(t *testing.T) {
+		enc := NewEncoder(pubPEM)
+		sha1 := SHA1
+		enc.HashAlg, enc.MGF1Hash = SHA384, &sha1
+		enc.AllowLegacyHash = true
+		max, err := enc.MaxRSAPlaintextSize()
+		if err != nil || max != 256-2*48-2 {
+			t.Fatalf("MaxRSAPlaintextSize = %d, %v, want %d", max, err, 256-2*48-2)
+		}
+		if _, err := enc.EncryptByteRSA(make([]byte, max)); err != nil {
+			t.Errorf("encrypting %d bytes: %v", max, err)
+		}
+		if _, err := enc.EncryptByteRSA(make([]byte, max+1)); !errors.Is(err, ErrPlaintextTooLarge) {
+			t.Errorf("encrypting %d bytes err = %v, want ErrPlaintextTooLarge", max+1, err)
+		}
+	})
+
+	t.Run("weakKey", func(t *testing.T) {
+		opts := &rsa.OAEPOptions{Hash: crypto.SHA256, MGFHash: crypto.SHA1}
+		short := new(big.Int).Rsh(priv.N, 1536)
+		short.SetBit(short, 0, 1)
+		for name, tc := range map[string]struct {
+			pub  *rsa.PublicKey
+			want error
+		}{
+			"shortModulus": {&rsa.PublicKey{N: short, E: 65537}, ErrInvalidKeySize},
+			"evenModulus":  {&rsa.PublicKey{N: new(big.Int).SetBit(priv.N, 0, 0), E: 65537}, ErrUnsupportedKeyType},
+			"exponentOne":  {&rsa.PublicKey{N: priv.N, E: 1}, ErrUnsupportedKeyType},
+			"evenExponent": {&rsa.PublicKey{N: priv.N, E: 65536}, ErrUnsupportedKeyType},
+		} {
+			for _, o := range []*rsa.OAEPOptions{opts, {Hash: crypto.SHA256, MGFHash: crypto.SHA256}} {
+				if _, err := encryptOAEP(tc.pub, o, msg); !errors.Is(err, tc.want) {
+					t.Errorf("%s: encryptOAEP err = %v, want %v", name, err, tc.want)
+				}
+			}
+		}
+		if _, err := encryptOAEP(&priv.PublicKey, opts, msg); err != nil {
+			t.Errorf("valid key: %v", err)
+		}
+	})
+
+	t.Run("hybrid", func(t *testing.T) {
+		enc := NewEncoder(pubPEM)
+		sha512 := SHA512
+		enc.MGF1Hash, enc.Label = &sha512, []byte("wrap")
+		dec := NewDecoder(privPEM)
+		dec.MGF1Hash, dec.Label = &sha512, []byte("wrap")
+
+		sealed, err := enc.SealRSA(msg, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := dec.OpenRSA(sealed, nil); err != nil || !bytes.Equal(got, msg) {
+			t.Errorf("OpenRSA = %q, %v", got, err)
+		}
+		dec.Label = nil
+		if _, err := dec.OpenRSA(sealed, nil); !errors.Is(err, ErrAuthenticationFailed) {
+			t.Errorf("OpenRSA without label err = %v, want ErrAuthenticationFailed", err)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package crypt
+
+// Anonymous sealed boxes, byte-for-byte compatible with libsodium's
+// crypto_box_seal (and TweetNaCl-based ports such as tweetnacl-sealedbox-js):
+//
+// sealed = ephemeralPublicKey(32) || XSalsa20-Poly1305(message)
+// nonce  = BLAKE2b-192(ephemeralPublicKey || recipientPublicKey)
+// key    = HSalsa20(X25519(ephemeralPrivateKey, recipientPublicKey))
+//
+// The sender's ephemeral key is discarded after sealing, so a box carries no
+// sender identity and cannot be opened by the sender either.
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	// X25519KeySize is the length of an X25519 public or private key.
+	X25519KeySize = 32
+
+	// SealAnonymousOverhead is how much longer a sealed box is than its
+	// message: the ephemeral public key and the Poly1305 tag.
+	SealAnonymousOverhead = box.AnonymousOverhead
+)
+
+// GenerateX25519KeyPair generates an X25519 key pair for [SealAnonymous] and
+// [OpenAnonymous], in the raw 32-byte form libsodium's crypto_box_keypair
+// returns.
+func GenerateX25519KeyPair() (publicKey, privateKey []byte, err error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating key: %w", err)
+	}
+	return priv.PublicKey().Bytes(), priv.Bytes(), nil
+}
+
+// X25519PublicKey returns the public key of a raw 32-byte X25519 private key,
+// as libsodium's crypto_scalarmult_base does.
+func X25519PublicKey(privateKey []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: X25519 private key of %d bytes (want %d)", ErrInvalidKeySize, len(privateKey), X25519KeySize)
+	}
+	return priv.PublicKey().Bytes(), nil
+}
+
+// SealAnonymous encrypts message to the holder of the X25519 private key for
+// recipientPublicKey, producing the same format as libsodium's
+// crypto_box_seal, so a box sealed by a browser or mobile app opens here and
+// the other way round. The result is [SealAnonymousOverhead] bytes longer
+// than message. The box is authenticated but anonymous: anyone holding the
+// public key can seal, so authenticate the sender separately if it matters.
+func SealAnonymous(recipientPublicKey, message []byte) ([]byte, error) {
+	return sealAnonymous(rand.Reader, recipientPublicKey, message)
+}
+
+// sealAnonymous is SealAnonymous with the ephemeral key read from random.
+func sealAnonymous(random io.Reader, recipientPublicKey, message []byte) ([]byte, error) {
+	pub, err := x25519PeerKey(recipientPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := box.SealAnonymous(nil, message, pub, random)
+	if err != nil {
+		return nil, fmt.Errorf("error sealing box: %w", err)
+	}
+	return sealed, nil
+}
+
+// OpenAnonymous decrypts a box made by [SealAnonymous] or libsodium's
+// crypto_box_seal with the recipient's raw X25519 private key. A box that is
+// truncated, altered or sealed to another key wraps [ErrAuthenticationFailed]
+// or [ErrCiphertextTooShort].
+func OpenAnonymous(privateKey, sealed []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: X25519 private key of %d bytes (want %d)", ErrInvalidKeySize, len(privateKey), X25519KeySize)
+	}
+	if len(sealed) < SealAnonymousOverhead {
+		return nil, ErrCiphertextTooShort
+	}
+	// libsodium refuses an ephemeral key that forces an all-zero shared
+	// secret; so do we.
+	if _, err := x25519PeerKey(sealed[:X25519KeySize]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAuthenticationFailed, err)
+	}
+
+	var pub, key [X25519KeySize]byte
+	copy(pub[:], priv.PublicKey().Bytes())
+	copy(key[:], priv.Bytes())
+	defer clear(key[:])
+	message, ok := box.OpenAnonymous(nil, sealed, &pub, &key)
+	if !ok {
+		return nil, ErrAuthenticationFailed
+	}
+	return message, nil
+}
+
+// x25519PeerKey checks a peer's raw X25519 public key. A low-order point,
+// which would make the shared secret all zeros whatever the other key, wraps
+// [ErrUnsupportedKeyType].
+func x25519PeerKey(publicKey []byte) (*[X25519KeySize]byte, error) {
+	pub, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: X25519 public key of %d bytes (want %d)", ErrInvalidKeySize, len(publicKey), X25519KeySize)
+	}
+	// any private key detects a low-order point: ECDH fails for all of them
+	probe, err := ecdh.X25519().NewPrivateKey(x25519Probe[:])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := probe.ECDH(pub); err != nil {
+		return nil, fmt.Errorf("%w: low-order X25519 public key", ErrUnsupportedKeyType)
+	}
+	var out [X25519KeySize]byte
+	copy(out[:], publicKey)
+	return &out, nil
+}
+
+// x25519Probe is a fixed scalar used only to test public keys for low order.
+var x25519Probe = [X25519KeySize]byte{1}
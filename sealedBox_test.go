@@ -0,0 +1,150 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pilinux/crypt/cryptotest"
+)
+
+// Vectors from libsodium 1.0.18. The key pair is
+// crypto_box_seed_keypair(seed = 00 01 .. 1f); the boxes are crypto_box_seal
+// output, except fixedEphemeral, which is crypto_box_seal's construction
+// computed with libsodium primitives and the ephemeral private key 42 42 ..
+// 42, so it pins the exact bytes.
+const (
+	sodiumPublicKey  = "4701d08488451f545a409fb58ae3e58581ca40ac3f7f114698cd71deac73ca01"
+	sodiumPrivateKey = "3d94eea49c580aef816935762be049559d6d1440dede12e6a125f1841fff8e6f"
+)
+
+var sodiumBoxes = []struct {
+	name, message, sealed string
+}{
+	{
+		name:    "empty",
+		message: "",
+		sealed:  "96067b4120a6cd3967517c09aa1377176e92b3882bdd179d83390a457f72084c04d37d240c8c557c79e9a0ee503545e5",
+	},
+	{
+		name:    "message",
+		message: "hello, libsodium",
+		sealed:  "c59997515e4434465470a392868aea5218dd97d98420f44e738f9ba61818b23037679993adeb77d178dda4da80fdfce3595104640353c1fc839d9133a05e1203",
+	},
+	{
+		name:    "fixedEphemeral",
+		message: "hello, libsodium",
+		sealed:  "132c442be010fbd57e72603328aa76e71fccc1503aae219327d14d9c9993f472099b67f4f716a059c325dc15dbfccde482d896f078f98469e8d9659035933f97",
+	},
+}
+
+func TestSealAnonymousLibsodium(t *testing.T) {
+	pub, priv := mustHex(t, sodiumPublicKey), mustHex(t, sodiumPrivateKey)
+	if got, err := X25519PublicKey(priv); err != nil || !bytes.Equal(got, pub) {
+		t.Fatalf("X25519PublicKey = %x, %v; want %x", got, err, pub)
+	}
+
+	for _, v := range sodiumBoxes {
+		t.Run(v.name, func(t *testing.T) {
+			got, err := OpenAnonymous(priv, mustHex(t, v.sealed))
+			if err != nil {
+				t.Fatalf("OpenAnonymous: %v", err)
+			}
+			if string(got) != v.message {
+				t.Errorf("OpenAnonymous = %q, want %q", got, v.message)
+			}
+		})
+	}
+
+	// the same ephemeral key gives libsodium's exact bytes
+	v := sodiumBoxes[2]
+	sealed, err := sealAnonymous(cryptotest.NewFixedReader(bytes.Repeat([]byte{0x42}, 32)), pub, []byte(v.message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := mustHex(t, v.sealed); !bytes.Equal(sealed, want) {
+		t.Errorf("sealed = %x, want %x", sealed, want)
+	}
+}
+
+func TestSealAnonymous(t *testing.T) {
+	pub, priv, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pub) != X25519KeySize || len(priv) != X25519KeySize {
+		t.Fatalf("key lengths %d and %d, want %d", len(pub), len(priv), X25519KeySize)
+	}
+
+	msg := []byte("card number 4111 1111 1111 1111")
+	a, err := SealAnonymous(pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := SealAnonymous(pub, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != len(msg)+SealAnonymousOverhead {
+		t.Errorf("sealed box is %d bytes, want %d", len(a), len(msg)+SealAnonymousOverhead)
+	}
+	if bytes.Equal(a, b) {
+		t.Error("two seals of one message are identical")
+	}
+	for _, sealed := range [][]byte{a, b} {
+		if got, err := OpenAnonymous(priv, sealed); err != nil || !bytes.Equal(got, msg) {
+			t.Errorf("OpenAnonymous = %q, %v", got, err)
+		}
+	}
+
+	_, otherPriv, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenAnonymous(otherPriv, a); !errors.Is(err, ErrAuthenticationFailed) {
+		t.Errorf("wrong key err = %v, want ErrAuthenticationFailed", err)
+	}
+	for _, i := range []int{0, 32, len(a) - 1} {
+		tampered := bytes.Clone(a)
+		tampered[i] ^= 1
+		if _, err := OpenAnonymous(priv, tampered); !errors.Is(err, ErrAuthenticationFailed) {
+			t.Errorf("flipped byte %d err = %v, want ErrAuthenticationFailed", i, err)
+		}
+	}
+	if _, err := OpenAnonymous(priv, a[:SealAnonymousOverhead-1]); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("truncated err = %v, want ErrCiphertextTooShort", err)
+	}
+}
+
+func TestSealAnonymousKeys(t *testing.T) {
+	for _, n := range []int{0, 31, 33} {
+		if _, err := SealAnonymous(make([]byte, n), []byte("x")); !errors.Is(err, ErrInvalidKeySize) {
+			t.Errorf("%d-byte public key err = %v, want ErrInvalidKeySize", n, err)
+		}
+		if _, err := OpenAnonymous(make([]byte, n), make([]byte, 64)); !errors.Is(err, ErrInvalidKeySize) {
+			t.Errorf("%d-byte private key err = %v, want ErrInvalidKeySize", n, err)
+		}
+		if _, err := X25519PublicKey(make([]byte, n)); !errors.Is(err, ErrInvalidKeySize) {
+			t.Errorf("X25519PublicKey(%d bytes) err = %v, want ErrInvalidKeySize", n, err)
+		}
+	}
+
+	// low-order points force an all-zero shared secret
+	lowOrder := [][]byte{
+		make([]byte, 32),
+		append([]byte{1}, make([]byte, 31)...),
+	}
+	_, priv, err := GenerateX25519KeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range lowOrder {
+		if _, err := SealAnonymous(p, []byte("x")); !errors.Is(err, ErrUnsupportedKeyType) {
+			t.Errorf("SealAnonymous to %x err = %v, want ErrUnsupportedKeyType", p, err)
+		}
+		forged := append(bytes.Clone(p), make([]byte, 17)...)
+		if _, err := OpenAnonymous(priv, forged); !errors.Is(err, ErrAuthenticationFailed) {
+			t.Errorf("OpenAnonymous with ephemeral key %x err = %v, want ErrAuthenticationFailed", p, err)
+		}
+	}
+}
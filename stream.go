@@ -0,0 +1,524 @@
+package crypt
+
+// Caller-keyed streaming encryption. It follows the envelope streaming format
+// (a chunked STREAM construction) but takes the key directly instead of going
+// through the envelope key hierarchy.
+//
+// stream = header || chunk_0 || ... || chunk_n
+//
+// header = version(1) || alg(1) || chunkSize(4, big-endian) || salt(16)
+//
+// Every stream derives its own key, HKDF-SHA256(key, salt, label || alg),
+// from the caller key and a random salt, so chunk nonces can be a plain
+// counter: zero padding || counter(8, big-endian) || final flag(1). The
+// header and the caller's AAD are authenticated with every chunk, and the
+// counter and flag pin each chunk to its position, so reordered, duplicated,
+// dropped or truncated chunks fail to open.
+
+import (
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Chunk sizes for the streaming format. The chunk size is the amount of
+// plaintext sealed at a time; each chunk costs one extra authentication tag.
+const (
+	// DefaultStreamChunkSize is the chunk size of [NewStreamWriter].
+	DefaultStreamChunkSize = 64 << 10 // 64 KiB
+
+	// MinStreamChunkSize keeps the per-chunk tag overhead below 2%.
+	MinStreamChunkSize = 1 << 10 // 1 KiB
+
+	// MaxStreamChunkSize caps the buffer a reader allocates for a chunk size
+	// read from an untrusted stream header.
+	MaxStreamChunkSize = 64 << 20 // 64 MiB
+)
+
+const (
+	// streamVersion tags the root streaming format.
+	streamVersion byte = 0x01
+
+	// streamSaltSize is the random per-stream salt the stream key is derived
+	// with.
+	streamSaltSize = 16
+
+	// streamHeaderSize is version(1) || alg(1) || chunkSize(4) || salt.
+	streamHeaderSize = 2 + 4 + streamSaltSize
+
+	// streamTagSize is the tag length of both stream ciphers.
+	streamTagSize = 16
+
+	// streamKeyLabel is the HKDF info prefix of the per-stream key; the
+	// algorithm byte follows it.
+	streamKeyLabel = "pilinux/crypt:stream:v1"
+)
+
+// streamAEAD derives the per-stream key from the caller key and salt and
+// builds the stream cipher for alg. AES-GCM keeps the caller key's length,
+// so an AES-128 key yields an AES-128 stream.
+func streamAEAD(alg Algorithm, key, salt []byte) (cipher.AEAD, error) {
+	if alg != AlgAesGcm && alg != AlgXChacha20poly1305 {
+		return nil, fmt.Errorf("%w: %v streams are not supported", ErrUnsupportedAlgorithm, alg)
+	}
+	if err := Key(key).ValidFor(alg); err != nil {
+		return nil, err
+	}
+
+	subKey, err := hkdf.Key(sha256.New, key, salt, streamKeyLabel+string([]byte{byte(alg)}), len(key))
+	if err != nil {
+		return nil, fmt.Errorf("error deriving stream key: %w", err)
+	}
+	defer clear(subKey)
+
+	if alg == AlgAesGcm {
+		return aesGCM(subKey)
+	}
+	aead, err := chacha20poly1305.NewX(subKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
+	}
+	return aead, nil
+}
+
+// streamNonce fills dst with the nonce of one chunk:
+// zero padding || counter (big-endian) || final flag.
+func streamNonce(dst []byte, counter uint64, final bool) {
+	n := len(dst) - 9
+	clear(dst[:n])
+	binary.BigEndian.PutUint64(dst[n:], counter)
+	dst[len(dst)-1] = 0
+	if final {
+		dst[len(dst)-1] = 1
+	}
+}
+
+// headerAAD returns header || additionalData, the data a format
+// authenticates alongside its ciphertext. Every header it is used with has a
+// length fixed by the format or the key, so the split is unambiguous.
+func headerAAD(header, additionalData []byte) []byte {
+	out := make([]byte, 0, len(header)+len(additionalData))
+	out = append(out, header...)
+	return append(out, additionalData...)
+}
+
+// StreamWriter encrypts everything written to it as a chained sequence of
+// AEAD chunks. Memory use stays at one chunk regardless of input size: the
+// buffer is allocated once and each chunk is sealed in place. The stream is
+// only complete once [StreamWriter.Close] has returned without error. A
+// StreamWriter is not safe for concurrent use.
+type StreamWriter struct {
+	dst     io.Writer   // where the ciphertext goes
+	aead    cipher.AEAD // per-stream key, derived from caller key + salt
+	aad     []byte      // header || caller AAD, authenticated with every chunk
+	nonce   []byte      // per-chunk nonce: padding || counter || final flag
+	buf     []byte      // plaintext buffer, len == chunk size, cap == +tag
+	n       int         // bytes buffered
+	next    [1]byte     // ReadFrom look-ahead; a field, so it is not allocated per chunk
+	counter uint64      // big-endian chunk counter in the nonce
+	closed  bool        // true after Close, so a second call is a no-op
+	err     error       // sticky: a failed chunk stops the stream for good
+}
+
+// NewStreamWriter returns a [StreamWriter] that encrypts to dst under key
+// with [DefaultStreamChunkSize] chunks. alg is [AlgAesGcm] (16, 24 or 32-byte
+// key) or [AlgXChacha20poly1305] (32-byte key). additionalData (which may be
+// nil) is authenticated with every chunk but neither encrypted nor stored;
+// the reader must be given the identical value.
+//
+// The stream header is written to dst immediately. Close finalizes whatever
+// has been written so far, so call it only after the whole input went in,
+// otherwise the result is a valid stream of truncated data.
+func NewStreamWriter(alg Algorithm, key []byte, dst io.Writer, additionalData []byte) (*StreamWriter, error) {
+	return NewStreamWriterSize(alg, key, dst, additionalData, DefaultStreamChunkSize)
+}
+
+// NewStreamWriterSize is [NewStreamWriter] with a chunk size between
+// [MinStreamChunkSize] and [MaxStreamChunkSize]. The chunk size is stored in
+// the header, so readers need not know it.
+func NewStreamWriterSize(alg Algorithm, key []byte, dst io.Writer, additionalData []byte, chunkSize int) (*StreamWriter, error) {
+	return NewStreamWriterWithRand(alg, key, dst, additionalData, chunkSize, nil)
+}
+
+// NewStreamWriterWithRand is [NewStreamWriterSize] with an explicit source
+// for the per-stream salt. A nil random selects crypto/rand. Anything else
+// is meant for reproducible test vectors: a repeated salt repeats the stream
+// key, and with it every chunk nonce.
+func NewStreamWriterWithRand(alg Algorithm, key []byte, dst io.Writer, additionalData []byte, chunkSize int, random io.Reader) (*StreamWriter, error) {
+	if chunkSize < MinStreamChunkSize || chunkSize > MaxStreamChunkSize {
+		return nil, fmt.Errorf("%w: chunk size %d out of range", ErrInvalidParameters, chunkSize)
+	}
+
+	header := make([]byte, streamHeaderSize)
+	header[0] = streamVersion
+	header[1] = byte(alg)
+	// the range check above bounds chunkSize by MaxStreamChunkSize, so it
+	// always fits in the 32-bit field.
+	binary.BigEndian.PutUint32(header[2:], uint32(chunkSize)) // #nosec G115
+	salt := header[6:]
+	if _, err := io.ReadFull(randomSource(random), salt); err != nil {
+		return nil, fmt.Errorf("error generating salt: %w", err)
+	}
+
+	aead, err := streamAEAD(alg, key, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &StreamWriter{
+		dst:   dst,
+		aead:  aead,
+		aad:   headerAAD(header, additionalData),
+		nonce: make([]byte, aead.NonceSize()),
+		buf:   make([]byte, chunkSize, chunkSize+streamTagSize),
+	}, nil
+}
+
+// Write buffers p and seals a chunk whenever the buffer is full and more data
+// follows. The trailing chunk is held back for [StreamWriter.Close], which is
+// what marks it as final.
+func (w *StreamWriter) Write(p []byte) (int, error) {
+	if err := w.state(); err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		if w.n == len(w.buf) {
+			if err := w.seal(false); err != nil {
+				return written, err
+			}
+		}
+		c := copy(w.buf[w.n:], p)
+		w.n += c
+		p = p[c:]
+		written += c
+	}
+	return written, nil
+}
+
+// ReadFrom drains r into the stream without the intermediate copy Write
+// needs, which is what [io.Copy] picks up. It stops at the end of r; the
+// buffered remainder becomes the final chunk on [StreamWriter.Close].
+func (w *StreamWriter) ReadFrom(r io.Reader) (int64, error) {
+	if err := w.state(); err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for {
+		// fill the buffer; a short read means r is exhausted.
+		n, err := io.ReadFull(r, w.buf[w.n:])
+		w.n += n
+		total += int64(n)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return total, nil
+			}
+			return total, err
+		}
+
+		// a full buffer is only a non-final chunk if something follows it.
+		switch m, err := io.ReadFull(r, w.next[:]); {
+		case m == 1:
+		case errors.Is(err, io.EOF):
+			return total, nil
+		default:
+			return total, err
+		}
+		total++
+
+		if err := w.seal(false); err != nil {
+			return total, err
+		}
+		w.buf[0] = w.next[0]
+		w.n = 1
+	}
+}
+
+// Close seals the buffered remainder as the final chunk. It does not close
+// the underlying writer. Calling it more than once is a no-op.
+func (w *StreamWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.closed {
+		return nil
+	}
+
+	err := w.seal(true)
+	w.closed = true
+	clear(w.buf)
+	return err
+}
+
+// state reports whether the stream can still accept data.
+func (w *StreamWriter) state() error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.closed {
+		return ErrStreamClosed
+	}
+	return nil
+}
+
+// seal encrypts the buffered plaintext in place and writes it out. A failure
+// is sticky, so a half-written stream can never be finalized afterwards.
+func (w *StreamWriter) seal(final bool) error {
+	streamNonce(w.nonce, w.counter, final)
+
+	chunk := w.aead.Seal(w.buf[:0], w.nonce, w.buf[:w.n], w.aad)
+	if _, err := w.dst.Write(chunk); err != nil {
+		w.err = err
+		return err
+	}
+
+	w.counter++
+	w.n = 0
+	return nil
+}
+
+// abort wipes the buffer and blocks the stream without writing a final chunk.
+func (w *StreamWriter) abort() {
+	w.closed = true
+	clear(w.buf)
+}
+
+// StreamReader decrypts a stream produced by a [StreamWriter], one chunk at a
+// time, and returns [io.EOF] only after the chunk marked final has been
+// authenticated. A StreamReader is not safe for concurrent use.
+type StreamReader struct {
+	src      io.Reader   // where the ciphertext comes from
+	alg      Algorithm   // cipher named in the header
+	aead     cipher.AEAD // per-stream key, derived from caller key + salt
+	aad      []byte      // header || caller AAD, authenticated with every chunk
+	nonce    []byte      // per-chunk nonce: padding || counter || final flag
+	buf      []byte      // one ciphertext chunk, decrypted in place
+	plain    []byte      // decrypted bytes not yet handed out, aliases buf
+	carry    [1]byte     // Read look-ahead; a field, so it is not allocated per chunk
+	hasCarry bool        // true if carry is valid, so a full chunk can be final
+	counter  uint64      // big-endian chunk counter in the nonce
+	final    bool        // true if the last chunk was marked final
+	err      error       // sticky, io.EOF once the stream ended cleanly
+}
+
+// NewStreamReader returns a [StreamReader] over src, verifying
+// additionalData against the value given at encryption. It reads and checks
+// the stream header up front; the algorithm and chunk size come from it.
+func NewStreamReader(key []byte, src io.Reader, additionalData []byte) (*StreamReader, error) {
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		// too short to be a stream; anything else is the caller's I/O error
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, ErrCiphertextTooShort
+		}
+		return nil, err
+	}
+
+	if header[0] != streamVersion {
+		return nil, fmt.Errorf("%w: stream version %d", ErrUnsupportedVersion, header[0])
+	}
+	alg := Algorithm(header[1])
+	chunkSize := binary.BigEndian.Uint32(header[2:6])
+	if chunkSize < MinStreamChunkSize || chunkSize > MaxStreamChunkSize {
+		return nil, fmt.Errorf("%w: chunk size %d out of range", ErrMalformedCiphertext, chunkSize)
+	}
+
+	aead, err := streamAEAD(alg, key, header[6:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamReader{
+		src:   src,
+		alg:   alg,
+		aead:  aead,
+		aad:   headerAAD(header, additionalData),
+		nonce: make([]byte, aead.NonceSize()),
+		buf:   make([]byte, chunkSize+streamTagSize),
+	}, nil
+}
+
+// Algorithm returns the cipher named in the stream header.
+func (r *StreamReader) Algorithm() Algorithm {
+	return r.alg
+}
+
+// Read fills p from the current chunk, decrypting the next one when it runs
+// out. A stream that ends without an authentic final chunk fails with
+// [ErrAuthenticationFailed] rather than reporting a clean [io.EOF].
+func (r *StreamReader) Read(p []byte) (int, error) {
+	for {
+		if len(r.plain) > 0 {
+			n := copy(p, r.plain)
+			r.plain = r.plain[n:]
+			return n, nil
+		}
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.final {
+			r.fail(io.EOF)
+			return 0, r.err
+		}
+		if err := r.readChunk(); err != nil {
+			r.fail(err)
+			return 0, r.err
+		}
+	}
+}
+
+// WriteTo drains the stream into dst a whole chunk at a time, which is what
+// [io.Copy] picks up.
+func (r *StreamReader) WriteTo(dst io.Writer) (int64, error) {
+	var total int64
+	for {
+		if len(r.plain) == 0 {
+			if r.err != nil {
+				if errors.Is(r.err, io.EOF) {
+					return total, nil
+				}
+				return total, r.err
+			}
+			if r.final {
+				r.fail(io.EOF)
+				return total, nil
+			}
+			if err := r.readChunk(); err != nil {
+				r.fail(err)
+				return total, r.err
+			}
+			continue
+		}
+
+		n, err := dst.Write(r.plain)
+		r.plain = r.plain[n:]
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+}
+
+// readChunk reads the next ciphertext chunk and decrypts it in place. A chunk
+// is final if it is short or if nothing follows it, which is why a full chunk
+// costs a one-byte look-ahead.
+func (r *StreamReader) readChunk() error {
+	n := 0
+	if r.hasCarry {
+		r.buf[0] = r.carry[0]
+		r.hasCarry = false
+		n = 1
+	}
+
+	m, err := io.ReadFull(r.src, r.buf[n:])
+	n += m
+	switch {
+	case err == nil:
+		switch k, e := io.ReadFull(r.src, r.carry[:]); {
+		case k == 1:
+			r.hasCarry = true
+		case errors.Is(e, io.EOF):
+			r.final = true
+		default:
+			return e
+		}
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		r.final = true
+	default:
+		return err
+	}
+
+	if n < streamTagSize {
+		return ErrCiphertextTooShort
+	}
+
+	streamNonce(r.nonce, r.counter, r.final)
+	plain, err := r.aead.Open(r.buf[:0], r.nonce, r.buf[:n], r.aad)
+	if err != nil {
+		return ErrAuthenticationFailed
+	}
+
+	r.counter++
+	r.plain = plain
+	return nil
+}
+
+// fail records the terminal state (io.EOF for a clean end) and wipes the
+// plaintext left in the buffer.
+func (r *StreamReader) fail(err error) {
+	r.err = err
+	r.plain = nil
+	clear(r.buf)
+}
+
+// encryptStream seals everything readable from src to dst and returns the
+// number of plaintext bytes sealed. On error the stream is abandoned without
+// a final chunk, so a partial dst can never be opened as a complete one.
+func encryptStream(alg Algorithm, key []byte, dst io.Writer, src io.Reader, additionalData []byte) (int64, error) {
+	w, err := NewStreamWriter(alg, key, dst, additionalData)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.ReadFrom(src)
+	if err != nil {
+		w.abort()
+		return n, err
+	}
+	return n, w.Close()
+}
+
+// decryptStream opens a stream from src into dst, requiring it to be sealed
+// with alg, and returns the number of plaintext bytes written.
+func decryptStream(alg Algorithm, key []byte, dst io.Writer, src io.Reader, additionalData []byte) (int64, error) {
+	r, err := NewStreamReader(key, src, additionalData)
+	if err != nil {
+		return 0, err
+	}
+	if r.alg != alg {
+		return 0, fmt.Errorf("%w: stream algorithm %v does not match %v", ErrUnsupportedAlgorithm, r.alg, alg)
+	}
+	return r.WriteTo(dst)
+}
+
+// EncryptStreamAesGcm encrypts everything readable from src to dst under an
+// AES key of 16, 24 or 32 bytes, binding additionalData (which may be nil)
+// into every chunk, and returns the number of plaintext bytes encrypted.
+func EncryptStreamAesGcm(key []byte, dst io.Writer, src io.Reader, additionalData []byte) (int64, error) {
+	return encryptStream(AlgAesGcm, key, dst, src, additionalData)
+}
+
+// DecryptStreamAesGcm decrypts a stream written by [EncryptStreamAesGcm]
+// from src into dst and returns the number of plaintext bytes written.
+// Chunks are written out as they authenticate, so a stream that fails
+// part-way has already produced output: treat dst as unusable unless the
+// call returns without error.
+func DecryptStreamAesGcm(key []byte, dst io.Writer, src io.Reader, additionalData []byte) (int64, error) {
+	return decryptStream(AlgAesGcm, key, dst, src, additionalData)
+}
+
+// EncryptStreamXChacha20poly1305 encrypts everything readable from src to
+// dst under a 32-byte key, binding additionalData (which may be nil) into
+// every chunk, and returns the number of plaintext bytes encrypted.
+func EncryptStreamXChacha20poly1305(key []byte, dst io.Writer, src io.Reader, additionalData []byte) (int64, error) {
+	return encryptStream(AlgXChacha20poly1305, key, dst, src, additionalData)
+}
+
+// DecryptStreamXChacha20poly1305 decrypts a stream written by
+// [EncryptStreamXChacha20poly1305] from src into dst and returns the number
+// of plaintext bytes written. As with [DecryptStreamAesGcm], treat dst as
+// unusable unless the call returns without error.
+func DecryptStreamXChacha20poly1305(key []byte, dst io.Writer, src io.Reader, additionalData []byte) (int64, error) {
+	return decryptStream(AlgXChacha20poly1305, key, dst, src, additionalData)
+}
@@ -0,0 +1,315 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"testing"
+)
+
+// streamChunk keeps the multi-chunk tests cheap: the smallest chunk the
+// format allows.
+const streamChunk = MinStreamChunkSize
+
+// streamAlgs lists the stream ciphers with a matching key size.
+var streamAlgs = []struct {
+	alg     Algorithm
+	keySize int
+}{
+	{AlgAesGcm, 16},
+	{AlgAesGcm, 32},
+	{AlgXChacha20poly1305, 32},
+}
+
+// sealStream encrypts plaintext into a small-chunk stream.
+func sealStream(t *testing.T, alg Algorithm, key, plaintext, aad []byte) []byte {
+	t.Helper()
+	var sealed bytes.Buffer
+	w, err := NewStreamWriterSize(alg, key, &sealed, aad, streamChunk)
+	if err != nil {
+		t.Fatalf("NewStreamWriterSize: %v", err)
+	}
+	if _, err := w.ReadFrom(bytes.NewReader(plaintext)); err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return sealed.Bytes()
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	sizes := []int{0, 1, streamChunk - 1, streamChunk, streamChunk + 1, 3*streamChunk + 7}
+
+	for _, a := range streamAlgs {
+		t.Run(a.alg.String(), func(t *testing.T) {
+			key := mustBytes(t, a.keySize)
+			for _, n := range sizes {
+				plaintext := mustBytes(t, n)
+				aad := []byte("file:42")
+				sealed := sealStream(t, a.alg, key, plaintext, aad)
+
+				chunks := max(1, (n+streamChunk-1)/streamChunk)
+				if want := streamHeaderSize + n + chunks*streamTagSize; len(sealed) != want {
+					t.Errorf("n=%d: ciphertext len = %d, want %d", n, len(sealed), want)
+				}
+
+				// Read path
+				r, err := NewStreamReader(key, bytes.NewReader(sealed), aad)
+				if err != nil {
+					t.Fatalf("n=%d: NewStreamReader: %v", n, err)
+				}
+				if r.Algorithm() != a.alg {
+					t.Errorf("n=%d: Algorithm = %v, want %v", n, r.Algorithm(), a.alg)
+				}
+				got, err := io.ReadAll(r)
+				if err != nil || !bytes.Equal(got, plaintext) {
+					t.Errorf("n=%d: ReadAll mismatch, err = %v", n, err)
+				}
+
+				// WriteTo path
+				var opened bytes.Buffer
+				if _, err := decryptStream(a.alg, key, &opened, bytes.NewReader(sealed), aad); err != nil {
+					t.Fatalf("n=%d: decrypt: %v", n, err)
+				}
+				if !bytes.Equal(opened.Bytes(), plaintext) {
+					t.Errorf("n=%d: WriteTo mismatch", n)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamFunctions(t *testing.T) {
+	key := mustBytes(t, 32)
+	plaintext := mustBytes(t, 3*DefaultStreamChunkSize+5)
+
+	funcs := []struct {
+		name string
+		enc  func(key []byte, dst io.Writer, src io.Reader, aad []byte) (int64, error)
+		dec  func(key []byte, dst io.Writer, src io.Reader, aad []byte) (int64, error)
+	}{
+		{"AES-GCM", EncryptStreamAesGcm, DecryptStreamAesGcm},
+		{"XChaCha20-Poly1305", EncryptStreamXChacha20poly1305, DecryptStreamXChacha20poly1305},
+	}
+	for i, f := range funcs {
+		t.Run(f.name, func(t *testing.T) {
+			var sealed bytes.Buffer
+			n, err := f.enc(key, &sealed, bytes.NewReader(plaintext), nil)
+			if err != nil || n != int64(len(plaintext)) {
+				t.Fatalf("encrypt = %d, %v", n, err)
+			}
+
+			var opened bytes.Buffer
+			n, err = f.dec(key, &opened, bytes.NewReader(sealed.Bytes()), nil)
+			if err != nil || n != int64(len(plaintext)) || !bytes.Equal(opened.Bytes(), plaintext) {
+				t.Fatalf("decrypt = %d, %v", n, err)
+			}
+
+			// the other algorithm's decrypt function refuses the stream
+			other := funcs[1-i]
+			if _, err := other.dec(key, io.Discard, bytes.NewReader(sealed.Bytes()), nil); !errors.Is(err, ErrUnsupportedAlgorithm) {
+				t.Errorf("cross-algorithm decrypt err = %v, want ErrUnsupportedAlgorithm", err)
+			}
+		})
+	}
+}
+
+func TestStreamWriteDribble(t *testing.T) {
+	key := mustBytes(t, 32)
+	plaintext := mustBytes(t, 2*streamChunk+3)
+
+	var sealed bytes.Buffer
+	w, err := NewStreamWriterSize(AlgXChacha20poly1305, key, &sealed, nil, streamChunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for off := 0; off < len(plaintext); off += 7 {
+		if _, err := w.Write(plaintext[off:min(off+7, len(plaintext))]); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := w.Write([]byte("late")); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Write after Close err = %v, want ErrStreamClosed", err)
+	}
+
+	r, err := NewStreamReader(key, &sealed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("round-trip mismatch, err = %v", err)
+	}
+}
+
+func TestStreamIntegrity(t *testing.T) {
+	for _, a := range streamAlgs {
+		t.Run(a.alg.String(), func(t *testing.T) {
+			key := mustBytes(t, a.keySize)
+			aad := []byte("ctx")
+			// three full chunks plus a short final one
+			sealed := sealStream(t, a.alg, key, mustBytes(t, 3*streamChunk+11), aad)
+			chunk := streamChunk + streamTagSize
+
+			tests := []struct {
+				name   string
+				mangle func(b []byte) []byte
+				key    []byte
+				aad    []byte
+				want   error
+			}{
+				{name: "wrongKey", key: mustBytes(t, a.keySize), want: ErrAuthenticationFailed},
+				{name: "wrongAAD", aad: []byte("other"), want: ErrAuthenticationFailed},
+				{name: "flipCiphertext", mangle: func(b []byte) []byte { b[streamHeaderSize+5] ^= 1; return b }, want: ErrAuthenticationFailed},
+				{name: "flipSalt", mangle: func(b []byte) []byte { b[streamHeaderSize-1] ^= 1; return b }, want: ErrAuthenticationFailed},
+				{name: "flipVersion", mangle: func(b []byte) []byte { b[0] ^= 0xFF; return b }, want: ErrUnsupportedVersion},
+				{name: "unknownAlgorithm", mangle: func(b []byte) []byte { b[1] = 0x7F; return b }, want: ErrUnsupportedAlgorithm},
+				{name: "zeroChunkSize", mangle: func(b []byte) []byte { clear(b[2:6]); return b }, want: ErrMalformedCiphertext},
+				{name: "dropFinalChunk", mangle: func(b []byte) []byte { return b[:len(b)-(11+streamTagSize)] }, want: ErrAuthenticationFailed},
+				{name: "truncateMidChunk", mangle: func(b []byte) []byte { return b[:len(b)-7] }, want: ErrAuthenticationFailed},
+				{name: "swapChunks", mangle: func(b []byte) []byte {
+					first := b[streamHeaderSize : streamHeaderSize+chunk]
+					second := b[streamHeaderSize+chunk : streamHeaderSize+2*chunk]
+					tmp := append([]byte{}, first...)
+					copy(first, second)
+					copy(second, tmp)
+					return b
+				}, want: ErrAuthenticationFailed},
+				{name: "headerOnly", mangle: func(b []byte) []byte { return b[:streamHeaderSize] }, want: ErrCiphertextTooShort},
+				{name: "truncatedHeader", mangle: func(b []byte) []byte { return b[:streamHeaderSize-1] }, want: ErrCiphertextTooShort},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					data := append([]byte{}, sealed...)
+					if tt.mangle != nil {
+						data = tt.mangle(data)
+					}
+					k, ad := key, aad
+					if tt.key != nil {
+						k = tt.key
+					}
+					if tt.aad != nil {
+						ad = tt.aad
+					}
+					var out bytes.Buffer
+					r, err := NewStreamReader(k, bytes.NewReader(data), ad)
+					if err == nil {
+						_, err = r.WriteTo(&out)
+					}
+					if !errors.Is(err, tt.want) {
+						t.Errorf("err = %v, want %v", err, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestStreamWriterErrors(t *testing.T) {
+	if _, err := NewStreamWriter(AlgChacha20poly1305, mustBytes(t, 32), io.Discard, nil); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("ChaCha20 stream err = %v, want ErrUnsupportedAlgorithm", err)
+	}
+	if _, err := NewStreamWriter(AlgXChacha20poly1305, mustBytes(t, 16), io.Discard, nil); !errors.Is(err, ErrInvalidKeySize) {
+		t.Errorf("short XChaCha20 key err = %v, want ErrInvalidKeySize", err)
+	}
+	if _, err := NewStreamWriterSize(AlgAesGcm, mustBytes(t, 32), io.Discard, nil, MinStreamChunkSize-1); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("small chunk err = %v, want ErrInvalidParameters", err)
+	}
+}
+
+func TestStreamKnownAnswer(t *testing.T) {
+	// Pins the root stream format: a fixed salt source makes the output
+	// reproducible. The multi-chunk vector is checked by digest to keep it
+	// short; its plaintext is two full chunks plus a tail.
+	salt := bytes.Repeat([]byte{0x42}, streamSaltSize)
+	vectors := []struct {
+		name      string
+		alg       Algorithm
+		key       []byte
+		aad       []byte
+		plaintext []byte
+		want      string // hex of the stream, or of its SHA-256 if digest
+		digest    bool
+	}{
+		{
+			name:      "AES-128-GCM",
+			alg:       AlgAesGcm,
+			key:       bytes.Repeat([]byte{0x01}, 16),
+			plaintext: []byte("pilinux/crypt stream"),
+			want:      "01010000040042424242424242424242424242424242dca3f8ca9f1d5fa572599e009b5a7b03aed6ea3ef649e00c02664abf067a809409702591",
+		},
+		{
+			name:      "XChaCha20-Poly1305/AAD",
+			alg:       AlgXChacha20poly1305,
+			key:       bytes.Repeat([]byte{0x02}, 32),
+			aad:       []byte("file:42"),
+			plaintext: []byte("pilinux/crypt stream"),
+			want:      "01030000040042424242424242424242424242424242c918dbe49b273b10cc09a2b173b57cf324bd67d0222ae2019324e6b06bfa65e3aea86039",
+		},
+		{
+			name:      "AES-256-GCM/empty",
+			alg:       AlgAesGcm,
+			key:       bytes.Repeat([]byte{0x03}, 32),
+			plaintext: nil,
+			want:      "0101000004004242424242424242424242424242424226c50bfbcbf390302b23c7b928ac1131",
+		},
+		{
+			name:      "AES-256-GCM/multiChunk",
+			alg:       AlgAesGcm,
+			key:       bytes.Repeat([]byte{0x04}, 32),
+			aad:       []byte("file:42"),
+			plaintext: bytes.Repeat([]byte("0123456789abcdef"), (2*streamChunk+24)/16),
+			want:      "6ab93ecd1d496489ec7591de75dca25b20925fd86f385cc6c4e96d27a8e965e0",
+			digest:    true,
+		},
+	}
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			var sealed bytes.Buffer
+			w, err := NewStreamWriterWithRand(v.alg, v.key, &sealed, v.aad, streamChunk, bytes.NewReader(salt))
+			if err != nil {
+				t.Fatalf("NewStreamWriterWithRand: %v", err)
+			}
+			if _, err := w.Write(v.plaintext); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if got := sealed.Bytes()[6:streamHeaderSize]; !bytes.Equal(got, salt) {
+				t.Errorf("salt = %x, want %x", got, salt)
+			}
+			got := sealed.Bytes()
+			if v.digest {
+				sum := sha256.Sum256(got)
+				got = sum[:]
+			}
+			if !bytes.Equal(got, mustHex(t, v.want)) {
+				t.Errorf("stream = %x\nwant     %s", got, v.want)
+			}
+
+			var opened bytes.Buffer
+			if _, err := decryptStream(v.alg, v.key, &opened, bytes.NewReader(sealed.Bytes()), v.aad); err != nil {
+				t.Fatalf("decrypt: %v", err)
+			}
+			if !bytes.Equal(opened.Bytes(), v.plaintext) {
+				t.Errorf("opened = %q, want %q", opened.Bytes(), v.plaintext)
+			}
+		})
+	}
+}
+
+func TestStreamWriterRandError(t *testing.T) {
+	readErr := errors.New("entropy exhausted")
+	if _, err := NewStreamWriterWithRand(AlgAesGcm, mustBytes(t, 32), io.Discard, nil, streamChunk, errReader{readErr}); !errors.Is(err, readErr) {
+		t.Errorf("err = %v, want %v", err, readErr)
+	}
+}
@@ -0,0 +1,208 @@
+package crypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+)
+
+// xaesGcmNonceSize is the XAES-256-GCM nonce length (192-bit). The first 96
+// bits select the derived key, the last 96 bits are the AES-GCM nonce.
+const xaesGcmNonceSize = 24
+
+// xaesGcm implements XAES-256-GCM as specified at c2sp.org/XAES-256-GCM: an
+// AES-256-GCM variant with a 192-bit nonce, safe to draw at random for an
+// effectively unlimited number of messages under one key. Every message is
+// sealed under its own AES-256 key derived from the first half of the nonce
+// with the NIST SP 800-108r1 counter-mode KDF on top of AES-CMAC, so only
+// standard AES building blocks are used.
+type xaesGcm struct {
+	mac *cmac // AES-256 under the caller key, with its CMAC subkey K1
+}
+
+// newXAesGcm prepares XAES-256-GCM for the given 256-bit key.
+func newXAesGcm(key []byte) (*xaesGcm, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%w: XAES-256-GCM takes a 32-byte key, got %d", ErrInvalidKeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeySize, err)
+	}
+	return &xaesGcm{mac: newCMAC(block)}, nil
+}
+
+// aead derives the per-message AES-256-GCM key from the first 12 bytes of
+// nonce: Kx = CMAC(K, 0x00 0x01 'X' 0x00 || N[:12]) || CMAC(K, 0x00 0x02 'X' 0x00 || N[:12]).
+// Each input is a single full block, so the CMAC is AES_K(M xor K1).
+func (x *xaesGcm) aead(nonce []byte) (cipher.AEAD, error) {
+	var m [cmacBlockSize]byte
+	m[2] = 'X'
+	copy(m[4:], nonce[:12])
+
+	var kx [32]byte
+	m[1] = 0x01
+	k := x.mac.sum(m[:])
+	copy(kx[:16], k[:])
+	m[1] = 0x02
+	k = x.mac.sum(m[:])
+	copy(kx[16:], k[:])
+
+	aead, err := aesGCM(kx[:])
+	clear(kx[:])
+	clear(k[:])
+	return aead, err
+}
+
+// encryptByteXAesGcm is the shared XAES-256-GCM encryption core. It seals
+// input under key with a fresh random 192-bit nonce and additionally
+// authenticates additionalData (which may be nil).
+func encryptByteXAesGcm(key, input, additionalData []byte) (ciphertext []byte, nonce []byte, err error) {
+	x, err := newXAesGcm(key)
+	if err != nil {
+		return
+	}
+
+	// reject oversized input so Seal returns an error rather than panicking
+	if uint64(len(input)) > gcmMaxPlaintextSize {
+		err = ErrPlaintextTooLarge
+		return
+	}
+
+	// generate a 192-bit random nonce
+	nonce = make([]byte, xaesGcmNonceSize)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		err = fmt.Errorf("error generating nonce: %w", err)
+		return
+	}
+
+	aead, err := x.aead(nonce)
+	if err != nil {
+		return
+	}
+
+	// encrypt the data under the derived key and the second half of the nonce
+	ciphertext = aead.Seal(nil, nonce[12:], input, additionalData)
+	return
+}
+
+// decryptByteXAesGcm is the shared XAES-256-GCM decryption core.
+// additionalData must be the same value supplied at encryption (nil if none).
+func decryptByteXAesGcm(key, nonce, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	x, err := newXAesGcm(key)
+	if err != nil {
+		return
+	}
+
+	// reject a wrong-length nonce before it is sliced
+	if len(nonce) != xaesGcmNonceSize {
+		err = ErrInvalidNonce
+		return
+	}
+
+	aead, err := x.aead(nonce)
+	if err != nil {
+		return
+	}
+	return decryptByteAesGcm(aead, nonce[12:], ciphertext, additionalData)
+}
+
+// EncryptByteXAesGcm encrypts and authenticates the given message (bytes) with
+// XAES-256-GCM using the given 256-bit key and 192-bit nonce.
+func EncryptByteXAesGcm(key []byte, input []byte) (ciphertext []byte, nonce []byte, err error) {
+	return encryptByteXAesGcm(key, input, nil)
+}
+
+// EncryptXAesGcm encrypts and authenticates the given message (string) with
+// XAES-256-GCM using the given 256-bit key and 192-bit nonce.
+func EncryptXAesGcm(key []byte, text string) (ciphertext []byte, nonce []byte, err error) {
+	return EncryptByteXAesGcm(key, []byte(text))
+}
+
+// DecryptByteXAesGcm decrypts and authenticates the given ciphertext with
+// XAES-256-GCM using the given 256-bit key and 192-bit nonce.
+func DecryptByteXAesGcm(key, nonce, ciphertext []byte) (plaintext []byte, err error) {
+	return decryptByteXAesGcm(key, nonce, ciphertext, nil)
+}
+
+// DecryptXAesGcm decrypts and authenticates the given ciphertext with
+// XAES-256-GCM using the given 256-bit key and 192-bit nonce.
+func DecryptXAesGcm(key, nonce, ciphertext []byte) (text string, err error) {
+	// decrypt the data
+	plaintext, err := DecryptByteXAesGcm(key, nonce, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteXAesGcmWithNonceAppended encrypts and authenticates the given message (bytes) with
+// XAES-256-GCM using the given 256-bit key and 192-bit nonce.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptByteXAesGcmWithNonceAppended(key []byte, input []byte) (ciphertext []byte, err error) {
+	return EncryptByteXAesGcmWithNonceAppendedAAD(key, input, nil)
+}
+
+// EncryptXAesGcmWithNonceAppended encrypts and authenticates the given message (string) with
+// XAES-256-GCM using the given 256-bit key and 192-bit nonce.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptXAesGcmWithNonceAppended(key []byte, text string) (ciphertext []byte, err error) {
+	return EncryptByteXAesGcmWithNonceAppended(key, []byte(text))
+}
+
+// DecryptByteXAesGcmWithNonceAppended decrypts and authenticates the given ciphertext with
+// XAES-256-GCM using the given 256-bit key and 192-bit nonce.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptByteXAesGcmWithNonceAppended(key, ciphertext []byte) (plaintext []byte, err error) {
+	return DecryptByteXAesGcmWithNonceAppendedAAD(key, ciphertext, nil)
+}
+
+// DecryptXAesGcmWithNonceAppended decrypts and authenticates the given ciphertext with
+// XAES-256-GCM using the given 256-bit key and 192-bit nonce.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptXAesGcmWithNonceAppended(key, ciphertext []byte) (text string, err error) {
+	plaintext, err := DecryptByteXAesGcmWithNonceAppended(key, ciphertext)
+	if err != nil {
+		return
+	}
+
+	text = string(plaintext)
+	return
+}
+
+// EncryptByteXAesGcmWithNonceAppendedAAD encrypts and authenticates the given
+// message (bytes) with XAES-256-GCM using the given 256-bit key and 192-bit nonce,
+// and additionally authenticates additionalData (AAD). The AAD is neither encrypted nor
+// included in the output; the identical bytes must be supplied at decryption. A nil AAD
+// makes this equivalent to EncryptByteXAesGcmWithNonceAppended.
+// It appends the ciphertext to the nonce [ciphertext = nonce + ciphertext].
+func EncryptByteXAesGcmWithNonceAppendedAAD(key, input, additionalData []byte) (ciphertext []byte, err error) {
+	ciphertext, nonce, err := encryptByteXAesGcm(key, input, additionalData)
+	if err != nil {
+		return
+	}
+
+	ciphertext = append(nonce, ciphertext...)
+	return
+}
+
+// DecryptByteXAesGcmWithNonceAppendedAAD decrypts and authenticates the given
+// ciphertext with XAES-256-GCM using the given 256-bit key and 192-bit nonce,
+// verifying additionalData (AAD) against the value supplied at encryption. Decryption
+// fails if the AAD differs. A nil AAD makes this equivalent to
+// DecryptByteXAesGcmWithNonceAppended.
+// It expects the ciphertext along with the nonce [ciphertext = nonce + ciphertext].
+func DecryptByteXAesGcmWithNonceAppendedAAD(key, ciphertext, additionalData []byte) (plaintext []byte, err error) {
+	if len(ciphertext) < xaesGcmNonceSize {
+		err = ErrCiphertextTooShort
+		return
+	}
+
+	nonce, ciphertext := ciphertext[:xaesGcmNonceSize], ciphertext[xaesGcmNonceSize:]
+	return decryptByteXAesGcm(key, nonce, ciphertext, additionalData)
+}
@@ -0,0 +1,162 @@
+package crypt
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestXAesGcmKnownAnswer(t *testing.T) {
+	// c2sp.org/XAES-256-GCM test vectors
+	vectors := []struct {
+		name      string
+		key       []byte
+		aad       []byte
+		plaintext string
+		want      string
+	}{
+		{
+			name:      "noAAD",
+			key:       bytes.Repeat([]byte{0x01}, 32),
+			plaintext: "XAES-256-GCM",
+			want:      "ce546ef63c9cc60765923609b33a9a1974e96e52daf2fcf7075e2271",
+		},
+		{
+			name:      "AAD",
+			key:       bytes.Repeat([]byte{0x03}, 32),
+			aad:       []byte("c2sp.org/XAES-256-GCM"),
+			plaintext: "XAES-256-GCM",
+			want:      "986ec1832593df5443a179437fd083bf3fdb41abd740a21f71eb769d",
+		},
+	}
+	nonce := []byte("ABCDEFGHIJKLMNOPQRSTUVWX")
+
+	for _, v := range vectors {
+		t.Run(v.name, func(t *testing.T) {
+			x, err := newXAesGcm(v.key)
+			if err != nil {
+				t.Fatalf("newXAesGcm: %v", err)
+			}
+			aead, err := x.aead(nonce)
+			if err != nil {
+				t.Fatalf("derive: %v", err)
+			}
+			want := mustHex(t, v.want)
+			if got := aead.Seal(nil, nonce[12:], []byte(v.plaintext), v.aad); !bytes.Equal(got, want) {
+				t.Errorf("Seal = %x, want %x", got, want)
+			}
+
+			blob := append(bytes.Clone(nonce), want...)
+			got, err := DecryptByteXAesGcmWithNonceAppendedAAD(v.key, blob, v.aad)
+			if err != nil {
+				t.Fatalf("DecryptByteXAesGcmWithNonceAppendedAAD: %v", err)
+			}
+			if string(got) != v.plaintext {
+				t.Errorf("decrypt = %q, want %q", got, v.plaintext)
+			}
+		})
+	}
+}
+
+func TestXAesGcmRoundTrip(t *testing.T) {
+	const text = "the quick brown fox jumps over the lazy dog"
+	const tagSize = 16
+	key := mustBytes(t, 32)
+
+	t.Run("string", func(t *testing.T) {
+		ciphertext, nonce, err := EncryptXAesGcm(key, text)
+		if err != nil {
+			t.Fatalf("EncryptXAesGcm: %v", err)
+		}
+		if len(nonce) != xaesGcmNonceSize {
+			t.Errorf("nonce len = %d, want %d", len(nonce), xaesGcmNonceSize)
+		}
+		got, err := DecryptXAesGcm(key, nonce, ciphertext)
+		if err != nil {
+			t.Fatalf("DecryptXAesGcm: %v", err)
+		}
+		if got != text {
+			t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+		}
+	})
+
+	t.Run("nonceAppended", func(t *testing.T) {
+		ciphertext, err := EncryptXAesGcmWithNonceAppended(key, text)
+		if err != nil {
+			t.Fatalf("EncryptXAesGcmWithNonceAppended: %v", err)
+		}
+		if want := xaesGcmNonceSize + len(text) + tagSize; len(ciphertext) != want {
+			t.Errorf("ciphertext len = %d, want %d (nonce+plaintext+tag)", len(ciphertext), want)
+		}
+		got, err := DecryptXAesGcmWithNonceAppended(key, ciphertext)
+		if err != nil {
+			t.Fatalf("DecryptXAesGcmWithNonceAppended: %v", err)
+		}
+		if got != text {
+			t.Errorf("round-trip mismatch: got %q, want %q", got, text)
+		}
+	})
+
+	t.Run("nonceAppendedAAD", func(t *testing.T) {
+		aad := []byte("record:42")
+		ciphertext, err := EncryptByteXAesGcmWithNonceAppendedAAD(key, []byte(text), aad)
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		got, err := DecryptByteXAesGcmWithNonceAppendedAAD(key, ciphertext, aad)
+		if err != nil || string(got) != text {
+			t.Errorf("decrypt = %q, %v", got, err)
+		}
+		if _, err := DecryptByteXAesGcmWithNonceAppendedAAD(key, ciphertext, []byte("record:7")); err == nil {
+			t.Error("decryption with wrong AAD succeeded, want failure")
+		}
+		if _, err := DecryptByteXAesGcmWithNonceAppended(key, ciphertext); err == nil {
+			t.Error("decryption without AAD succeeded, want failure")
+		}
+	})
+}
+
+func TestXAesGcmErrors(t *testing.T) {
+	key := mustBytes(t, 32)
+
+	t.Run("invalidKeySize", func(t *testing.T) {
+		// XAES is defined for AES-256 only
+		for _, size := range []int{0, 16, 24, 33} {
+			if _, _, err := EncryptXAesGcm(make([]byte, size), "x"); err == nil {
+				t.Errorf("encrypt with %d-byte key succeeded, want error", size)
+			}
+		}
+	})
+
+	t.Run("tamperedNonce", func(t *testing.T) {
+		// both halves of the nonce matter: the first picks the key
+		ciphertext, err := EncryptXAesGcmWithNonceAppended(key, "secret")
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		for _, i := range []int{0, 11, 12, 23} {
+			bad := bytes.Clone(ciphertext)
+			bad[i] ^= 0x01
+			if _, err := DecryptByteXAesGcmWithNonceAppended(key, bad); err == nil {
+				t.Errorf("decryption with nonce byte %d flipped succeeded, want failure", i)
+			}
+		}
+	})
+
+	t.Run("tooShort", func(t *testing.T) {
+		if _, err := DecryptByteXAesGcmWithNonceAppended(key, make([]byte, xaesGcmNonceSize-1)); err == nil {
+			t.Error("expected error for too-short ciphertext, got nil")
+		}
+	})
+
+	t.Run("wrongNonceLength", func(t *testing.T) {
+		ciphertext, _, err := EncryptByteXAesGcm(key, []byte("secret"))
+		if err != nil {
+			t.Fatalf("encrypt: %v", err)
+		}
+		for _, badLen := range []int{0, 12, xaesGcmNonceSize - 1, xaesGcmNonceSize + 1} {
+			if _, err := DecryptByteXAesGcm(key, make([]byte, badLen), ciphertext); err == nil {
+				t.Errorf("decrypt with %d-byte nonce succeeded, want error", badLen)
+			}
+		}
+	})
+}
@@ -0,0 +1,40 @@
+//go:build go1.26
+
+package crypt
+
+import (
+	"bytes"
+	"crypto/mlkem/mlkemtest"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestXWingEncapsulateVectors(t *testing.T) {
+	// The eseed of each vector is split as the draft specifies: the first
+	// 32 bytes drive ML-KEM-768 through mlkemtest, which only exists since
+	// Go 1.26, and the rest is the X25519 ephemeral key.
+	b, err := os.ReadFile(filepath.Join("testdata", "xwing.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []struct {
+		PublicKey, Eseed, Ciphertext, SharedSecret string
+	}
+	if err := json.Unmarshal(b, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		ss, ct, err := xwingEncapsulate(bytes.NewReader(mustHex(t, v.Eseed)), mlkemtest.Encapsulate768, mustHex(t, v.PublicKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := mustHex(t, v.Ciphertext); !bytes.Equal(ct, want) {
+			t.Errorf("ciphertext = %x, want %x", ct, want)
+		}
+		if want := mustHex(t, v.SharedSecret); !bytes.Equal(ss, want) {
+			t.Errorf("shared secret = %x, want %x", ss, want)
+		}
+	}
+}